@@ -0,0 +1,139 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cms parses and verifies the SignedData content type of the
+// Cryptographic Message Syntax (CMS), also known as PKCS #7.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5652
+package cms
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+)
+
+// ContentInfo is the outer structure of a CMS message.
+//
+//	ContentInfo ::= SEQUENCE {
+//	 contentType  ContentType,
+//	 content      [0] EXPLICIT ANY DEFINED BY contentType }
+type ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// SignedData is the signed-data content type.
+//
+//	SignedData ::= SEQUENCE {
+//	 version           CMSVersion,
+//	 digestAlgorithms  DigestAlgorithmIdentifiers,
+//	 encapContentInfo  EncapsulatedContentInfo,
+//	 certificates      [0] IMPLICIT CertificateSet OPTIONAL,
+//	 crls              [1] IMPLICIT RevocationInfoChoices OPTIONAL,
+//	 signerInfos       SignerInfos }
+type SignedData struct {
+	Version                    int
+	DigestAlgorithmIdentifiers []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapsulatedContentInfo    EncapsulatedContentInfo
+	Certificates               asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs                       asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos                []SignerInfo  `asn1:"set"`
+}
+
+// EncapsulatedContentInfo is the signed content of SignedData.
+//
+//	EncapsulatedContentInfo ::= SEQUENCE {
+//	 eContentType  ContentType,
+//	 eContent      [0] EXPLICIT OCTET STRING OPTIONAL }
+type EncapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// SignerInfo is the per-signer information of SignedData.
+//
+//	SignerInfo ::= SEQUENCE {
+//	 version             CMSVersion,
+//	 sid                 SignerIdentifier,
+//	 digestAlgorithm     DigestAlgorithmIdentifier,
+//	 signedAttrs         [0] IMPLICIT SignedAttributes OPTIONAL,
+//	 signatureAlgorithm  SignatureAlgorithmIdentifier,
+//	 signature           SignatureValue,
+//	 unsignedAttrs       [1] IMPLICIT UnsignedAttributes OPTIONAL }
+//
+//	SignerIdentifier ::= CHOICE {
+//	 issuerAndSerialNumber  IssuerAndSerialNumber,
+//	 subjectKeyIdentifier   [0] SubjectKeyIdentifier }
+type SignerInfo struct {
+	Version            int
+	SignerIdentifier   asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttributes   Attributes `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttributes Attributes `asn1:"optional,tag:1"`
+}
+
+// IssuerAndSerialNumber identifies a certificate by its issuer name and
+// serial number.
+//
+//	IssuerAndSerialNumber ::= SEQUENCE {
+//	 issuer        Name,
+//	 serialNumber  CertificateSerialNumber }
+type IssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// Attribute is a signed or unsigned attribute of a SignerInfo.
+//
+//	Attribute ::= SEQUENCE {
+//	 attrType    OBJECT IDENTIFIER,
+//	 attrValues  SET OF AttributeValue }
+type Attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// Attributes is a set of attributes.
+type Attributes []Attribute
+
+// Get unmarshals the single value of the attribute identified by oid into
+// out.
+func (a Attributes) Get(oid asn1.ObjectIdentifier, out interface{}) error {
+	for _, attr := range a {
+		if !attr.Type.Equal(oid) {
+			continue
+		}
+		rest, err := asn1.Unmarshal(attr.Values.Bytes, out)
+		if err != nil {
+			return &SyntaxError{Msg: "malformed attribute value", Err: err}
+		}
+		if len(rest) != 0 {
+			return &SyntaxError{Msg: "attribute must have exactly one value"}
+		}
+		return nil
+	}
+	return ErrAttributeNotFound
+}
+
+// Has reports whether the attribute identified by oid is present.
+func (a Attributes) Has(oid asn1.ObjectIdentifier) bool {
+	for _, attr := range a {
+		if attr.Type.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
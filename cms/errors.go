@@ -0,0 +1,64 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cms
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotSignedData is returned if the content type of the ContentInfo is not
+// id-signedData.
+var ErrNotSignedData = errors.New("cms: content type is not signed data")
+
+// ErrAttributeNotFound is returned if the requested attribute is not present.
+var ErrAttributeNotFound = errors.New("cms: attribute not found")
+
+// SyntaxError is used when the CMS structure is malformed.
+type SyntaxError struct {
+	Msg string
+	Err error
+}
+
+// Error returns the formatted error message.
+func (e *SyntaxError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("cms: syntax error: %s: %s", e.Msg, e.Err.Error())
+	}
+	return fmt.Sprintf("cms: syntax error: %s", e.Msg)
+}
+
+// Unwrap returns the unwrapped error.
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// VerificationError is used when a SignerInfo cannot be verified.
+type VerificationError struct {
+	Msg string
+	Err error
+}
+
+// Error returns the formatted error message.
+func (e *VerificationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("cms: verification failure: %s: %s", e.Msg, e.Err.Error())
+	}
+	return fmt.Sprintf("cms: verification failure: %s", e.Msg)
+}
+
+// Unwrap returns the unwrapped error.
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,96 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cms
+
+import "encoding/asn1"
+
+// Content type OIDs
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5652
+var (
+	// OIDData is the OID of the id-data content type.
+	OIDData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+
+	// OIDSignedData is the OID of the id-signedData content type.
+	OIDSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+// Attribute OIDs
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5652#section-11
+var (
+	// OIDAttributeContentType is the OID of the content-type attribute.
+	OIDAttributeContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+
+	// OIDAttributeMessageDigest is the OID of the message-digest attribute.
+	OIDAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	// OIDAttributeSigningTime is the OID of the signing-time attribute.
+	OIDAttributeSigningTime = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+)
+
+// Extended security services attribute OIDs
+//
+// References:
+//   - https://www.rfc-editor.org/rfc/rfc2634#section-2.9
+//   - https://www.rfc-editor.org/rfc/rfc3161#appendix-A
+//   - https://www.rfc-editor.org/rfc/rfc5035#section-3
+var (
+	// OIDAttributeContentHints is the OID of the content-hints attribute.
+	OIDAttributeContentHints = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 4}
+
+	// OIDAttributeSigningCertificate is the OID of the signing-certificate
+	// attribute.
+	OIDAttributeSigningCertificate = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 12}
+
+	// OIDAttributeSigningCertificateV2 is the OID of the
+	// signing-certificate-v2 attribute.
+	OIDAttributeSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+
+	// OIDAttributeTimeStampToken is the OID of the time-stamp token
+	// unsigned attribute.
+	OIDAttributeTimeStampToken = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 14}
+)
+
+// Digest algorithm OIDs
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5754#section-2
+var (
+	OIDDigestAlgorithmSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	OIDDigestAlgorithmSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	OIDDigestAlgorithmSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// Signature algorithm OIDs
+//
+// References:
+//   - https://www.rfc-editor.org/rfc/rfc8017#appendix-A.2
+//   - https://www.rfc-editor.org/rfc/rfc5758#section-3.2
+var (
+	OIDSignatureAlgorithmRSA             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	OIDSignatureAlgorithmRSAPSS          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	OIDSignatureAlgorithmSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	OIDSignatureAlgorithmSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	OIDSignatureAlgorithmSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	OIDSignatureAlgorithmECDSA           = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	OIDSignatureAlgorithmECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	OIDSignatureAlgorithmECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	OIDSignatureAlgorithmECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+)
+
+// OIDMaskGenerationFunctionMGF1 is the OID of the MGF1 mask generation
+// function used by RSASSA-PSS.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc8017#appendix-B.2.1
+var OIDMaskGenerationFunctionMGF1 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
@@ -0,0 +1,341 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+
+	nasn1 "github.com/notaryproject/notation-core-go/internal/encoding/asn1"
+)
+
+// ParsedSignedData is a parsed SignedData.
+type ParsedSignedData struct {
+	// Content is the encapsulated content. It is nil for detached
+	// signatures.
+	Content []byte
+
+	// ContentType is the type of the encapsulated content.
+	ContentType asn1.ObjectIdentifier
+
+	// Certificates are the certificates carried in the SignedData.
+	Certificates []*x509.Certificate
+
+	// CRLs are the CRLs carried in the SignedData.
+	CRLs []*x509.RevocationList
+
+	// Signers are the SignerInfos of the SignedData.
+	Signers []SignerInfo
+
+	// rawSignedAttributes are the encoded signed attributes of Signers in
+	// the same order, with the IMPLICIT [0] tag as parsed.
+	rawSignedAttributes [][]byte
+}
+
+// rawSignedData is SignedData with the fields unparsed except the
+// SignerInfos, which is used to capture the encoded signed attributes.
+type rawSignedData struct {
+	Version                    int
+	DigestAlgorithmIdentifiers asn1.RawValue
+	EncapsulatedContentInfo    asn1.RawValue
+	Certificates               asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs                       asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos                []rawSignerInfo `asn1:"set"`
+}
+
+// rawSignerInfo is SignerInfo with the fields unparsed.
+type rawSignerInfo struct {
+	Version            int
+	SignerIdentifier   asn1.RawValue
+	DigestAlgorithm    asn1.RawValue
+	SignedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm asn1.RawValue
+	Signature          asn1.RawValue
+	UnsignedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// ParseSignedData parses a ContentInfo with the signed-data content type.
+// The input can be BER-encoded, which is normalized to DER before parsing.
+func ParseSignedData(data []byte) (*ParsedSignedData, error) {
+	der, err := nasn1.ConvertToDER(data)
+	if err != nil {
+		return nil, &SyntaxError{Msg: "invalid encoding", Err: err}
+	}
+
+	var ci ContentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, &SyntaxError{Msg: "invalid content info", Err: err}
+	} else if len(rest) != 0 {
+		return nil, &SyntaxError{Msg: "trailing data after content info"}
+	}
+	if !ci.ContentType.Equal(OIDSignedData) {
+		return nil, ErrNotSignedData
+	}
+
+	var sd SignedData
+	if rest, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, &SyntaxError{Msg: "invalid signed data", Err: err}
+	} else if len(rest) != 0 {
+		return nil, &SyntaxError{Msg: "trailing data after signed data"}
+	}
+
+	var raw rawSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &raw); err != nil {
+		return nil, &SyntaxError{Msg: "invalid signed data", Err: err}
+	}
+	rawSignedAttrs := make([][]byte, len(raw.SignerInfos))
+	for i, signerInfo := range raw.SignerInfos {
+		rawSignedAttrs[i] = signerInfo.SignedAttributes.FullBytes
+	}
+
+	var certs []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		certs, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, &SyntaxError{Msg: "invalid certificates", Err: err}
+		}
+	}
+	crls, err := parseCRLs(sd.CRLs.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return &ParsedSignedData{
+		Content:      sd.EncapsulatedContentInfo.Content,
+		ContentType:  sd.EncapsulatedContentInfo.ContentType,
+		Certificates: certs,
+		CRLs:         crls,
+		Signers:      sd.SignerInfos,
+
+		rawSignedAttributes: rawSignedAttrs,
+	}, nil
+}
+
+// parseCRLs parses the CertificateList choices of RevocationInfoChoices.
+// Other revocation info formats are ignored.
+//
+//	RevocationInfoChoice ::= CHOICE {
+//	 crl    CertificateList,
+//	 other  [1] IMPLICIT OtherRevocationInfoFormat }
+func parseCRLs(data []byte) ([]*x509.RevocationList, error) {
+	var crls []*x509.RevocationList
+	for len(data) > 0 {
+		var raw asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &raw)
+		if err != nil {
+			return nil, &SyntaxError{Msg: "invalid revocation info", Err: err}
+		}
+		data = rest
+		if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+			continue
+		}
+		crl, err := x509.ParseRevocationList(raw.FullBytes)
+		if err != nil {
+			return nil, &SyntaxError{Msg: "invalid CRL", Err: err}
+		}
+		crls = append(crls, crl)
+	}
+	return crls, nil
+}
+
+// Verify verifies all the SignerInfos over the encapsulated content, and
+// returns the signing certificates in the order of the SignerInfos.
+//
+// Only the signatures are verified. The certificate chains of the signing
+// certificates are not validated.
+func (d *ParsedSignedData) Verify() ([]*x509.Certificate, error) {
+	if d.Content == nil {
+		return nil, &VerificationError{Msg: "missing encapsulated content"}
+	}
+	return d.VerifyDetached(d.Content)
+}
+
+// VerifyDetached is similar to Verify but verifies the SignerInfos over the
+// detached content.
+func (d *ParsedSignedData) VerifyDetached(content []byte) ([]*x509.Certificate, error) {
+	if len(d.Signers) == 0 {
+		return nil, &VerificationError{Msg: "no signer found"}
+	}
+	certs := make([]*x509.Certificate, 0, len(d.Signers))
+	for i := range d.Signers {
+		cert, err := d.VerifySigner(&d.Signers[i], content)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// VerifySigner verifies the SignerInfo over the content and returns the
+// signing certificate.
+//
+// If signed attributes are present, the content-type attribute must match
+// the encapsulated content type, and the message-digest attribute must match
+// the digest of the content.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5652#section-5.6
+func (d *ParsedSignedData) VerifySigner(signerInfo *SignerInfo, content []byte) (*x509.Certificate, error) {
+	cert, err := d.SigningCertificate(signerInfo)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hashFromOID(signerInfo.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	sigAlg, err := signatureAlgorithm(signerInfo.SignatureAlgorithm.Algorithm, hash, cert.PublicKeyAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := content
+	if len(signerInfo.SignedAttributes) > 0 {
+		var contentType asn1.ObjectIdentifier
+		if err := signerInfo.SignedAttributes.Get(OIDAttributeContentType, &contentType); err != nil {
+			return nil, &VerificationError{Msg: "invalid content-type attribute", Err: err}
+		}
+		if !contentType.Equal(d.ContentType) {
+			return nil, &VerificationError{Msg: "content-type attribute does not match the encapsulated content type"}
+		}
+		var messageDigest []byte
+		if err := signerInfo.SignedAttributes.Get(OIDAttributeMessageDigest, &messageDigest); err != nil {
+			return nil, &VerificationError{Msg: "invalid message-digest attribute", Err: err}
+		}
+		h := hash.New()
+		h.Write(content)
+		if !bytes.Equal(h.Sum(nil), messageDigest) {
+			return nil, &VerificationError{Msg: "message-digest attribute does not match the content"}
+		}
+
+		signed, err = d.signedAttributesBytes(signerInfo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := cert.CheckSignature(sigAlg, signed, signerInfo.Signature); err != nil {
+		return nil, &VerificationError{Msg: "invalid signature", Err: err}
+	}
+	return cert, nil
+}
+
+// signedAttributesBytes returns the encoded signed attributes of the
+// SignerInfo over which the signature is computed.
+//
+// The signature is computed over the SET OF attributes instead of the
+// IMPLICIT [0] tagged value. For a SignerInfo parsed by ParseSignedData, the
+// signed attributes are used as encoded by the signer with the tag replaced,
+// so that a signer not sorting the SET OF is still verifiable. Otherwise,
+// the signed attributes are re-encoded in DER.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5652#section-5.4
+func (d *ParsedSignedData) signedAttributesBytes(signerInfo *SignerInfo) ([]byte, error) {
+	for i := range d.Signers {
+		if &d.Signers[i] != signerInfo || i >= len(d.rawSignedAttributes) {
+			continue
+		}
+		if raw := d.rawSignedAttributes[i]; len(raw) > 0 {
+			signed := append([]byte(nil), raw...)
+			signed[0] = 0x31 // SET OF
+			return signed, nil
+		}
+	}
+	signed, err := asn1.MarshalWithParams(signerInfo.SignedAttributes, "set")
+	if err != nil {
+		return nil, &VerificationError{Msg: "failed to encode signed attributes", Err: err}
+	}
+	return signed, nil
+}
+
+// SigningCertificate finds the certificate identified by the signer
+// identifier of the SignerInfo.
+func (d *ParsedSignedData) SigningCertificate(signerInfo *SignerInfo) (*x509.Certificate, error) {
+	sid := signerInfo.SignerIdentifier
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias IssuerAndSerialNumber
+		if rest, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+			return nil, &SyntaxError{Msg: "invalid issuer and serial number", Err: err}
+		} else if len(rest) != 0 {
+			return nil, &SyntaxError{Msg: "trailing data after issuer and serial number"}
+		}
+		for _, cert := range d.Certificates {
+			if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+				return cert, nil
+			}
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		if len(sid.Bytes) == 0 {
+			return nil, &SyntaxError{Msg: "invalid subject key identifier"}
+		}
+		for _, cert := range d.Certificates {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+	default:
+		return nil, &SyntaxError{Msg: "invalid signer identifier"}
+	}
+	return nil, &VerificationError{Msg: "signing certificate not found"}
+}
+
+// hashFromOID returns the hash algorithm of the digest algorithm OID.
+func hashFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(OIDDigestAlgorithmSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(OIDDigestAlgorithmSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(OIDDigestAlgorithmSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, &VerificationError{Msg: "unsupported digest algorithm " + oid.String()}
+}
+
+// signatureAlgorithm returns the x509.SignatureAlgorithm combining the
+// signature algorithm OID, the digest algorithm and the public key algorithm
+// of the signing certificate.
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash, keyAlg x509.PublicKeyAlgorithm) (x509.SignatureAlgorithm, error) {
+	var algs map[crypto.Hash]x509.SignatureAlgorithm
+	switch {
+	case keyAlg == x509.RSA && (oid.Equal(OIDSignatureAlgorithmRSA) ||
+		oid.Equal(OIDSignatureAlgorithmSHA256WithRSA) && hash == crypto.SHA256 ||
+		oid.Equal(OIDSignatureAlgorithmSHA384WithRSA) && hash == crypto.SHA384 ||
+		oid.Equal(OIDSignatureAlgorithmSHA512WithRSA) && hash == crypto.SHA512):
+		algs = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA256: x509.SHA256WithRSA,
+			crypto.SHA384: x509.SHA384WithRSA,
+			crypto.SHA512: x509.SHA512WithRSA,
+		}
+	case keyAlg == x509.RSA && oid.Equal(OIDSignatureAlgorithmRSAPSS):
+		algs = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA256: x509.SHA256WithRSAPSS,
+			crypto.SHA384: x509.SHA384WithRSAPSS,
+			crypto.SHA512: x509.SHA512WithRSAPSS,
+		}
+	case keyAlg == x509.ECDSA && (oid.Equal(OIDSignatureAlgorithmECDSA) ||
+		oid.Equal(OIDSignatureAlgorithmECDSAWithSHA256) && hash == crypto.SHA256 ||
+		oid.Equal(OIDSignatureAlgorithmECDSAWithSHA384) && hash == crypto.SHA384 ||
+		oid.Equal(OIDSignatureAlgorithmECDSAWithSHA512) && hash == crypto.SHA512):
+		algs = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA256: x509.ECDSAWithSHA256,
+			crypto.SHA384: x509.ECDSAWithSHA384,
+			crypto.SHA512: x509.ECDSAWithSHA512,
+		}
+	default:
+		return x509.UnknownSignatureAlgorithm, &VerificationError{Msg: "unsupported signature algorithm " + oid.String()}
+	}
+	return algs[hash], nil
+}
@@ -0,0 +1,538 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/testhelper"
+)
+
+// testSigner describes a SignerInfo to be created by createSignedData.
+type testSigner struct {
+	cert             *x509.Certificate
+	key              crypto.Signer
+	hash             crypto.Hash
+	noSignedAttrs    bool
+	subjectKeyID     bool
+	contentType      asn1.ObjectIdentifier
+	messageDigest    []byte
+	unsignedAttrs    Attributes
+	signatureContent []byte
+
+	// unsortedSignedAttrs encodes the signed attributes in the reverse of
+	// the DER order.
+	unsortedSignedAttrs bool
+}
+
+type testSignedDataOptions struct {
+	content      []byte
+	detached     bool
+	certificates []*x509.Certificate
+	crls         [][]byte
+	signers      []testSigner
+}
+
+func TestParseSignedData(t *testing.T) {
+	chain := testhelper.GetRevokableRSAChainWithCRL(2)
+	leaf, root := chain[0], chain[1]
+	crl := createCRL(t, root)
+	content := []byte("hello world")
+	data := createSignedData(t, testSignedDataOptions{
+		content:      content,
+		certificates: []*x509.Certificate{leaf.Cert, root.Cert},
+		crls:         [][]byte{crl},
+		signers: []testSigner{{
+			cert: leaf.Cert,
+			key:  leaf.PrivateKey,
+			hash: crypto.SHA256,
+			unsignedAttrs: Attributes{
+				newAttribute(t, OIDAttributeSigningTime, time.Now().UTC()),
+			},
+		}},
+	})
+
+	signedData, err := ParseSignedData(data)
+	if err != nil {
+		t.Fatalf("ParseSignedData() error = %v", err)
+	}
+	if string(signedData.Content) != string(content) {
+		t.Errorf("Expected content %q but got %q", content, signedData.Content)
+	}
+	if !signedData.ContentType.Equal(OIDData) {
+		t.Errorf("Expected content type %v but got %v", OIDData, signedData.ContentType)
+	}
+	if len(signedData.Certificates) != 2 || !signedData.Certificates[0].Equal(leaf.Cert) || !signedData.Certificates[1].Equal(root.Cert) {
+		t.Errorf("Expected certificates [leaf, root] but got %v", signedData.Certificates)
+	}
+	if len(signedData.CRLs) != 1 || signedData.CRLs[0].Number.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Expected 1 CRL but got %v", signedData.CRLs)
+	}
+	if len(signedData.Signers) != 1 {
+		t.Fatalf("Expected 1 signer but got %d", len(signedData.Signers))
+	}
+	var signingTime time.Time
+	if err := signedData.Signers[0].UnsignedAttributes.Get(OIDAttributeSigningTime, &signingTime); err != nil {
+		t.Errorf("Expected signing time attribute but got error %v", err)
+	}
+	if err := signedData.Signers[0].UnsignedAttributes.Get(OIDAttributeMessageDigest, &signingTime); !errors.Is(err, ErrAttributeNotFound) {
+		t.Errorf("Expected ErrAttributeNotFound but got %v", err)
+	}
+
+	certs, err := signedData.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(certs) != 1 || !certs[0].Equal(leaf.Cert) {
+		t.Fatalf("Expected signing certificate %v but got %v", leaf.Cert.Subject, certs)
+	}
+}
+
+func TestParseSignedDataBER(t *testing.T) {
+	leaf := testhelper.GetRSALeafCertificate()
+	der := createSignedData(t, testSignedDataOptions{
+		content:      []byte("hello world"),
+		certificates: []*x509.Certificate{leaf.Cert},
+		signers: []testSigner{{
+			cert: leaf.Cert,
+			key:  leaf.PrivateKey,
+			hash: crypto.SHA256,
+		}},
+	})
+
+	// re-encode the length of the outer ContentInfo in the non-minimal long
+	// form, which is valid BER but not DER
+	var ci asn1.RawValue
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		t.Fatal(err)
+	}
+	length := len(ci.Bytes)
+	ber := []byte{0x30, 0x84, byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length)}
+	ber = append(ber, ci.Bytes...)
+
+	signedData, err := ParseSignedData(ber)
+	if err != nil {
+		t.Fatalf("ParseSignedData() error = %v", err)
+	}
+	if _, err := signedData.Verify(); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+// TestParseSignedDataOpenSSL parses signed data created by
+//
+//	openssl cms -sign -binary -md sha256 -nodetach -outform DER
+//	openssl cms -sign -binary -md sha384 -outform DER
+//
+// where the latter is a detached signature.
+func TestParseSignedDataOpenSSL(t *testing.T) {
+	content, err := os.ReadFile("testdata/content.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		file     string
+		detached bool
+	}{
+		{name: "embedded", file: "testdata/signed.p7s"},
+		{name: "detached", file: "testdata/detached.p7s", detached: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signedData, err := ParseSignedData(data)
+			if err != nil {
+				t.Fatalf("ParseSignedData() error = %v", err)
+			}
+			if len(signedData.Certificates) != 2 {
+				t.Fatalf("Expected 2 certificates but got %d", len(signedData.Certificates))
+			}
+			var certs []*x509.Certificate
+			if tt.detached {
+				if signedData.Content != nil {
+					t.Fatalf("Expected no encapsulated content but got %q", signedData.Content)
+				}
+				certs, err = signedData.VerifyDetached(content)
+			} else {
+				if string(signedData.Content) != string(content) {
+					t.Fatalf("Expected content %q but got %q", content, signedData.Content)
+				}
+				certs, err = signedData.Verify()
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if len(certs) != 1 || certs[0].Subject.CommonName != "Notation Test Fixture leaf" {
+				t.Fatalf("Expected the fixture leaf certificate but got %v", certs)
+			}
+			if _, err := signedData.VerifyDetached([]byte("other content")); err == nil {
+				t.Fatal("Expected VerifyDetached() to fail for other content")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	rsaLeaf := testhelper.GetRSALeafCertificate()
+	ecLeaf := testhelper.GetECLeafCertificate()
+	ecRoot := testhelper.GetECRootCertificate()
+	content := []byte("hello world")
+	tests := []struct {
+		name string
+		opts testSignedDataOptions
+	}{
+		{
+			name: "RSA with signed attributes",
+			opts: testSignedDataOptions{
+				signers: []testSigner{{cert: rsaLeaf.Cert, key: rsaLeaf.PrivateKey, hash: crypto.SHA384}},
+			},
+		},
+		{
+			name: "RSA without signed attributes",
+			opts: testSignedDataOptions{
+				signers: []testSigner{{cert: rsaLeaf.Cert, key: rsaLeaf.PrivateKey, hash: crypto.SHA256, noSignedAttrs: true}},
+			},
+		},
+		{
+			name: "ECDSA with subject key identifier",
+			opts: testSignedDataOptions{
+				signers: []testSigner{{cert: ecRoot.Cert, key: ecRoot.PrivateKey, hash: crypto.SHA512, subjectKeyID: true}},
+			},
+		},
+		{
+			name: "signed attributes not in DER order",
+			opts: testSignedDataOptions{
+				signers: []testSigner{{cert: rsaLeaf.Cert, key: rsaLeaf.PrivateKey, hash: crypto.SHA256, unsortedSignedAttrs: true}},
+			},
+		},
+		{
+			name: "multiple signers",
+			opts: testSignedDataOptions{
+				signers: []testSigner{
+					{cert: rsaLeaf.Cert, key: rsaLeaf.PrivateKey, hash: crypto.SHA256},
+					{cert: ecLeaf.Cert, key: ecLeaf.PrivateKey, hash: crypto.SHA256},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.content = content
+			tt.opts.certificates = []*x509.Certificate{rsaLeaf.Cert, ecLeaf.Cert, ecRoot.Cert}
+			signedData, err := ParseSignedData(createSignedData(t, tt.opts))
+			if err != nil {
+				t.Fatalf("ParseSignedData() error = %v", err)
+			}
+			certs, err := signedData.Verify()
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if len(certs) != len(tt.opts.signers) {
+				t.Fatalf("Expected %d signing certificates but got %d", len(tt.opts.signers), len(certs))
+			}
+			// SignerInfos are sorted in DER, so the order may differ
+			for _, signer := range tt.opts.signers {
+				var found bool
+				for _, cert := range certs {
+					found = found || cert.Equal(signer.cert)
+				}
+				if !found {
+					t.Errorf("Expected signing certificate %v but got %v", signer.cert.Subject, certs)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyDetached(t *testing.T) {
+	leaf := testhelper.GetRSALeafCertificate()
+	content := []byte("hello world")
+	signedData, err := ParseSignedData(createSignedData(t, testSignedDataOptions{
+		content:      content,
+		detached:     true,
+		certificates: []*x509.Certificate{leaf.Cert},
+		signers:      []testSigner{{cert: leaf.Cert, key: leaf.PrivateKey, hash: crypto.SHA256}},
+	}))
+	if err != nil {
+		t.Fatalf("ParseSignedData() error = %v", err)
+	}
+	if signedData.Content != nil {
+		t.Fatalf("Expected no encapsulated content but got %q", signedData.Content)
+	}
+	if _, err := signedData.Verify(); err == nil {
+		t.Fatal("Expected Verify() to fail without content")
+	}
+	if _, err := signedData.VerifyDetached(content); err != nil {
+		t.Fatalf("VerifyDetached() error = %v", err)
+	}
+	var verificationErr *VerificationError
+	if _, err := signedData.VerifyDetached([]byte("other content")); !errors.As(err, &verificationErr) {
+		t.Fatalf("Expected VerificationError but got %v", err)
+	}
+}
+
+func TestVerifyError(t *testing.T) {
+	leaf := testhelper.GetRSALeafCertificate()
+	other := testhelper.GetECLeafCertificate()
+	tests := []struct {
+		name    string
+		signer  testSigner
+		certs   []*x509.Certificate
+		wantErr string
+	}{
+		{
+			name:    "signing certificate not found",
+			signer:  testSigner{cert: leaf.Cert, key: leaf.PrivateKey, hash: crypto.SHA256},
+			certs:   []*x509.Certificate{other.Cert},
+			wantErr: "signing certificate not found",
+		},
+		{
+			name:    "content type mismatch",
+			signer:  testSigner{cert: leaf.Cert, key: leaf.PrivateKey, hash: crypto.SHA256, contentType: OIDSignedData},
+			wantErr: "content-type attribute does not match the encapsulated content type",
+		},
+		{
+			name:    "message digest mismatch",
+			signer:  testSigner{cert: leaf.Cert, key: leaf.PrivateKey, hash: crypto.SHA256, messageDigest: []byte("digest")},
+			wantErr: "message-digest attribute does not match the content",
+		},
+		{
+			name:    "invalid signature",
+			signer:  testSigner{cert: leaf.Cert, key: leaf.PrivateKey, hash: crypto.SHA256, noSignedAttrs: true, signatureContent: []byte("other")},
+			wantErr: "invalid signature",
+		},
+		{
+			name:    "unsupported digest algorithm",
+			signer:  testSigner{cert: leaf.Cert, key: leaf.PrivateKey, hash: crypto.SHA1},
+			wantErr: "unsupported digest algorithm",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs := tt.certs
+			if certs == nil {
+				certs = []*x509.Certificate{leaf.Cert}
+			}
+			signedData, err := ParseSignedData(createSignedData(t, testSignedDataOptions{
+				content:      []byte("hello world"),
+				certificates: certs,
+				signers:      []testSigner{tt.signer},
+			}))
+			if err != nil {
+				t.Fatalf("ParseSignedData() error = %v", err)
+			}
+			_, err = signedData.Verify()
+			var verificationErr *VerificationError
+			if !errors.As(err, &verificationErr) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected VerificationError containing %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseSignedDataError(t *testing.T) {
+	data, err := asn1.Marshal(ContentInfo{
+		ContentType: OIDData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: []byte{0x04, 0x00}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseSignedData(data); !errors.Is(err, ErrNotSignedData) {
+		t.Errorf("Expected ErrNotSignedData but got %v", err)
+	}
+
+	var syntaxErr *SyntaxError
+	for _, data := range [][]byte{nil, {0x30, 0x00}, {0x02, 0x01, 0x01}} {
+		if _, err := ParseSignedData(data); !errors.As(err, &syntaxErr) {
+			t.Errorf("Expected SyntaxError for %x but got %v", data, err)
+		}
+	}
+}
+
+// createSignedData creates a DER-encoded ContentInfo of signed data.
+func createSignedData(t *testing.T, opts testSignedDataOptions) []byte {
+	t.Helper()
+	var digestAlgs []pkix.AlgorithmIdentifier
+	var signerInfos []SignerInfo
+	for _, signer := range opts.signers {
+		digestAlg := pkix.AlgorithmIdentifier{Algorithm: digestOID(signer.hash)}
+		digestAlgs = append(digestAlgs, digestAlg)
+
+		signatureContent := opts.content
+		if signer.signatureContent != nil {
+			signatureContent = signer.signatureContent
+		}
+		var signedAttrs Attributes
+		if !signer.noSignedAttrs {
+			contentType := signer.contentType
+			if contentType == nil {
+				contentType = OIDData
+			}
+			messageDigest := signer.messageDigest
+			if messageDigest == nil {
+				messageDigest = digest(signer.hash, opts.content)
+			}
+			signedAttrs = Attributes{
+				newAttribute(t, OIDAttributeContentType, contentType),
+				newAttribute(t, OIDAttributeMessageDigest, messageDigest),
+			}
+			encoded, err := asn1.MarshalWithParams(signedAttrs, "set")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signer.unsortedSignedAttrs {
+				signedAttrs = Attributes{signedAttrs[1], signedAttrs[0]}
+				if encoded, err = asn1.Marshal(signedAttrs); err != nil {
+					t.Fatal(err)
+				}
+				encoded[0] = 0x31 // SET OF
+			}
+			signatureContent = encoded
+		}
+
+		var sig []byte
+		var err error
+		var sigAlg asn1.ObjectIdentifier
+		switch key := signer.key.(type) {
+		case *rsa.PrivateKey:
+			sigAlg = OIDSignatureAlgorithmRSA
+			sig, err = rsa.SignPKCS1v15(rand.Reader, key, signer.hash, digest(signer.hash, signatureContent))
+		case *ecdsa.PrivateKey:
+			sigAlg = OIDSignatureAlgorithmECDSA
+			sig, err = ecdsa.SignASN1(rand.Reader, key, digest(signer.hash, signatureContent))
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var sid asn1.RawValue
+		if signer.subjectKeyID {
+			sid = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: signer.cert.SubjectKeyId}
+		} else {
+			ias, err := asn1.Marshal(IssuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: signer.cert.RawIssuer},
+				SerialNumber: signer.cert.SerialNumber,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sid = asn1.RawValue{FullBytes: ias}
+		}
+		signerInfos = append(signerInfos, SignerInfo{
+			Version:            1,
+			SignerIdentifier:   sid,
+			DigestAlgorithm:    digestAlg,
+			SignedAttributes:   signedAttrs,
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: sigAlg},
+			Signature:          sig,
+			UnsignedAttributes: signer.unsignedAttrs,
+		})
+	}
+
+	var certs []byte
+	for _, cert := range opts.certificates {
+		certs = append(certs, cert.Raw...)
+	}
+	var crls []byte
+	for _, crl := range opts.crls {
+		crls = append(crls, crl...)
+	}
+	signedData := SignedData{
+		Version:                    1,
+		DigestAlgorithmIdentifiers: digestAlgs,
+		EncapsulatedContentInfo: EncapsulatedContentInfo{
+			ContentType: OIDData,
+		},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos:  signerInfos,
+	}
+	if !opts.detached {
+		signedData.EncapsulatedContentInfo.Content = opts.content
+	}
+	if len(crls) > 0 {
+		signedData.CRLs = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: crls}
+	}
+	sd, err := asn1.Marshal(signedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := asn1.Marshal(ContentInfo{
+		ContentType: OIDSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newAttribute(t *testing.T, oid asn1.ObjectIdentifier, value interface{}) Attribute {
+	t.Helper()
+	encoded, err := asn1.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Attribute{
+		Type:   oid,
+		Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: encoded},
+	}
+}
+
+func createCRL(t *testing.T, issuer testhelper.RSACertTuple) []byte {
+	t.Helper()
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(time.Hour),
+	}, issuer.Cert, issuer.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crl
+}
+
+func digestOID(hash crypto.Hash) asn1.ObjectIdentifier {
+	switch hash {
+	case crypto.SHA256:
+		return OIDDigestAlgorithmSHA256
+	case crypto.SHA384:
+		return OIDDigestAlgorithmSHA384
+	case crypto.SHA512:
+		return OIDDigestAlgorithmSHA512
+	}
+	return asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+}
+
+func digest(hash crypto.Hash, content []byte) []byte {
+	h := hash.New()
+	h.Write(content)
+	return h.Sum(nil)
+}
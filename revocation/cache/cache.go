@@ -0,0 +1,41 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache provides the interface and implementations for caching
+// revocation responses, such as OCSP responses and CRLs, so that they can be
+// reused until they expire
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrCacheMiss is returned when a key is not found in the cache or the entry
+// has expired
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache is an interface that specifies methods used for caching revocation
+// responses
+//
+// Implementations must be safe for concurrent use by multiple goroutines
+type Cache interface {
+	// Get retrieves the value stored for key. If the key is not found or the
+	// entry has expired, ErrCacheMiss is returned
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores value for key until expiration. An existing entry for key is
+	// overwritten
+	Set(ctx context.Context, key string, value []byte, expiration time.Time) error
+}
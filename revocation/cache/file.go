@@ -0,0 +1,113 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileCache is a Cache that stores each entry as a file in a directory of the
+// file system
+type fileCache struct {
+	root string
+}
+
+// fileEntry is the content of a file of fileCache
+type fileEntry struct {
+	Value      []byte    `json:"value"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// NewFileCache constructs a Cache that stores its entries in the root
+// directory. The directory is created if it does not exist
+func NewFileCache(root string) (Cache, error) {
+	if root == "" {
+		return nil, errors.New("invalid input: a non-empty root directory must be specified")
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &fileCache{
+		root: root,
+	}, nil
+}
+
+// Get retrieves the value stored for key. If the key is not found or the entry
+// has expired, ErrCacheMiss is returned
+func (c *fileCache) Get(ctx context.Context, key string) ([]byte, error) {
+	path := c.path(key)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	var entry fileEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	if !time.Now().Before(entry.Expiration) {
+		// The entry may have been replaced concurrently, a failure to remove
+		// it is not an error
+		_ = os.Remove(path)
+		return nil, ErrCacheMiss
+	}
+	return entry.Value, nil
+}
+
+// Set stores value for key until expiration. An existing entry for key is
+// overwritten
+func (c *fileCache) Set(ctx context.Context, key string, value []byte, expiration time.Time) error {
+	content, err := json.Marshal(fileEntry{
+		Value:      value,
+		Expiration: expiration,
+	})
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so that a concurrent Get never reads a
+	// partially written entry
+	tmp, err := os.CreateTemp(c.root, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// path returns the path of the file for key. The key is hashed so that it can
+// be safely used as a file name
+func (c *fileCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.root, hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,106 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewFileCache(t *testing.T) {
+	t.Run("empty root", func(t *testing.T) {
+		_, err := NewFileCache("")
+		expectedErr := "invalid input: a non-empty root directory must be specified"
+		if err == nil || err.Error() != expectedErr {
+			t.Errorf("Expected NewFileCache to fail with %v, but got %v", expectedErr, err)
+		}
+	})
+
+	t.Run("create root", func(t *testing.T) {
+		root := filepath.Join(t.TempDir(), "cache")
+		if _, err := NewFileCache(root); err != nil {
+			t.Fatalf("Expected NewFileCache to succeed, but got error: %v", err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			t.Errorf("Expected root directory to be created, but got error: %v", err)
+		}
+	})
+}
+
+func TestFileCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("set and get", func(t *testing.T) {
+		c, _ := NewFileCache(t.TempDir())
+		if err := c.Set(ctx, "key", []byte("value"), time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Expected Set to succeed, but got error: %v", err)
+		}
+		value, err := c.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("Expected Get to succeed, but got error: %v", err)
+		}
+		if !bytes.Equal(value, []byte("value")) {
+			t.Errorf("Expected value to be %q, but got %q", "value", value)
+		}
+	})
+
+	t.Run("shared between instances", func(t *testing.T) {
+		root := t.TempDir()
+		c1, _ := NewFileCache(root)
+		c2, _ := NewFileCache(root)
+		c1.Set(ctx, "key", []byte("value"), time.Now().Add(time.Hour))
+		value, err := c2.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("Expected Get to succeed, but got error: %v", err)
+		}
+		if !bytes.Equal(value, []byte("value")) {
+			t.Errorf("Expected value to be %q, but got %q", "value", value)
+		}
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		c, _ := NewFileCache(t.TempDir())
+		if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected Get to fail with %v, but got %v", ErrCacheMiss, err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		root := t.TempDir()
+		c, _ := NewFileCache(root)
+		c.Set(ctx, "key", []byte("value"), time.Now().Add(-time.Second))
+		if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected Get to fail with %v, but got %v", ErrCacheMiss, err)
+		}
+		if entries, _ := os.ReadDir(root); len(entries) != 0 {
+			t.Errorf("Expected expired entry to be removed, but found %d files", len(entries))
+		}
+	})
+
+	t.Run("corrupted entry", func(t *testing.T) {
+		c, _ := NewFileCache(t.TempDir())
+		fc := c.(*fileCache)
+		if err := os.WriteFile(fc.path("key"), []byte("corrupted"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.Get(ctx, "key"); err == nil || errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected Get to fail with decoding error, but got %v", err)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// memoryCache is an in-memory Cache that evicts the least recently used entry
+// when its capacity is reached
+type memoryCache struct {
+	capacity int
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List // front is the most recently used entry
+}
+
+// memoryEntry is an entry of memoryCache
+type memoryEntry struct {
+	key        string
+	value      []byte
+	expiration time.Time
+}
+
+// NewMemoryCache constructs an in-memory LRU Cache that holds at most capacity
+// entries
+func NewMemoryCache(capacity int) (Cache, error) {
+	if capacity <= 0 {
+		return nil, errors.New("invalid input: capacity must be greater than 0")
+	}
+	return &memoryCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}, nil
+}
+
+// Get retrieves a copy of the value stored for key. If the key is not found or
+// the entry has expired, ErrCacheMiss is returned
+func (c *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !time.Now().Before(entry.expiration) {
+		c.remove(elem)
+		return nil, ErrCacheMiss
+	}
+	c.lru.MoveToFront(elem)
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Set stores a copy of value for key until expiration. An existing entry for
+// key is overwritten
+func (c *memoryCache) Set(ctx context.Context, key string, value []byte, expiration time.Time) error {
+	// the caller may modify value after Set returns
+	value = append([]byte(nil), value...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiration = expiration
+		c.lru.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.lru.PushFront(&memoryEntry{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+	})
+	if c.lru.Len() > c.capacity {
+		c.remove(c.lru.Back())
+	}
+	return nil
+}
+
+func (c *memoryCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,141 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewMemoryCache(t *testing.T) {
+	_, err := NewMemoryCache(0)
+	expectedErr := "invalid input: capacity must be greater than 0"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("Expected NewMemoryCache(0) to fail with %v, but got %v", expectedErr, err)
+	}
+
+	if _, err := NewMemoryCache(1); err != nil {
+		t.Errorf("Expected NewMemoryCache(1) to succeed, but got error: %v", err)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("set and get", func(t *testing.T) {
+		c, _ := NewMemoryCache(2)
+		if err := c.Set(ctx, "key", []byte("value"), time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Expected Set to succeed, but got error: %v", err)
+		}
+		value, err := c.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("Expected Get to succeed, but got error: %v", err)
+		}
+		if !bytes.Equal(value, []byte("value")) {
+			t.Errorf("Expected value to be %q, but got %q", "value", value)
+		}
+	})
+
+	t.Run("modifying values does not affect the entry", func(t *testing.T) {
+		c, _ := NewMemoryCache(2)
+		value := []byte("value")
+		if err := c.Set(ctx, "key", value, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("Expected Set to succeed, but got error: %v", err)
+		}
+		value[0] = 'X'
+		got, err := c.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("Expected Get to succeed, but got error: %v", err)
+		}
+		got[1] = 'X'
+		got, err = c.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("Expected Get to succeed, but got error: %v", err)
+		}
+		if !bytes.Equal(got, []byte("value")) {
+			t.Errorf("Expected value to be %q, but got %q", "value", got)
+		}
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		c, _ := NewMemoryCache(2)
+		if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected Get to fail with %v, but got %v", ErrCacheMiss, err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		c, _ := NewMemoryCache(2)
+		if err := c.Set(ctx, "key", []byte("value"), time.Now().Add(-time.Second)); err != nil {
+			t.Fatalf("Expected Set to succeed, but got error: %v", err)
+		}
+		if _, err := c.Get(ctx, "key"); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected Get to fail with %v, but got %v", ErrCacheMiss, err)
+		}
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		c, _ := NewMemoryCache(2)
+		c.Set(ctx, "key", []byte("old"), time.Now().Add(time.Hour))
+		c.Set(ctx, "key", []byte("new"), time.Now().Add(time.Hour))
+		value, err := c.Get(ctx, "key")
+		if err != nil {
+			t.Fatalf("Expected Get to succeed, but got error: %v", err)
+		}
+		if !bytes.Equal(value, []byte("new")) {
+			t.Errorf("Expected value to be %q, but got %q", "new", value)
+		}
+	})
+
+	t.Run("evict least recently used", func(t *testing.T) {
+		c, _ := NewMemoryCache(2)
+		expiration := time.Now().Add(time.Hour)
+		c.Set(ctx, "a", []byte("a"), expiration)
+		c.Set(ctx, "b", []byte("b"), expiration)
+		// a becomes the most recently used entry
+		if _, err := c.Get(ctx, "a"); err != nil {
+			t.Fatalf("Expected Get to succeed, but got error: %v", err)
+		}
+		c.Set(ctx, "c", []byte("c"), expiration)
+
+		if _, err := c.Get(ctx, "b"); !errors.Is(err, ErrCacheMiss) {
+			t.Errorf("Expected b to be evicted, but got %v", err)
+		}
+		for _, key := range []string{"a", "c"} {
+			if _, err := c.Get(ctx, key); err != nil {
+				t.Errorf("Expected %s to be cached, but got error: %v", key, err)
+			}
+		}
+	})
+
+	t.Run("concurrent access", func(t *testing.T) {
+		c, _ := NewMemoryCache(10)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				key := fmt.Sprintf("key%d", i%20)
+				c.Set(ctx, key, []byte(key), time.Now().Add(time.Hour))
+				c.Get(ctx, key)
+			}(i)
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,438 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crl provides methods for checking the CRL revocation status of a
+// certificate chain, as well as errors related to these checks
+package crl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/revocation/cache"
+	"github.com/notaryproject/notation-core-go/revocation/result"
+	coreX509 "github.com/notaryproject/notation-core-go/x509"
+)
+
+// Options specifies values that are needed to check CRL revocation
+type Options struct {
+	CertChain   []*x509.Certificate
+	SigningTime time.Time
+	HTTPClient  *http.Client
+
+	// Context is passed into every HTTP request to the CRL distribution
+	// points. When it is cancelled, the remaining checks are stopped. If it is
+	// nil, context.Background() is used.
+	Context context.Context
+
+	// Cache is used to store CRLs until their NextUpdate, so that they can be
+	// reused instead of downloading them again. If it is nil, CRLs are not
+	// cached.
+	Cache cache.Cache
+}
+
+func (opts Options) ctx() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+const (
+	invalidityDateOID           string = "2.5.29.24"
+	reasonCodeOID               string = "2.5.29.21"
+	deltaCRLIndicatorOID        string = "2.5.29.27"
+	issuingDistributionPointOID string = "2.5.29.28"
+	// reasonCodeRemoveFromCRL is the CRLReason of an entry that only appears
+	// in delta CRLs to indicate that a certificate is no longer on hold.
+	reasonCodeRemoveFromCRL asn1.Enumerated = 8
+	// Public CRLs are usually well under 1 MB, but CRLs of large CAs can
+	// grow to several MBs
+	crlMaxSize int64 = 32 * 1024 * 1024 // bytes
+)
+
+// CheckStatus checks CRL based on the passed options and returns an array of
+// result.CertRevocationResult objects that contains the results and error. The
+// length of this array will always be equal to the length of the certificate
+// chain.
+//
+// If opts.Context is cancelled or its deadline is exceeded, the context error
+// is returned.
+func CheckStatus(opts Options) ([]*result.CertRevocationResult, error) {
+	ctx := opts.ctx()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(opts.CertChain) == 0 {
+		return nil, result.InvalidChainError{Err: errors.New("chain does not contain any certificates")}
+	}
+
+	// Validate cert chain structure
+	// Since this is using authentic signing time, signing time may be zero.
+	// Thus, it is better to pass nil here than fail for a cert's NotBefore
+	// being after zero time
+	if err := coreX509.ValidateCodeSigningCertChain(opts.CertChain, nil); err != nil {
+		return nil, result.InvalidChainError{Err: err}
+	}
+
+	certResults := make([]*result.CertRevocationResult, len(opts.CertChain))
+
+	// Check status for each cert in cert chain
+	var wg sync.WaitGroup
+	for i, cert := range opts.CertChain[:len(opts.CertChain)-1] {
+		wg.Add(1)
+		// Assume cert chain is accurate and next cert in chain is the issuer
+		go func(i int, cert *x509.Certificate) {
+			defer wg.Done()
+			certResults[i] = CertCheckStatus(cert, opts.CertChain[i+1], opts)
+		}(i, cert)
+	}
+	// Last is root cert, which will never be revoked by CRL
+	certResults[len(opts.CertChain)-1] = &result.CertRevocationResult{
+		Result: result.ResultNonRevokable,
+		ServerResults: []*result.ServerResult{{
+			Result: result.ResultNonRevokable,
+			Error:  nil,
+		}},
+	}
+
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return certResults, nil
+}
+
+// CertCheckStatus checks the revocation status of a single certificate against
+// the CRLs published at its CRLDistributionPoints. The issuer must be the
+// certificate that issued cert, as it is used to verify the CRLs.
+//
+// The chain in opts is not validated and may be left empty.
+func CertCheckStatus(cert, issuer *x509.Certificate, opts Options) *result.CertRevocationResult {
+	crlURLs := cert.CRLDistributionPoints
+	if len(crlURLs) == 0 {
+		// CRL not enabled for this certificate.
+		return &result.CertRevocationResult{
+			Result:        result.ResultNonRevokable,
+			ServerResults: []*result.ServerResult{toServerResult("", NoServerError{})},
+		}
+	}
+
+	serverResults := make([]*result.ServerResult, len(crlURLs))
+	for serverIndex, server := range crlURLs {
+		if err := opts.ctx().Err(); err != nil {
+			// The check has been cancelled, the remaining distribution points
+			// are not queried
+			serverResults[serverIndex] = toServerResult(server, GenericError{Err: err})
+			continue
+		}
+		serverResult := checkStatusFromServer(cert, issuer, server, opts)
+		if serverResult.Result == result.ResultOK ||
+			serverResult.Result == result.ResultRevoked {
+			// A valid CRL has been retrieved from this distribution point
+			// Result should be based on only this CRL, not any errors from
+			// other distribution points
+			return serverResultsToCertRevocationResult([]*result.ServerResult{serverResult})
+		}
+		serverResults[serverIndex] = serverResult
+	}
+	return serverResultsToCertRevocationResult(serverResults)
+}
+
+func checkStatusFromServer(cert, issuer *x509.Certificate, server string, opts Options) *result.ServerResult {
+	// Check valid server
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return toServerResult(server, GenericError{Err: fmt.Errorf("invalid CRL distribution point %q: %v", server, err)})
+	}
+	if !strings.EqualFold(serverURL.Scheme, "http") {
+		// This function is only able to retrieve CRLs that are accessible via
+		// HTTP
+		return toServerResult(server, GenericError{Err: fmt.Errorf("CRLDistributionPoints protocol %s is not supported", serverURL.Scheme)})
+	}
+
+	// Download and validate CRL, unless a valid CRL is cached
+	key := cacheKey(issuer, server)
+	crl, cached := getCachedCRL(cert, issuer, key, opts)
+	if !cached {
+		crl, err = fetchCRL(opts.ctx(), server, opts.HTTPClient)
+		if err != nil {
+			return toServerResult(server, err)
+		}
+		if err := validateCRL(crl, cert, issuer); err != nil {
+			return toServerResult(server, GenericError{Err: err})
+		}
+		if opts.Cache != nil && !crl.NextUpdate.IsZero() {
+			// A failure to cache the CRL does not affect the result of the
+			// check
+			_ = opts.Cache.Set(opts.ctx(), key, crl.Raw, crl.NextUpdate)
+		}
+	}
+
+	// No errors, valid CRL
+	return toServerResult(server, checkRevocation(crl, cert, opts.SigningTime))
+}
+
+func fetchCRL(ctx context.Context, server string, httpClient *http.Client) (*x509.RevocationList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server, nil)
+	if err != nil {
+		return nil, GenericError{Err: err}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) && urlErr.Timeout() {
+			return nil, TimeoutError{}
+		}
+		return nil, GenericError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, GenericError{Err: fmt.Errorf("failed to download CRL: response had status code %d", resp.StatusCode)}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, crlMaxSize+1))
+	if err != nil {
+		return nil, GenericError{Err: err}
+	}
+	if int64(len(body)) > crlMaxSize {
+		return nil, GenericError{Err: fmt.Errorf("CRL exceeds max size of %d bytes", crlMaxSize)}
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, GenericError{Err: fmt.Errorf("failed to parse CRL: %v", err)}
+	}
+	return crl, nil
+}
+
+// getCachedCRL returns the CRL from opts.Cache if it is present and still
+// valid.
+func getCachedCRL(cert, issuer *x509.Certificate, key string, opts Options) (*x509.RevocationList, bool) {
+	if opts.Cache == nil {
+		return nil, false
+	}
+	raw, err := opts.Cache.Get(opts.ctx(), key)
+	if err != nil {
+		return nil, false
+	}
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil || validateCRL(crl, cert, issuer) != nil {
+		return nil, false
+	}
+	return crl, true
+}
+
+// cacheKey returns the key of the CRL downloaded from server for the
+// certificates issued by issuer.
+func cacheKey(issuer *x509.Certificate, server string) string {
+	issuerHash := sha256.Sum256(issuer.Raw)
+	serverHash := sha256.Sum256([]byte(server))
+	return fmt.Sprintf("crl:%x:%x", issuerHash, serverHash)
+}
+
+// validateCRL checks that the CRL is issued and signed by the issuer of the
+// certificate, that it is within its validity period, that it is a complete
+// CRL, and that its scope covers the certificate.
+func validateCRL(crl *x509.RevocationList, cert, issuer *x509.Certificate) error {
+	if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+		return errors.New("CRL issuer does not match certificate issuer")
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return fmt.Errorf("CRL is not signed by the certificate issuer: %v", err)
+	}
+
+	now := time.Now()
+	if now.Before(crl.ThisUpdate) {
+		return errors.New("CRL is not yet valid")
+	}
+	if !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+		return errors.New("expired CRL")
+	}
+
+	for _, ext := range crl.Extensions {
+		if ext.Id.String() == issuingDistributionPointOID {
+			if err := validateIssuingDistributionPoint(ext.Value, cert); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ext.Critical {
+			continue
+		}
+		switch ext.Id.String() {
+		case deltaCRLIndicatorOID:
+			return errors.New("delta CRL is not supported")
+		default:
+			return fmt.Errorf("unsupported critical CRL extension %s", ext.Id)
+		}
+	}
+	return nil
+}
+
+// issuingDistributionPoint is the value of the issuingDistributionPoint CRL
+// extension.
+//
+//	IssuingDistributionPoint ::= SEQUENCE {
+//	 distributionPoint          [0] DistributionPointName OPTIONAL,
+//	 onlyContainsUserCerts      [1] BOOLEAN DEFAULT FALSE,
+//	 onlyContainsCACerts        [2] BOOLEAN DEFAULT FALSE,
+//	 onlySomeReasons            [3] ReasonFlags OPTIONAL,
+//	 indirectCRL                [4] BOOLEAN DEFAULT FALSE,
+//	 onlyContainsAttributeCerts [5] BOOLEAN DEFAULT FALSE }
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5280#section-5.2.5
+type issuingDistributionPoint struct {
+	DistributionPoint          distributionPointName `asn1:"optional,tag:0"`
+	OnlyContainsUserCerts      bool                  `asn1:"optional,tag:1"`
+	OnlyContainsCACerts        bool                  `asn1:"optional,tag:2"`
+	OnlySomeReasons            asn1.BitString        `asn1:"optional,tag:3"`
+	IndirectCRL                bool                  `asn1:"optional,tag:4"`
+	OnlyContainsAttributeCerts bool                  `asn1:"optional,tag:5"`
+}
+
+// distributionPointName is the name of a distribution point.
+//
+//	DistributionPointName ::= CHOICE {
+//	 fullName                [0] GeneralNames,
+//	 nameRelativeToCRLIssuer [1] RelativeDistinguishedName }
+type distributionPointName struct {
+	FullName     []asn1.RawValue                   `asn1:"optional,tag:0"`
+	RelativeName pkix.RelativeDistinguishedNameSET `asn1:"optional,tag:1"`
+}
+
+// validateIssuingDistributionPoint checks that the scope of the CRL defined
+// by its issuingDistributionPoint extension covers the certificate, as
+// required by RFC 5280 section 6.3.3 step (b)(2). Indirect CRLs and CRLs
+// partitioned by reasons are not supported.
+func validateIssuingDistributionPoint(value []byte, cert *x509.Certificate) error {
+	var idp issuingDistributionPoint
+	rest, err := asn1.Unmarshal(value, &idp)
+	if err != nil {
+		return fmt.Errorf("invalid issuingDistributionPoint CRL extension: %v", err)
+	}
+	if len(rest) > 0 {
+		return errors.New("invalid issuingDistributionPoint CRL extension: trailing data")
+	}
+
+	// the distribution point of the CRL must be one of the distribution
+	// points of the certificate
+	if len(idp.DistributionPoint.RelativeName) > 0 {
+		return errors.New("issuingDistributionPoint with nameRelativeToCRLIssuer is not supported")
+	}
+	if len(idp.DistributionPoint.FullName) > 0 && !matchDistributionPoint(idp.DistributionPoint.FullName, cert.CRLDistributionPoints) {
+		return errors.New("CRL distribution point does not match the certificate")
+	}
+
+	isCA := cert.BasicConstraintsValid && cert.IsCA
+	switch {
+	case idp.OnlyContainsUserCerts && isCA:
+		return errors.New("CRL only contains user certificates, but the certificate is a CA certificate")
+	case idp.OnlyContainsCACerts && !isCA:
+		return errors.New("CRL only contains CA certificates, but the certificate is not a CA certificate")
+	case idp.OnlyContainsAttributeCerts:
+		return errors.New("CRL only contains attribute certificates")
+	case idp.OnlySomeReasons.BitLength > 0:
+		return errors.New("CRL partitioned by reasons is not supported")
+	case idp.IndirectCRL:
+		return errors.New("indirect CRL is not supported")
+	}
+	return nil
+}
+
+// matchDistributionPoint reports whether any URI of the general names is one
+// of the URLs.
+func matchDistributionPoint(names []asn1.RawValue, urls []string) bool {
+	for _, name := range names {
+		// uniformResourceIdentifier [6] IA5String
+		if name.Class != asn1.ClassContextSpecific || name.Tag != 6 {
+			continue
+		}
+		for _, u := range urls {
+			if string(name.Bytes) == u {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkRevocation looks up the serial number of the certificate in the CRL.
+func checkRevocation(crl *x509.RevocationList, cert *x509.Certificate, signingTime time.Time) error {
+	for _, revokedCert := range crl.RevokedCertificates {
+		if revokedCert.SerialNumber == nil || revokedCert.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+			continue
+		}
+
+		// Handle CRLReason and id-ce-invalidityDate entry extensions if present
+		extensionMap := extensionsToMap(revokedCert.Extensions)
+		if reasonCodeBytes, foundReasonCode := extensionMap[reasonCodeOID]; foundReasonCode {
+			var reasonCode asn1.Enumerated
+			rest, err := asn1.Unmarshal(reasonCodeBytes, &reasonCode)
+			if len(rest) == 0 && err == nil && reasonCode == reasonCodeRemoveFromCRL {
+				continue
+			}
+		}
+		if invalidityDateBytes, foundInvalidityDate := extensionMap[invalidityDateOID]; foundInvalidityDate && !signingTime.IsZero() {
+			var invalidityDate time.Time
+			rest, err := asn1.UnmarshalWithParams(invalidityDateBytes, &invalidityDate, "generalized")
+			if len(rest) == 0 && err == nil && signingTime.Before(invalidityDate) {
+				return nil
+			}
+		}
+		return RevokedError{}
+	}
+	return nil
+}
+
+func extensionsToMap(extensions []pkix.Extension) map[string][]byte {
+	extensionMap := make(map[string][]byte)
+	for _, extension := range extensions {
+		extensionMap[extension.Id.String()] = extension.Value
+	}
+	return extensionMap
+}
+
+func toServerResult(server string, err error) *result.ServerResult {
+	switch t := err.(type) {
+	case nil:
+		return result.NewServerResult(result.ResultOK, server, nil)
+	case NoServerError:
+		return result.NewServerResult(result.ResultNonRevokable, server, nil)
+	case RevokedError:
+		return result.NewServerResult(result.ResultRevoked, server, t)
+	default:
+		// Includes GenericError and TimeoutError
+		return result.NewServerResult(result.ResultUnknown, server, t)
+	}
+}
+
+func serverResultsToCertRevocationResult(serverResults []*result.ServerResult) *result.CertRevocationResult {
+	return &result.CertRevocationResult{
+		Result:        serverResults[len(serverResults)-1].Result,
+		ServerResults: serverResults,
+	}
+}
@@ -0,0 +1,645 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crl
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/revocation/cache"
+	"github.com/notaryproject/notation-core-go/revocation/result"
+	"github.com/notaryproject/notation-core-go/testhelper"
+	"golang.org/x/crypto/ocsp"
+)
+
+func validateEquivalentCertResults(certResults, expectedCertResults []*result.CertRevocationResult, t *testing.T) {
+	if len(certResults) != len(expectedCertResults) {
+		t.Errorf("Length of certResults (%d) did not match expected length (%d)", len(certResults), len(expectedCertResults))
+		return
+	}
+	for i, certResult := range certResults {
+		if certResult.Result != expectedCertResults[i].Result {
+			t.Errorf("Expected certResults[%d].Result to be %s, but got %s", i, expectedCertResults[i].Result, certResult.Result)
+		}
+		if len(certResult.ServerResults) != len(expectedCertResults[i].ServerResults) {
+			t.Errorf("Length of certResults[%d].ServerResults (%d) did not match expected length (%d)", i, len(certResult.ServerResults), len(expectedCertResults[i].ServerResults))
+			return
+		}
+		for j, serverResult := range certResult.ServerResults {
+			if serverResult.Result != expectedCertResults[i].ServerResults[j].Result {
+				t.Errorf("Expected certResults[%d].ServerResults[%d].Result to be %s, but got %s", i, j, expectedCertResults[i].ServerResults[j].Result, serverResult.Result)
+			}
+			if serverResult.Server != expectedCertResults[i].ServerResults[j].Server {
+				t.Errorf("Expected certResults[%d].ServerResults[%d].Server to be %s, but got %s", i, j, expectedCertResults[i].ServerResults[j].Server, serverResult.Server)
+			}
+			if serverResult.Error == nil {
+				if expectedCertResults[i].ServerResults[j].Error == nil {
+					continue
+				}
+				t.Errorf("certResults[%d].ServerResults[%d].Error was nil, but expected %v", i, j, expectedCertResults[i].ServerResults[j].Error)
+			} else if expectedCertResults[i].ServerResults[j].Error == nil {
+				t.Errorf("Unexpected error for certResults[%d].ServerResults[%d].Error: %v", i, j, serverResult.Error)
+			} else if serverResult.Error.Error() != expectedCertResults[i].ServerResults[j].Error.Error() {
+				t.Errorf("Expected certResults[%d].ServerResults[%d].Error to be %v, but got %v", i, j, expectedCertResults[i].ServerResults[j].Error, serverResult.Error)
+			}
+		}
+	}
+}
+
+func getOKCertResult(server string) *result.CertRevocationResult {
+	return &result.CertRevocationResult{
+		Result: result.ResultOK,
+		ServerResults: []*result.ServerResult{
+			result.NewServerResult(result.ResultOK, server, nil),
+		},
+	}
+}
+
+func getRevokedCertResult(server string) *result.CertRevocationResult {
+	return &result.CertRevocationResult{
+		Result: result.ResultRevoked,
+		ServerResults: []*result.ServerResult{
+			result.NewServerResult(result.ResultRevoked, server, RevokedError{}),
+		},
+	}
+}
+
+func getRootCertResult() *result.CertRevocationResult {
+	return &result.CertRevocationResult{
+		Result: result.ResultNonRevokable,
+		ServerResults: []*result.ServerResult{
+			result.NewServerResult(result.ResultNonRevokable, "", nil),
+		},
+	}
+}
+
+type statusRoundTripper struct {
+	statusCode int
+	body       []byte
+}
+
+func (s statusRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Body:       io.NopCloser(bytes.NewBuffer(s.body)),
+		StatusCode: s.statusCode,
+	}, nil
+}
+
+func TestCheckStatusForChain(t *testing.T) {
+	zeroTime := time.Time{}
+	testChain := testhelper.GetRevokableRSAChainWithCRL(4)
+	revokableChain := make([]*x509.Certificate, 4)
+	for i, tuple := range testChain {
+		revokableChain[i] = tuple.Cert
+		revokableChain[i].NotBefore = zeroTime
+	}
+
+	t.Run("empty chain", func(t *testing.T) {
+		opts := Options{
+			CertChain:   []*x509.Certificate{},
+			SigningTime: time.Now(),
+			HTTPClient:  http.DefaultClient,
+		}
+		certResults, err := CheckStatus(opts)
+		expectedErr := result.InvalidChainError{Err: errors.New("chain does not contain any certificates")}
+		if err == nil || err.Error() != expectedErr.Error() {
+			t.Errorf("Expected CheckStatus to fail with %v, but got: %v", expectedErr, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+	})
+	t.Run("check non-revoked chain", func(t *testing.T) {
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, nil)
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  client,
+		}
+
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[2].CRLDistributionPoints[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+	t.Run("check chain with 1 revoked cert", func(t *testing.T) {
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, []int{1})
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  client,
+		}
+
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].CRLDistributionPoints[0]),
+			getRevokedCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[2].CRLDistributionPoints[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+	t.Run("check chain with 1 future revoked cert", func(t *testing.T) {
+		revokedTime := time.Now().Add(time.Hour)
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, &revokedTime, true, []int{0})
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  client,
+		}
+
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[2].CRLDistributionPoints[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+	t.Run("check chain with 1 revoked cert after zero signing time", func(t *testing.T) {
+		revokedTime := time.Now().Add(time.Hour)
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, &revokedTime, true, []int{0})
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: zeroTime,
+			HTTPClient:  client,
+		}
+
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getRevokedCertResult(revokableChain[0].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[2].CRLDistributionPoints[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+}
+
+func TestCertCheckStatusErrors(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(3)
+	otherChain := testhelper.GetRevokableRSAChainWithCRL(3)
+	client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, nil)
+
+	newLeaf := func(crlURLs ...string) *x509.Certificate {
+		leaf, _ := x509.ParseCertificate(testChain[0].Cert.Raw)
+		leaf.CRLDistributionPoints = crlURLs
+		return leaf
+	}
+	issuer := testChain[1].Cert
+
+	t.Run("no CRLDistributionPoints specified", func(t *testing.T) {
+		certResult := CertCheckStatus(newLeaf(), issuer, Options{HTTPClient: client})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultNonRevokable,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultNonRevokable, "", nil),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+
+	t.Run("non-HTTP URI error", func(t *testing.T) {
+		server := "ldap://ds.example.com:123/chain_crl/0"
+		certResult := CertCheckStatus(newLeaf(server), issuer, Options{HTTPClient: client})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, server, GenericError{Err: errors.New("CRLDistributionPoints protocol ldap is not supported")}),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+
+	t.Run("expired CRL", func(t *testing.T) {
+		server := "http://example.com/expired_crl"
+		certResult := CertCheckStatus(newLeaf(server), issuer, Options{HTTPClient: client})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, server, GenericError{Err: errors.New("expired CRL")}),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+
+	t.Run("CRL not signed by issuer", func(t *testing.T) {
+		server := "http://example.com/chain_crl/0"
+		certResult := CertCheckStatus(newLeaf(server), otherChain[1].Cert, Options{HTTPClient: client})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, server, GenericError{Err: errors.New("CRL is not signed by the certificate issuer: crypto/rsa: verification error")}),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := "http://example.com/chain_crl/0"
+		timeoutClient := &http.Client{Timeout: 1 * time.Nanosecond}
+		certResult := CertCheckStatus(newLeaf(server), issuer, Options{HTTPClient: timeoutClient})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, server, TimeoutError{}),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+
+	t.Run("bad status code", func(t *testing.T) {
+		server := "http://example.com/chain_crl/0"
+		badClient := &http.Client{Transport: statusRoundTripper{statusCode: http.StatusNotFound}}
+		certResult := CertCheckStatus(newLeaf(server), issuer, Options{HTTPClient: badClient})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, server, GenericError{Err: errors.New("failed to download CRL: response had status code 404")}),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+
+	t.Run("malformed CRL", func(t *testing.T) {
+		server := "http://example.com/chain_crl/0"
+		badClient := &http.Client{Transport: statusRoundTripper{statusCode: http.StatusOK, body: []byte("malformed")}}
+		certResult := CertCheckStatus(newLeaf(server), issuer, Options{HTTPClient: badClient})
+		if certResult.Result != result.ResultUnknown {
+			t.Errorf("Expected Result to be %s, but got %s", result.ResultUnknown, certResult.Result)
+		}
+		if _, ok := certResult.ServerResults[0].Error.(GenericError); !ok {
+			t.Errorf("Expected GenericError, but got %v", certResult.ServerResults[0].Error)
+		}
+	})
+
+	t.Run("fallback to second distribution point", func(t *testing.T) {
+		badServer := "http://example.com/expired_crl"
+		server := "http://example.com/chain_crl/0"
+		certResult := CertCheckStatus(newLeaf(badServer, server), issuer, Options{HTTPClient: client})
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{getOKCertResult(server)}, t)
+	})
+
+	t.Run("all distribution points fail", func(t *testing.T) {
+		badServer := "http://example.com/expired_crl"
+		ldapServer := "ldap://ds.example.com:123/chain_crl/0"
+		certResult := CertCheckStatus(newLeaf(badServer, ldapServer), issuer, Options{HTTPClient: client})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, badServer, GenericError{Err: errors.New("expired CRL")}),
+				result.NewServerResult(result.ResultUnknown, ldapServer, GenericError{Err: errors.New("CRLDistributionPoints protocol ldap is not supported")}),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+}
+
+func TestValidateCRL(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(3)
+	cert := testChain[0].Cert
+	issuer := testChain[1]
+
+	// a CA certificate issued by the same issuer
+	caCert := *testChain[1].Cert
+	caCert.RawIssuer = cert.RawIssuer
+
+	createCRL := func(template *x509.RevocationList) *x509.RevocationList {
+		template.Number = big.NewInt(1)
+		crlBytes, err := x509.CreateRevocationList(rand.Reader, template, issuer.Cert, issuer.PrivateKey)
+		if err != nil {
+			t.Fatalf("failed to create CRL: %v", err)
+		}
+		crl, err := x509.ParseRevocationList(crlBytes)
+		if err != nil {
+			t.Fatalf("failed to parse CRL: %v", err)
+		}
+		return crl
+	}
+
+	tests := []struct {
+		name      string
+		template  *x509.RevocationList
+		cert      *x509.Certificate
+		expectErr string
+	}{
+		{
+			name: "valid CRL",
+			template: &x509.RevocationList{
+				ThisUpdate: time.Now().Add(-time.Hour),
+				NextUpdate: time.Now().Add(time.Hour),
+			},
+			cert: cert,
+		},
+		{
+			name: "issuer mismatch",
+			template: &x509.RevocationList{
+				ThisUpdate: time.Now().Add(-time.Hour),
+				NextUpdate: time.Now().Add(time.Hour),
+			},
+			cert:      testChain[1].Cert,
+			expectErr: "CRL issuer does not match certificate issuer",
+		},
+		{
+			name: "not yet valid",
+			template: &x509.RevocationList{
+				ThisUpdate: time.Now().Add(time.Hour),
+				NextUpdate: time.Now().Add(2 * time.Hour),
+			},
+			cert:      cert,
+			expectErr: "CRL is not yet valid",
+		},
+		{
+			name: "delta CRL",
+			template: &x509.RevocationList{
+				ThisUpdate: time.Now().Add(-time.Hour),
+				NextUpdate: time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{
+					{Id: asn1.ObjectIdentifier{2, 5, 29, 27}, Critical: true, Value: []byte{0x02, 0x01, 0x01}},
+				},
+			},
+			cert:      cert,
+			expectErr: "delta CRL is not supported",
+		},
+		{
+			name: "matching issuing distribution point",
+			template: &x509.RevocationList{
+				ThisUpdate:      time.Now().Add(-time.Hour),
+				NextUpdate:      time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{idpExtension(issuingDistributionPoint{DistributionPoint: idpName(cert.CRLDistributionPoints[0]), OnlyContainsUserCerts: true})},
+			},
+			cert: cert,
+		},
+		{
+			name: "mismatched issuing distribution point",
+			template: &x509.RevocationList{
+				ThisUpdate:      time.Now().Add(-time.Hour),
+				NextUpdate:      time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{idpExtension(issuingDistributionPoint{DistributionPoint: idpName("http://example.com/partition/1")})},
+			},
+			cert:      cert,
+			expectErr: "CRL distribution point does not match the certificate",
+		},
+		{
+			name: "only user certificates",
+			template: &x509.RevocationList{
+				ThisUpdate:      time.Now().Add(-time.Hour),
+				NextUpdate:      time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{idpExtension(issuingDistributionPoint{OnlyContainsUserCerts: true})},
+			},
+			cert:      &caCert,
+			expectErr: "CRL only contains user certificates, but the certificate is a CA certificate",
+		},
+		{
+			name: "only CA certificates",
+			template: &x509.RevocationList{
+				ThisUpdate:      time.Now().Add(-time.Hour),
+				NextUpdate:      time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{idpExtension(issuingDistributionPoint{OnlyContainsCACerts: true})},
+			},
+			cert:      cert,
+			expectErr: "CRL only contains CA certificates, but the certificate is not a CA certificate",
+		},
+		{
+			name: "only attribute certificates",
+			template: &x509.RevocationList{
+				ThisUpdate:      time.Now().Add(-time.Hour),
+				NextUpdate:      time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{idpExtension(issuingDistributionPoint{OnlyContainsAttributeCerts: true})},
+			},
+			cert:      cert,
+			expectErr: "CRL only contains attribute certificates",
+		},
+		{
+			name: "only some reasons",
+			template: &x509.RevocationList{
+				ThisUpdate:      time.Now().Add(-time.Hour),
+				NextUpdate:      time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{idpExtension(issuingDistributionPoint{OnlySomeReasons: asn1.BitString{Bytes: []byte{0x40}, BitLength: 2}})},
+			},
+			cert:      cert,
+			expectErr: "CRL partitioned by reasons is not supported",
+		},
+		{
+			name: "indirect CRL",
+			template: &x509.RevocationList{
+				ThisUpdate:      time.Now().Add(-time.Hour),
+				NextUpdate:      time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{idpExtension(issuingDistributionPoint{IndirectCRL: true})},
+			},
+			cert:      cert,
+			expectErr: "indirect CRL is not supported",
+		},
+		{
+			name: "malformed issuing distribution point",
+			template: &x509.RevocationList{
+				ThisUpdate: time.Now().Add(-time.Hour),
+				NextUpdate: time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{
+					{Id: asn1.ObjectIdentifier{2, 5, 29, 28}, Critical: true, Value: []byte{0x30, 0x00, 0x00}},
+				},
+			},
+			cert:      cert,
+			expectErr: "invalid issuingDistributionPoint CRL extension: trailing data",
+		},
+		{
+			name: "unsupported critical extension",
+			template: &x509.RevocationList{
+				ThisUpdate: time.Now().Add(-time.Hour),
+				NextUpdate: time.Now().Add(time.Hour),
+				ExtraExtensions: []pkix.Extension{
+					{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Critical: true, Value: []byte{0x05, 0x00}},
+				},
+			},
+			cert:      cert,
+			expectErr: "unsupported critical CRL extension 1.2.3.4",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCRL(createCRL(tt.template), tt.cert, issuer.Cert)
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Errorf("Expected validateCRL to succeed, but got error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectErr {
+				t.Errorf("Expected validateCRL to fail with %v, but got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+// idpName returns the distribution point name with the URI.
+func idpName(uri string) distributionPointName {
+	return distributionPointName{
+		FullName: []asn1.RawValue{{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte(uri)}},
+	}
+}
+
+// idpExtension returns the critical issuingDistributionPoint extension.
+func idpExtension(idp issuingDistributionPoint) pkix.Extension {
+	value, err := asn1.Marshal(idp)
+	if err != nil {
+		panic(err)
+	}
+	return pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 28}, Critical: true, Value: value}
+}
+
+func TestCheckRevocationRemoveFromCRL(t *testing.T) {
+	cert := testhelper.GetRevokableRSAChainWithCRL(2)[0].Cert
+	reasonCode, _ := asn1.Marshal(reasonCodeRemoveFromCRL)
+	crl := &x509.RevocationList{
+		RevokedCertificates: []pkix.RevokedCertificate{{
+			SerialNumber:   cert.SerialNumber,
+			RevocationTime: time.Now().Add(-time.Hour),
+			Extensions:     []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 21}, Value: reasonCode}},
+		}},
+	}
+	if err := checkRevocation(crl, cert, time.Now()); err != nil {
+		t.Errorf("Expected removeFromCRL entry to be ignored, but got error: %v", err)
+	}
+}
+
+func TestCheckStatusWithContext(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(3)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert, testChain[2].Cert}
+	client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, nil)
+
+	t.Run("check non-revoked chain with context", func(t *testing.T) {
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  client,
+			Context:     context.Background(),
+		}
+
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  client,
+			Context:     ctx,
+		}
+
+		certResults, err := CheckStatus(opts)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected CheckStatus to fail with %v, but got: %v", context.Canceled, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+	})
+
+	t.Run("cancelled context for single cert", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		certResult := CertCheckStatus(revokableChain[0], revokableChain[1], Options{HTTPClient: client, Context: ctx})
+		expectedCertResult := &result.CertRevocationResult{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, revokableChain[0].CRLDistributionPoints[0], GenericError{Err: context.Canceled}),
+			},
+		}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{expectedCertResult}, t)
+	})
+}
+
+func TestCheckStatusWithCache(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(3)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert, testChain[2].Cert}
+
+	t.Run("cached CRL is reused", func(t *testing.T) {
+		c, _ := cache.NewMemoryCache(10)
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, []int{0}),
+			Cache:       c,
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getRevokedCertResult(revokableChain[0].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getRootCertResult(),
+		}
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+
+		// the CRL distribution points are no longer reachable
+		opts.HTTPClient = &http.Client{Transport: statusRoundTripper{statusCode: http.StatusServiceUnavailable}}
+		certResults, err = CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("cached CRL of another issuer is ignored", func(t *testing.T) {
+		c, _ := cache.NewMemoryCache(10)
+		otherChain := testhelper.GetRevokableRSAChainWithCRL(3)
+		server := revokableChain[0].CRLDistributionPoints[0]
+		otherClient := testhelper.MockClientWithCRL(otherChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, nil)
+		otherResult := CertCheckStatus(otherChain[0].Cert, otherChain[1].Cert, Options{HTTPClient: otherClient, Cache: c})
+		validateEquivalentCertResults([]*result.CertRevocationResult{otherResult}, []*result.CertRevocationResult{getOKCertResult(server)}, t)
+
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, []int{0})
+		certResult := CertCheckStatus(revokableChain[0], revokableChain[1], Options{HTTPClient: client, Cache: c})
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{getRevokedCertResult(server)}, t)
+	})
+}
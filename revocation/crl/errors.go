@@ -0,0 +1,55 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crl provides methods for checking the CRL revocation status of a
+// certificate chain, as well as errors related to these checks
+package crl
+
+import "fmt"
+
+// RevokedError is returned when the certificate is listed as revoked in the
+// CRL
+type RevokedError struct{}
+
+func (e RevokedError) Error() string {
+	return "certificate is revoked via CRL"
+}
+
+// GenericError is returned when there is an error during the CRL revocation
+// check, not necessarily a revocation
+type GenericError struct {
+	Err error
+}
+
+func (e GenericError) Error() string {
+	msg := "error checking revocation status via CRL"
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// NoServerError is returned when the CRLDistributionPoints is not specified.
+type NoServerError struct{}
+
+func (e NoServerError) Error() string {
+	return "no valid CRL distribution point found"
+}
+
+// TimeoutError is returned when the download of a CRL exceeds the timeout of
+// the HTTP client
+type TimeoutError struct{}
+
+func (e TimeoutError) Error() string {
+	return "exceeded timeout threshold for CRL download"
+}
@@ -50,6 +50,30 @@ func (e GenericError) Error() string {
 	return msg
 }
 
+// InvalidResponderError is returned when the OCSP response is signed by a
+// delegated responder whose certificate is not authorized to sign responses
+// on behalf of the issuer, as specified in RFC 6960 section 4.2.2.2
+type InvalidResponderError struct {
+	Err error
+}
+
+func (e InvalidResponderError) Error() string {
+	msg := "invalid OCSP responder certificate"
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// NonceNotSupportedError is returned when the nonce is enabled but the OCSP
+// response does not contain the nonce of the request, as the OCSP server
+// ignores nonces
+type NonceNotSupportedError struct{}
+
+func (e NonceNotSupportedError) Error() string {
+	return "OCSP server ignores the nonce of the request"
+}
+
 // NoServerError is returned when the OCSPServer is not specified.
 type NoServerError struct{}
 
@@ -64,5 +88,10 @@ type TimeoutError struct {
 }
 
 func (e TimeoutError) Error() string {
-	return fmt.Sprintf("exceeded timeout threshold of %.2f seconds for OCSP check", e.timeout.Seconds())
+	if e.timeout <= 0 {
+		// The timeout is set by the context or the transport of the HTTP
+		// client
+		return "exceeded timeout threshold for OCSP check"
+	}
+	return fmt.Sprintf("exceeded timeout threshold of %s for OCSP check", e.timeout)
 }
@@ -15,7 +15,6 @@ package ocsp
 
 import (
 	"errors"
-	"fmt"
 	"testing"
 	"time"
 )
@@ -58,6 +57,35 @@ func TestGenericError(t *testing.T) {
 	})
 }
 
+func TestInvalidResponderError(t *testing.T) {
+	t.Run("without_inner_error", func(t *testing.T) {
+		err := &InvalidResponderError{}
+		expectedMsg := "invalid OCSP responder certificate"
+
+		if err.Error() != expectedMsg {
+			t.Errorf("Expected %v but got %v", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("with_inner_error", func(t *testing.T) {
+		err := &InvalidResponderError{Err: errors.New("inner error")}
+		expectedMsg := "invalid OCSP responder certificate: inner error"
+
+		if err.Error() != expectedMsg {
+			t.Errorf("Expected %v but got %v", expectedMsg, err.Error())
+		}
+	})
+}
+
+func TestNonceNotSupportedError(t *testing.T) {
+	err := &NonceNotSupportedError{}
+	expectedMsg := "OCSP server ignores the nonce of the request"
+
+	if err.Error() != expectedMsg {
+		t.Errorf("Expected %v but got %v", expectedMsg, err.Error())
+	}
+}
+
 func TestNoServerError(t *testing.T) {
 	err := &NoServerError{}
 	expectedMsg := "no valid OCSP server found"
@@ -68,11 +96,31 @@ func TestNoServerError(t *testing.T) {
 }
 
 func TestTimeoutError(t *testing.T) {
-	duration := 5 * time.Second
-	err := &TimeoutError{duration}
-	expectedMsg := fmt.Sprintf("exceeded timeout threshold of %.2f seconds for OCSP check", duration.Seconds())
+	t.Run("with_threshold", func(t *testing.T) {
+		duration := 5 * time.Second
+		err := &TimeoutError{duration}
+		expectedMsg := "exceeded timeout threshold of 5s for OCSP check"
 
-	if err.Error() != expectedMsg {
-		t.Errorf("Expected %v but got %v", expectedMsg, err.Error())
-	}
+		if err.Error() != expectedMsg {
+			t.Errorf("Expected %v but got %v", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("with_sub_millisecond_threshold", func(t *testing.T) {
+		err := &TimeoutError{500 * time.Microsecond}
+		expectedMsg := "exceeded timeout threshold of 500µs for OCSP check"
+
+		if err.Error() != expectedMsg {
+			t.Errorf("Expected %v but got %v", expectedMsg, err.Error())
+		}
+	})
+
+	t.Run("without_threshold", func(t *testing.T) {
+		err := &TimeoutError{}
+		expectedMsg := "exceeded timeout threshold for OCSP check"
+
+		if err.Error() != expectedMsg {
+			t.Errorf("Expected %v but got %v", expectedMsg, err.Error())
+		}
+	})
 }
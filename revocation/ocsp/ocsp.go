@@ -17,7 +17,10 @@ package ocsp
 
 import (
 	"bytes"
+	"context"
 	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
@@ -25,12 +28,17 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	mrand "math/rand"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/notaryproject/notation-core-go/revocation/cache"
+	"github.com/notaryproject/notation-core-go/revocation/crl"
 	"github.com/notaryproject/notation-core-go/revocation/result"
 	coreX509 "github.com/notaryproject/notation-core-go/x509"
 	"golang.org/x/crypto/ocsp"
@@ -41,21 +49,184 @@ type Options struct {
 	CertChain   []*x509.Certificate
 	SigningTime time.Time
 	HTTPClient  *http.Client
+
+	// Context is passed into every HTTP request to the OCSP servers. When it
+	// is cancelled, the remaining checks are stopped. If it is nil,
+	// context.Background() is used.
+	Context context.Context
+
+	// Cache is used to store OCSP responses until their NextUpdate, so that
+	// they can be reused instead of querying the OCSP servers again. If it is
+	// nil, responses are not cached.
+	Cache cache.Cache
+
+	// Hash is the hash algorithm used to build the CertID of OCSP requests.
+	// It can be crypto.SHA1, crypto.SHA256, crypto.SHA384 or crypto.SHA512.
+	// If it is zero, crypto.SHA1 is used, as it is supported by all tested
+	// OCSP servers
+	Hash crypto.Hash
+
+	// HashFallback enables a second request with a CertID built with
+	// crypto.SHA1 if the OCSP server answers malformedRequest or the status
+	// unknown to the request built with Hash, as not all OCSP servers
+	// support other hash algorithms. It has no effect if Hash is crypto.SHA1
+	HashFallback bool
+
+	// Nonce enables the id-pkix-ocsp-nonce extension in OCSP requests to
+	// prevent the replay of previous responses. If enabled, the OCSP response
+	// must echo the nonce of the request, otherwise the status of the
+	// certificate is unknown. Responses served from Cache are not checked
+	Nonce bool
+
+	// AllowedSchemes is the list of URL schemes of the OCSP servers that can
+	// be queried. The supported schemes are "http" and "https", both using
+	// HTTPClient. If it is empty, both are allowed
+	AllowedSchemes []string
+
+	// URLRewriter is called with the URL of every OCSP server before it is
+	// queried, and the returned URL is queried instead. It can be used to
+	// redirect OCSP requests to an internal mirror. The original URL is kept
+	// in the ServerResult. If it is nil, the URLs are not rewritten
+	URLRewriter func(server string) (string, error)
+
+	// Retry specifies how requests to an OCSP server are retried when the
+	// server answers tryLater or a 5xx status code, or when the request fails
+	// or times out. If it is the zero value, requests are not retried
+	Retry RetryPolicy
+
+	// QueryMode specifies how the OCSP servers of a certificate are queried.
+	// The default is QuerySequential
+	QueryMode QueryMode
+
+	// HedgeDelay is the delay after which the next OCSP server is queried in
+	// QueryHedged mode. If it is zero, a delay of 500 milliseconds is used
+	HedgeDelay time.Duration
+}
+
+// QueryMode specifies how the OCSP servers of a certificate are queried
+type QueryMode int
+
+const (
+	// QuerySequential is a QueryMode that queries the OCSP servers one after
+	// another, until a server gives an authoritative answer
+	QuerySequential QueryMode = iota
+	// QueryParallel is a QueryMode that queries all OCSP servers at the same
+	// time and takes the first authoritative answer
+	QueryParallel
+	// QueryHedged is a QueryMode that queries the next OCSP server when the
+	// previous servers have not given an authoritative answer within the
+	// HedgeDelay or have failed, and takes the first authoritative answer
+	QueryHedged
+)
+
+const defaultHedgeDelay = 500 * time.Millisecond
+
+// RetryPolicy specifies the timeout, retries and backoff of the requests to
+// an OCSP server
+type RetryPolicy struct {
+	// Timeout is the timeout of a single attempt. If it is zero, only the
+	// timeout of the HTTP client applies
+	Timeout time.Duration
+
+	// MaxAttempts is the maximum number of attempts per OCSP server,
+	// including the first one. If it is less than 1, a single attempt is made
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. It is doubled for
+	// every further retry, up to MaxBackoff. A random jitter of up to half of
+	// the delay is subtracted from each delay
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between two attempts. If it is zero,
+	// the delay is not limited
+	MaxBackoff time.Duration
 }
 
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the delay after the given attempt, starting from 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff) && delay <= math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Use equal jitter so that retries of concurrent checks are spread out
+	// while keeping the exponential growth
+	half := delay / 2
+	return delay - time.Duration(mrand.Int63n(int64(half)+1))
+}
+
+// timeout returns the threshold after which a single request times out.
+func (opts Options) timeout() time.Duration {
+	if opts.Retry.Timeout > 0 && (opts.HTTPClient.Timeout <= 0 || opts.Retry.Timeout < opts.HTTPClient.Timeout) {
+		return opts.Retry.Timeout
+	}
+	return opts.HTTPClient.Timeout
+}
+
+func (opts Options) allowedSchemes() []string {
+	if len(opts.AllowedSchemes) == 0 {
+		return supportedSchemes
+	}
+	return opts.AllowedSchemes
+}
+
+func (opts Options) ctx() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+var (
+	errMalformedRequest = errors.New("OCSP malformed")
+	nonceOID            = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}
+	supportedSchemes    = []string{"http", "https"}
+)
+
 const (
 	pkixNoCheckOID    string = "1.3.6.1.5.5.7.48.1.5"
 	invalidityDateOID string = "2.5.29.24"
 	// Max size determined from https://www.ibm.com/docs/en/sva/9.0.6?topic=stanza-ocsp-max-response-size.
 	// Typical size is ~4 KB
 	ocspMaxResponseSize int64 = 20480 //bytes
+	// RFC 8954 limits the nonce to 32 bytes
+	nonceSize = 32
 )
 
 // CheckStatus checks OCSP based on the passed options and returns an array of
 // result.CertRevocationResult objects that contains the results and error. The
 // length of this array will always be equal to the length of the certificate
 // chain.
+//
+// If opts.Context is cancelled or its deadline is exceeded, the context error
+// is returned.
 func CheckStatus(opts Options) ([]*result.CertRevocationResult, error) {
+	ctx := opts.ctx()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	switch opts.Hash {
+	case 0, crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512:
+	default:
+		return nil, fmt.Errorf("invalid input: unsupported hash algorithm %s for OCSP CertID", opts.Hash)
+	}
+	for _, scheme := range opts.AllowedSchemes {
+		if !strings.EqualFold(scheme, "http") && !strings.EqualFold(scheme, "https") {
+			return nil, fmt.Errorf("invalid input: unsupported OCSP server URL scheme %q", scheme)
+		}
+	}
 	if len(opts.CertChain) == 0 {
 		return nil, result.InvalidChainError{Err: errors.New("chain does not contain any certificates")}
 	}
@@ -90,6 +261,9 @@ func CheckStatus(opts Options) ([]*result.CertRevocationResult, error) {
 	}
 
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return certResults, nil
 }
 
@@ -103,12 +277,20 @@ func certCheckStatus(cert, issuer *x509.Certificate, opts Options) *result.CertR
 		}
 	}
 
+	if opts.QueryMode != QuerySequential && len(ocspURLs) > 1 {
+		return certCheckStatusConcurrently(cert, issuer, opts)
+	}
+
 	serverResults := make([]*result.ServerResult, len(ocspURLs))
 	for serverIndex, server := range ocspURLs {
+		if err := opts.ctx().Err(); err != nil {
+			// The check has been cancelled, the remaining servers are
+			// not queried
+			serverResults[serverIndex] = toServerResult(server, GenericError{Err: err})
+			continue
+		}
 		serverResult := checkStatusFromServer(cert, issuer, server, opts)
-		if serverResult.Result == result.ResultOK ||
-			serverResult.Result == result.ResultRevoked ||
-			(serverResult.Result == result.ResultUnknown && errors.Is(serverResult.Error, UnknownStatusError{})) {
+		if isAuthoritative(serverResult) {
 			// A valid response has been received from an OCSP server
 			// Result should be based on only this response, not any errors from
 			// other servers
@@ -119,15 +301,139 @@ func certCheckStatus(cert, issuer *x509.Certificate, opts Options) *result.CertR
 	return serverResultsToCertRevocationResult(serverResults)
 }
 
+// certCheckStatusConcurrently queries the OCSP servers of the certificate
+// according to opts.QueryMode and returns as soon as a server gives an
+// authoritative answer, cancelling the remaining queries. The ServerResults
+// are the same as the ones of a sequential check.
+func certCheckStatusConcurrently(cert, issuer *x509.Certificate, opts Options) *result.CertRevocationResult {
+	ocspURLs := cert.OCSPServer
+	ctx, cancel := context.WithCancel(opts.ctx())
+	defer cancel()
+	queryOpts := opts
+	queryOpts.Context = ctx
+
+	type indexedResult struct {
+		index        int
+		serverResult *result.ServerResult
+	}
+	// The channel is buffered so that the remaining queries can finish after
+	// returning
+	results := make(chan indexedResult, len(ocspURLs))
+	next := 0
+	query := func() {
+		go func(i int, server string) {
+			results <- indexedResult{index: i, serverResult: checkStatusFromServer(cert, issuer, server, queryOpts)}
+		}(next, ocspURLs[next])
+		next++
+	}
+	query()
+	if opts.QueryMode == QueryParallel {
+		for next < len(ocspURLs) {
+			query()
+		}
+	}
+	hedgeDelay := opts.HedgeDelay
+	if hedgeDelay <= 0 {
+		hedgeDelay = defaultHedgeDelay
+	}
+
+	serverResults := make([]*result.ServerResult, len(ocspURLs))
+	for received := 0; received < len(ocspURLs); {
+		var timer *time.Timer
+		var hedge <-chan time.Time
+		if next < len(ocspURLs) {
+			timer = time.NewTimer(hedgeDelay)
+			hedge = timer.C
+		}
+		select {
+		case r := <-results:
+			received++
+			if isAuthoritative(r.serverResult) {
+				return serverResultsToCertRevocationResult([]*result.ServerResult{r.serverResult})
+			}
+			serverResults[r.index] = r.serverResult
+			if next < len(ocspURLs) {
+				// Do not wait for the hedge delay once a server has failed
+				query()
+			}
+		case <-hedge:
+			query()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	return serverResultsToCertRevocationResult(serverResults)
+}
+
+// isAuthoritative returns true if the server result is based on a valid
+// response, so that the results of other servers can be discarded.
+func isAuthoritative(serverResult *result.ServerResult) bool {
+	return serverResult.Result == result.ResultOK ||
+		serverResult.Result == result.ResultRevoked ||
+		(serverResult.Result == result.ResultUnknown && errors.Is(serverResult.Error, UnknownStatusError{}))
+}
+
 func checkStatusFromServer(cert, issuer *x509.Certificate, server string, opts Options) *result.ServerResult {
 	// Check valid server
-	if serverURL, err := url.Parse(server); err != nil || !strings.EqualFold(serverURL.Scheme, "http") {
-		// This function is only able to check servers that are accessible via HTTP
-		return toServerResult(server, GenericError{Err: fmt.Errorf("OCSPServer protocol %s is not supported", serverURL.Scheme)})
+	reqURL, err := resolveServerURL(server, opts)
+	if err != nil {
+		return toServerResult(server, err)
+	}
+
+	hash := opts.Hash
+	if hash == 0 {
+		hash = crypto.SHA1
+	}
+	serverResult := checkStatusWithHash(cert, issuer, server, reqURL, hash, opts)
+	if !opts.HashFallback || hash == crypto.SHA1 {
+		return serverResult
+	}
+	// Not all OCSP servers support CertIDs built with other hash algorithms
+	// than SHA-1. Such servers answer either malformedRequest or the status
+	// unknown
+	if !errors.Is(serverResult.Error, GenericError{Err: errMalformedRequest}) &&
+		!errors.Is(serverResult.Error, UnknownStatusError{}) {
+		return serverResult
 	}
+	return checkStatusWithHash(cert, issuer, server, reqURL, crypto.SHA1, opts)
+}
 
+// resolveServerURL returns the URL that is queried for server, after applying
+// opts.URLRewriter, and checks that its scheme is allowed.
+func resolveServerURL(server string, opts Options) (string, error) {
+	reqURL := server
+	if opts.URLRewriter != nil {
+		var err error
+		reqURL, err = opts.URLRewriter(server)
+		if err != nil {
+			return "", GenericError{Err: fmt.Errorf("failed to rewrite OCSP server URL %q: %v", server, err)}
+		}
+	}
+	serverURL, err := url.Parse(reqURL)
+	if err != nil {
+		return "", GenericError{Err: fmt.Errorf("invalid OCSP server URL %q: %v", reqURL, err)}
+	}
+	for _, scheme := range opts.allowedSchemes() {
+		if strings.EqualFold(serverURL.Scheme, scheme) {
+			return reqURL, nil
+		}
+	}
+	return "", GenericError{Err: fmt.Errorf("OCSPServer protocol %s is not supported", serverURL.Scheme)}
+}
+
+// checkStatusWithHash checks the status of the certificate from server, which
+// is queried at reqURL, with an OCSP request whose CertID is built with hash,
+// and records hash in the result.
+func checkStatusWithHash(cert, issuer *x509.Certificate, server, reqURL string, hash crypto.Hash, opts Options) *result.ServerResult {
+	serverResult := checkStatusFromResponse(cert, issuer, server, reqURL, hash, opts)
+	serverResult.CertIDHash = hash
+	return serverResult
+}
+
+func checkStatusFromResponse(cert, issuer *x509.Certificate, server, reqURL string, hash crypto.Hash, opts Options) *result.ServerResult {
 	// Create OCSP Request
-	resp, err := executeOCSPCheck(cert, issuer, server, opts)
+	resp, err := executeOCSPCheck(cert, issuer, reqURL, hash, opts)
 	if err != nil {
 		// If there is a server error, attempt all servers before determining what to return
 		// to the user
@@ -139,16 +445,14 @@ func checkStatusFromServer(cert, issuer *x509.Certificate, server string, opts O
 		return toServerResult(server, GenericError{Err: errors.New("expired OCSP response")})
 	}
 
-	// Handle pkix-ocsp-no-check and id-ce-invalidityDate extensions if present
-	// in response
-	extensionMap := extensionsToMap(resp.Extensions)
-	if _, foundNoCheck := extensionMap[pkixNoCheckOID]; !foundNoCheck {
-		// This will be ignored until CRL is implemented
-		// If it isn't found, CRL should be used to verify the OCSP response
-		_ = foundNoCheck // needed to bypass linter warnings (Remove after adding CRL)
-		// TODO: add CRL support
-		// https://github.com/notaryproject/notation-core-go/issues/125
+	// Validate the delegated responder if the response is not signed by the
+	// issuer itself
+	if err := validateResponder(resp.Certificate, issuer, opts); err != nil {
+		return toServerResult(server, err)
 	}
+
+	// Handle id-ce-invalidityDate extension if present in response
+	extensionMap := extensionsToMap(resp.Extensions)
 	if invalidityDateBytes, foundInvalidityDate := extensionMap[invalidityDateOID]; foundInvalidityDate && !opts.SigningTime.IsZero() && resp.Status == ocsp.Revoked {
 		var invalidityDate time.Time
 		rest, err := asn1.UnmarshalWithParams(invalidityDateBytes, &invalidityDate, "generalized")
@@ -169,6 +473,58 @@ func checkStatusFromServer(cert, issuer *x509.Certificate, server string, opts O
 	}
 }
 
+// validateResponder validates the certificate of a delegated OCSP responder
+// against the requirements of RFC 6960 section 4.2.2.2. The signature of the
+// responder certificate by the issuer has already been verified by
+// ocsp.ParseResponseForCert.
+func validateResponder(responder, issuer *x509.Certificate, opts Options) error {
+	if responder == nil || responder.Equal(issuer) {
+		// Response is signed by the issuer
+		return nil
+	}
+
+	if !bytes.Equal(responder.RawIssuer, issuer.RawSubject) {
+		return InvalidResponderError{Err: fmt.Errorf("responder certificate with subject %q is not issued by the certificate issuer", responder.Subject)}
+	}
+	if !hasOCSPSigningEKU(responder) {
+		return InvalidResponderError{Err: fmt.Errorf("responder certificate with subject %q does not have the OCSPSigning extended key usage", responder.Subject)}
+	}
+	now := time.Now()
+	if now.Before(responder.NotBefore) || now.After(responder.NotAfter) {
+		return InvalidResponderError{Err: fmt.Errorf("responder certificate with subject %q is not valid at %s", responder.Subject, now.UTC())}
+	}
+
+	// A responder certificate with the id-pkix-ocsp-nocheck extension is
+	// trusted for its lifetime. Otherwise, its revocation status is checked
+	// via CRL. If the responder certificate does not specify any CRL
+	// distribution point, it is trusted as well.
+	if _, foundNoCheck := extensionsToMap(responder.Extensions)[pkixNoCheckOID]; foundNoCheck {
+		return nil
+	}
+	crlResult := crl.CertCheckStatus(responder, issuer, crl.Options{
+		HTTPClient: opts.HTTPClient,
+		Context:    opts.Context,
+		Cache:      opts.Cache,
+	})
+	switch crlResult.Result {
+	case result.ResultOK, result.ResultNonRevokable:
+		return nil
+	case result.ResultRevoked:
+		return InvalidResponderError{Err: fmt.Errorf("responder certificate with subject %q is revoked", responder.Subject)}
+	default:
+		return InvalidResponderError{Err: fmt.Errorf("revocation status of responder certificate with subject %q is unknown: %v", responder.Subject, crlResult.ServerResults[len(crlResult.ServerResults)-1].Error)}
+	}
+}
+
+func hasOCSPSigningEKU(cert *x509.Certificate) bool {
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageOCSPSigning {
+			return true
+		}
+	}
+	return false
+}
+
 func extensionsToMap(extensions []pkix.Extension) map[string][]byte {
 	extensionMap := make(map[string][]byte)
 	for _, extension := range extensions {
@@ -177,23 +533,77 @@ func extensionsToMap(extensions []pkix.Extension) map[string][]byte {
 	return extensionMap
 }
 
-func executeOCSPCheck(cert, issuer *x509.Certificate, server string, opts Options) (*ocsp.Response, error) {
-	// TODO: Look into other alternatives for specifying the Hash
-	// https://github.com/notaryproject/notation-core-go/issues/139
+func executeOCSPCheck(cert, issuer *x509.Certificate, server string, hash crypto.Hash, opts Options) (*ocsp.Response, error) {
+	// Reuse the cached response if it is still valid
+	key := cacheKey(issuer, cert.SerialNumber, hash)
+	if resp, ok := getCachedResponse(cert, issuer, key, opts); ok {
+		return resp, nil
+	}
+
 	// The following do not support SHA256 hashes:
 	//  - Microsoft
 	//  - Entrust
 	//  - Let's Encrypt
 	//  - Digicert (sometimes)
-	// As this represents a large percentage of public CAs, we are using the
-	// hashing algorithm SHA1, which has been confirmed to be supported by all
-	// that were tested.
-	ocspRequest, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	// As this represents a large percentage of public CAs, the hash is
+	// configurable, with SHA1 as the default.
+	var nonce []byte
+	if opts.Nonce {
+		nonce = make([]byte, nonceSize)
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, GenericError{Err: err}
+		}
+	}
+	ocspRequest, err := createRequest(cert, issuer, hash, nonce)
 	if err != nil {
 		return nil, GenericError{Err: err}
 	}
 
+	var body []byte
+	for attempt := 1; ; attempt++ {
+		var retryable bool
+		body, retryable, err = sendRequest(ocspRequest, server, opts)
+		if err == nil || !retryable || attempt >= opts.Retry.maxAttempts() {
+			break
+		}
+		if err := sleep(opts.ctx(), opts.Retry.backoff(attempt)); err != nil {
+			return nil, GenericError{Err: err}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ocspResp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Nonce {
+		if err := checkNonce(ocspResp, nonce); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Cache != nil && time.Now().Before(ocspResp.NextUpdate) {
+		// A failure to cache the response does not affect the result of the
+		// check
+		_ = opts.Cache.Set(opts.ctx(), key, body, ocspResp.NextUpdate)
+	}
+	return ocspResp, nil
+}
+
+// sendRequest sends the OCSP request to server within the timeout of a
+// single attempt and returns the body of the response. It also reports
+// whether the request can be retried upon an error.
+func sendRequest(ocspRequest []byte, server string, opts Options) ([]byte, bool, error) {
+	ctx := opts.ctx()
+	if opts.Retry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Retry.Timeout)
+		defer cancel()
+	}
+
 	var resp *http.Response
+	var err error
 	postRequired := base64.StdEncoding.EncodedLen(len(ocspRequest)) >= 255
 	if !postRequired {
 		encodedReq := url.QueryEscape(base64.StdEncoding.EncodeToString(ocspRequest))
@@ -201,53 +611,163 @@ func executeOCSPCheck(cert, issuer *x509.Certificate, server string, opts Option
 			var reqURL string
 			reqURL, err = url.JoinPath(server, encodedReq)
 			if err != nil {
-				return nil, GenericError{Err: err}
+				return nil, false, GenericError{Err: err}
 			}
-			resp, err = opts.HTTPClient.Get(reqURL)
+			resp, err = getRequest(ctx, reqURL, opts.HTTPClient)
 		} else {
-			resp, err = postRequest(ocspRequest, server, opts.HTTPClient)
+			resp, err = postRequest(ctx, ocspRequest, server, opts.HTTPClient)
 		}
 	} else {
-		resp, err = postRequest(ocspRequest, server, opts.HTTPClient)
+		resp, err = postRequest(ctx, ocspRequest, server, opts.HTTPClient)
 	}
 
 	if err != nil {
+		// The request is not retried once the check has been cancelled
+		retryable := opts.ctx().Err() == nil
 		var urlErr *url.Error
 		if errors.As(err, &urlErr) && urlErr.Timeout() {
-			return nil, TimeoutError{}
+			return nil, retryable, TimeoutError{timeout: opts.timeout()}
 		}
-		return nil, GenericError{Err: err}
+		return nil, retryable, GenericError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("failed to retrieve OCSP: response had status code %d", resp.StatusCode)
+		return nil, resp.StatusCode >= 500, fmt.Errorf("failed to retrieve OCSP: response had status code %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, ocspMaxResponseSize))
 	if err != nil {
-		return nil, GenericError{Err: err}
+		return nil, false, GenericError{Err: err}
 	}
 
 	switch {
 	case bytes.Equal(body, ocsp.UnauthorizedErrorResponse):
-		return nil, GenericError{Err: errors.New("OCSP unauthorized")}
+		return nil, false, GenericError{Err: errors.New("OCSP unauthorized")}
 	case bytes.Equal(body, ocsp.MalformedRequestErrorResponse):
-		return nil, GenericError{Err: errors.New("OCSP malformed")}
+		return nil, false, GenericError{Err: errMalformedRequest}
 	case bytes.Equal(body, ocsp.InternalErrorErrorResponse):
-		return nil, GenericError{Err: errors.New("OCSP internal error")}
+		return nil, false, GenericError{Err: errors.New("OCSP internal error")}
 	case bytes.Equal(body, ocsp.TryLaterErrorResponse):
-		return nil, GenericError{Err: errors.New("OCSP try later")}
+		return nil, true, GenericError{Err: errors.New("OCSP try later")}
 	case bytes.Equal(body, ocsp.SigRequredErrorResponse):
-		return nil, GenericError{Err: errors.New("OCSP signature required")}
+		return nil, false, GenericError{Err: errors.New("OCSP signature required")}
+	}
+	return body, false, nil
+}
+
+// sleep waits for d unless ctx is done first, in which case the context error
+// is returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ocspRequestASN1 is the ASN.1 structure of an OCSP request as specified in
+// RFC 6960 section 4.1.1, with the request list left encoded.
+type ocspRequestASN1 struct {
+	TBSRequest tbsRequestASN1
+}
+
+type tbsRequestASN1 struct {
+	Version           int              `asn1:"explicit,tag:0,default:0,optional"`
+	RequestorName     pkix.RDNSequence `asn1:"explicit,tag:1,optional"`
+	RequestList       []asn1.RawValue
+	RequestExtensions []pkix.Extension `asn1:"explicit,tag:2,optional"`
+}
+
+// createRequest creates an OCSP request for cert with the CertID built with
+// hash. If nonce is not empty, it is added to the request as the
+// id-pkix-ocsp-nonce extension.
+func createRequest(cert, issuer *x509.Certificate, hash crypto.Hash, nonce []byte) ([]byte, error) {
+	ocspRequest, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: hash})
+	if err != nil || len(nonce) == 0 {
+		return ocspRequest, err
 	}
 
-	return ocsp.ParseResponseForCert(body, cert, issuer)
+	// golang.org/x/crypto/ocsp does not support request extensions, so the
+	// request is re-encoded with the nonce extension
+	var req ocspRequestASN1
+	if rest, err := asn1.Unmarshal(ocspRequest, &req); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data in OCSP request")
+	}
+	nonceValue, err := asn1.Marshal(nonce)
+	if err != nil {
+		return nil, err
+	}
+	req.TBSRequest.RequestExtensions = append(req.TBSRequest.RequestExtensions, pkix.Extension{Id: nonceOID, Value: nonceValue})
+	return asn1.Marshal(req)
 }
 
-func postRequest(req []byte, server string, httpClient *http.Client) (*http.Response, error) {
-	reader := bytes.NewReader(req)
-	return httpClient.Post(server, "application/ocsp-request", reader)
+// checkNonce checks that the OCSP response echoes the nonce of the request.
+func checkNonce(resp *ocsp.Response, nonce []byte) error {
+	for _, ext := range resp.Extensions {
+		if !ext.Id.Equal(nonceOID) {
+			continue
+		}
+		// Some OCSP servers return the nonce without wrapping it in an
+		// OCTET STRING
+		var value []byte
+		if rest, err := asn1.Unmarshal(ext.Value, &value); err == nil && len(rest) == 0 && bytes.Equal(value, nonce) {
+			return nil
+		}
+		if bytes.Equal(ext.Value, nonce) {
+			return nil
+		}
+		return GenericError{Err: errors.New("nonce of OCSP response does not match the nonce of the request")}
+	}
+	return NonceNotSupportedError{}
+}
+
+// getCachedResponse returns the OCSP response for cert from opts.Cache if it
+// is present and has not expired.
+func getCachedResponse(cert, issuer *x509.Certificate, key string, opts Options) (*ocsp.Response, bool) {
+	if opts.Cache == nil {
+		return nil, false
+	}
+	body, err := opts.Cache.Get(opts.ctx(), key)
+	if err != nil {
+		return nil, false
+	}
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil || !time.Now().Before(resp.NextUpdate) {
+		return nil, false
+	}
+	return resp, true
+}
+
+// cacheKey returns the key of the OCSP response for the certificate with
+// serialNumber issued by issuer, requested with a CertID built with hash. The
+// responses to CertIDs built with different hash algorithms are cached
+// separately, as a server may not support all of them.
+func cacheKey(issuer *x509.Certificate, serialNumber *big.Int, hash crypto.Hash) string {
+	issuerHash := sha256.Sum256(issuer.Raw)
+	return fmt.Sprintf("ocsp:%x:%s:%s", issuerHash, serialNumber.Text(16), hash)
+}
+
+func getRequest(ctx context.Context, reqURL string, httpClient *http.Client) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
+func postRequest(ctx context.Context, body []byte, server string, httpClient *http.Client) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	return httpClient.Do(req)
 }
 
 func toServerResult(server string, err error) *result.ServerResult {
@@ -259,8 +779,8 @@ func toServerResult(server string, err error) *result.ServerResult {
 	case RevokedError:
 		return result.NewServerResult(result.ResultRevoked, server, t)
 	default:
-		// Includes GenericError, UnknownStatusError, result.InvalidChainError,
-		// and TimeoutError
+		// Includes GenericError, UnknownStatusError, InvalidResponderError,
+		// NonceNotSupportedError, result.InvalidChainError, and TimeoutError
 		return result.NewServerResult(result.ResultUnknown, server, t)
 	}
 }
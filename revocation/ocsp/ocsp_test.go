@@ -14,13 +14,22 @@
 package ocsp
 
 import (
+	"bytes"
+	"context"
+	"crypto"
 	"crypto/x509"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/notaryproject/notation-core-go/revocation/cache"
 	"github.com/notaryproject/notation-core-go/revocation/result"
 	"github.com/notaryproject/notation-core-go/testhelper"
 	"golang.org/x/crypto/ocsp"
@@ -546,13 +555,13 @@ func TestCheckStatusErrors(t *testing.T) {
 			{
 				Result: result.ResultUnknown,
 				ServerResults: []*result.ServerResult{
-					result.NewServerResult(result.ResultUnknown, okChain[0].OCSPServer[0], TimeoutError{}),
+					result.NewServerResult(result.ResultUnknown, okChain[0].OCSPServer[0], TimeoutError{timeout: timeoutClient.Timeout}),
 				},
 			},
 			{
 				Result: result.ResultUnknown,
 				ServerResults: []*result.ServerResult{
-					result.NewServerResult(result.ResultUnknown, okChain[1].OCSPServer[0], TimeoutError{}),
+					result.NewServerResult(result.ResultUnknown, okChain[1].OCSPServer[0], TimeoutError{timeout: timeoutClient.Timeout}),
 				},
 			},
 			getRootCertResult(),
@@ -683,3 +692,884 @@ func TestCheckOCSPInvalidChain(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckStatusWithDelegatedResponder(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(2)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert}
+	server := revokableChain[0].OCSPServer[0]
+	responderSubject := "CN=Notation Test OCSP Responder,O=Notary,L=Seattle,ST=WA,C=US"
+
+	t.Run("responder with pkix-ocsp-nocheck", func(t *testing.T) {
+		responder := testhelper.GetRSAOCSPResponderCertTuple(testChain[1], true, true)
+		client := testhelper.MockClientWithResponder(testChain, []ocsp.ResponseStatus{ocsp.Good}, responder, true)
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: client})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(server),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("responder not revoked via CRL", func(t *testing.T) {
+		responder := testhelper.GetRSAOCSPResponderCertTuple(testChain[1], true, false)
+		client := testhelper.MockClientWithResponder(testChain, []ocsp.ResponseStatus{ocsp.Revoked}, responder, false)
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: client})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultRevoked,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultRevoked, server, RevokedError{}),
+				},
+			},
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("responder revoked via CRL", func(t *testing.T) {
+		responder := testhelper.GetRSAOCSPResponderCertTuple(testChain[1], true, false)
+		client := testhelper.MockClientWithResponder(testChain, []ocsp.ResponseStatus{ocsp.Revoked}, responder, true)
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: client})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		responderErr := InvalidResponderError{Err: fmt.Errorf("responder certificate with subject %q is revoked", responderSubject)}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, server, responderErr),
+				},
+			},
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("responder without OCSPSigning EKU", func(t *testing.T) {
+		responder := testhelper.GetRSAOCSPResponderCertTuple(testChain[1], false, true)
+		client := testhelper.MockClientWithResponder(testChain, []ocsp.ResponseStatus{ocsp.Good}, responder, false)
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: client})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		responderErr := InvalidResponderError{Err: fmt.Errorf("responder certificate with subject %q does not have the OCSPSigning extended key usage", responderSubject)}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, server, responderErr),
+				},
+			},
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("responder not issued by the certificate issuer", func(t *testing.T) {
+		responder := testhelper.GetRSAOCSPResponderCertTuple(testhelper.GetRSARootCertificate(), true, true)
+		client := testhelper.MockClientWithResponder(testChain, []ocsp.ResponseStatus{ocsp.Good}, responder, false)
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: client})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		if certResults[0].Result != result.ResultUnknown {
+			t.Errorf("Expected certResults[0].Result to be %s, but got %s", result.ResultUnknown, certResults[0].Result)
+		}
+	})
+}
+
+func TestValidateResponder(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(2)
+
+	t.Run("response signed by issuer", func(t *testing.T) {
+		if err := validateResponder(nil, testChain[1].Cert, Options{}); err != nil {
+			t.Errorf("Expected validateResponder to succeed, but got error: %v", err)
+		}
+		if err := validateResponder(testChain[1].Cert, testChain[1].Cert, Options{}); err != nil {
+			t.Errorf("Expected validateResponder to succeed, but got error: %v", err)
+		}
+	})
+
+	t.Run("responder with mismatched issuer name", func(t *testing.T) {
+		responder := testhelper.GetRSAOCSPResponderCertTuple(testhelper.GetRSARootCertificate(), true, true)
+		err := validateResponder(responder.Cert, testChain[1].Cert, Options{})
+		var responderErr InvalidResponderError
+		if !errors.As(err, &responderErr) {
+			t.Errorf("Expected InvalidResponderError, but got: %v", err)
+		}
+	})
+
+	t.Run("responder with unknown revocation status", func(t *testing.T) {
+		responder := testhelper.GetRSAOCSPResponderCertTuple(testChain[1], true, false)
+		client := &http.Client{Transport: failingRoundTripper{}}
+		err := validateResponder(responder.Cert, testChain[1].Cert, Options{HTTPClient: client})
+		var responderErr InvalidResponderError
+		if !errors.As(err, &responderErr) {
+			t.Errorf("Expected InvalidResponderError, but got: %v", err)
+		}
+	})
+}
+
+type blockingRoundTripper struct{}
+
+func (blockingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestCheckStatusWithContext(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(3)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert, testChain[2].Cert}
+
+	t.Run("check non-revoked chain with context", func(t *testing.T) {
+		client := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true)
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  client,
+			Context:     context.Background(),
+		}
+
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].OCSPServer[0]),
+			getOKCertResult(revokableChain[1].OCSPServer[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		client := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  client,
+			Context:     ctx,
+		}
+
+		certResults, err := CheckStatus(opts)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected CheckStatus to fail with %v, but got: %v", context.Canceled, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+	})
+
+	t.Run("deadline exceeded during check", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  &http.Client{Transport: blockingRoundTripper{}},
+			Context:     ctx,
+		}
+
+		start := time.Now()
+		certResults, err := CheckStatus(opts)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected CheckStatus to fail with %v, but got: %v", context.DeadlineExceeded, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected CheckStatus to stop when the deadline is exceeded, but it took %v", elapsed)
+		}
+	})
+
+	t.Run("remaining servers are skipped after cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		leaf, _ := x509.ParseCertificate(revokableChain[0].Raw)
+		leaf.OCSPServer = []string{"http://example.com/chain_ocsp/0", "http://example.com/chain_ocsp/0"}
+		opts := Options{
+			SigningTime: time.Now(),
+			HTTPClient:  testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true),
+			Context:     ctx,
+		}
+
+		certResult := certCheckStatus(leaf, revokableChain[1], opts)
+		cancelErr := GenericError{Err: context.Canceled}
+		expectedCertResults := []*result.CertRevocationResult{{
+			Result: result.ResultUnknown,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultUnknown, leaf.OCSPServer[0], cancelErr),
+				result.NewServerResult(result.ResultUnknown, leaf.OCSPServer[1], cancelErr),
+			},
+		}}
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, expectedCertResults, t)
+	})
+}
+
+type failingRoundTripper struct{}
+
+func (failingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("network unavailable")
+}
+
+func TestCheckStatusWithCache(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(3)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert, testChain[2].Cert}
+	expectedCertResults := []*result.CertRevocationResult{
+		getOKCertResult(revokableChain[0].OCSPServer[0]),
+		getOKCertResult(revokableChain[1].OCSPServer[0]),
+		getRootCertResult(),
+	}
+
+	t.Run("cached response is reused", func(t *testing.T) {
+		c, _ := cache.NewMemoryCache(10)
+		opts := Options{
+			CertChain:   revokableChain,
+			SigningTime: time.Now(),
+			HTTPClient:  testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true),
+			Cache:       c,
+		}
+		certResults, err := CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+
+		// the OCSP servers are no longer reachable
+		opts.HTTPClient = &http.Client{Transport: failingRoundTripper{}}
+		certResults, err = CheckStatus(opts)
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("expired response is not cached", func(t *testing.T) {
+		c, _ := cache.NewMemoryCache(10)
+		expiredLeaf, _ := x509.ParseCertificate(revokableChain[0].Raw)
+		expiredLeaf.OCSPServer = []string{"http://example.com/expired_ocsp"}
+		opts := Options{
+			SigningTime: time.Now(),
+			HTTPClient:  testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true),
+			Cache:       c,
+		}
+		certCheckStatus(expiredLeaf, revokableChain[1], opts)
+		if _, err := c.Get(context.Background(), cacheKey(revokableChain[1], expiredLeaf.SerialNumber, crypto.SHA1)); !errors.Is(err, cache.ErrCacheMiss) {
+			t.Errorf("Expected expired response not to be cached, but got %v", err)
+		}
+	})
+
+	t.Run("invalid cached response is ignored", func(t *testing.T) {
+		c, _ := cache.NewMemoryCache(10)
+		c.Set(context.Background(), cacheKey(revokableChain[1], revokableChain[0].SerialNumber, crypto.SHA1), []byte("invalid"), time.Now().Add(time.Hour))
+		opts := Options{
+			SigningTime: time.Now(),
+			HTTPClient:  testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true),
+			Cache:       c,
+		}
+		certResult := certCheckStatus(revokableChain[0], revokableChain[1], opts)
+		validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, expectedCertResults[:1], t)
+	})
+}
+
+// hashRoundTripper simulates an OCSP server that only supports CertIDs built
+// with SHA-1. Requests with other hashes are answered with malformedRequest,
+// or with the status unknown if unknownStatus is set.
+type hashRoundTripper struct {
+	good          http.RoundTripper
+	unknown       http.RoundTripper
+	unknownStatus bool
+	sha1Only      bool
+	hashes        []crypto.Hash
+}
+
+func (h *hashRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var rawRequest []byte
+	var err error
+	if req.Method == http.MethodPost {
+		rawRequest, err = io.ReadAll(req.Body)
+	} else {
+		rawRequest, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(req.URL.Path, "/chain_ocsp/0/"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	ocspRequest, err := ocsp.ParseRequest(rawRequest)
+	if err != nil {
+		return nil, err
+	}
+	h.hashes = append(h.hashes, ocspRequest.HashAlgorithm)
+
+	if !h.sha1Only || ocspRequest.HashAlgorithm == crypto.SHA1 {
+		return h.good.RoundTrip(req)
+	}
+	if h.unknownStatus {
+		return h.unknown.RoundTrip(req)
+	}
+	return &http.Response{
+		Body:       io.NopCloser(bytes.NewReader(ocsp.MalformedRequestErrorResponse)),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+func TestCheckStatusWithHash(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(2)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert}
+	good := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true).Transport
+	unknown := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Unknown}, nil, true).Transport
+
+	tests := []struct {
+		name           string
+		hash           crypto.Hash
+		fallback       bool
+		sha1Only       bool
+		unknownStatus  bool
+		expectedResult result.Result
+		expectedHashes []crypto.Hash
+	}{
+		{"default hash", 0, false, false, false, result.ResultOK, []crypto.Hash{crypto.SHA1}},
+		{"SHA256", crypto.SHA256, false, false, false, result.ResultOK, []crypto.Hash{crypto.SHA256}},
+		{"SHA256 not supported", crypto.SHA256, false, true, false, result.ResultUnknown, []crypto.Hash{crypto.SHA256}},
+		{"fallback with SHA256 supported", crypto.SHA256, true, false, false, result.ResultOK, []crypto.Hash{crypto.SHA256}},
+		{"fallback with malformedRequest", crypto.SHA256, true, true, false, result.ResultOK, []crypto.Hash{crypto.SHA256, crypto.SHA1}},
+		{"fallback with unknown status", crypto.SHA256, true, true, true, result.ResultOK, []crypto.Hash{crypto.SHA256, crypto.SHA1}},
+		{"fallback with default hash", 0, true, false, false, result.ResultOK, []crypto.Hash{crypto.SHA1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := &hashRoundTripper{good: good, unknown: unknown, sha1Only: tt.sha1Only, unknownStatus: tt.unknownStatus}
+			certResults, err := CheckStatus(Options{
+				CertChain:    revokableChain,
+				HTTPClient:   &http.Client{Transport: transport},
+				Hash:         tt.hash,
+				HashFallback: tt.fallback,
+			})
+			if err != nil {
+				t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+			}
+			serverResult := certResults[0].ServerResults[0]
+			if serverResult.Result != tt.expectedResult {
+				t.Errorf("Expected result to be %s, but got %s", tt.expectedResult, serverResult.Result)
+			}
+			expectedHash := tt.expectedHashes[len(tt.expectedHashes)-1]
+			if serverResult.CertIDHash != expectedHash {
+				t.Errorf("Expected CertIDHash to be %s, but got %s", expectedHash, serverResult.CertIDHash)
+			}
+			if !reflect.DeepEqual(transport.hashes, tt.expectedHashes) {
+				t.Errorf("Expected requests with hashes %v, but got %v", tt.expectedHashes, transport.hashes)
+			}
+		})
+	}
+
+	t.Run("fallback with cache", func(t *testing.T) {
+		c, _ := cache.NewMemoryCache(10)
+		transport := &hashRoundTripper{good: good, unknown: unknown, sha1Only: true, unknownStatus: true}
+		opts := Options{
+			CertChain:    revokableChain,
+			SigningTime:  time.Now(),
+			HTTPClient:   &http.Client{Transport: transport},
+			Cache:        c,
+			Hash:         crypto.SHA256,
+			HashFallback: true,
+		}
+		for i, expectedHashes := range [][]crypto.Hash{
+			{crypto.SHA256, crypto.SHA1},
+			{crypto.SHA256, crypto.SHA1}, // both responses are cached
+		} {
+			certResults, err := CheckStatus(opts)
+			if err != nil {
+				t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+			}
+			serverResult := certResults[0].ServerResults[0]
+			if serverResult.Result != result.ResultOK {
+				t.Errorf("check %d: expected result to be %s, but got %s", i, result.ResultOK, serverResult.Result)
+			}
+			if serverResult.CertIDHash != crypto.SHA1 {
+				t.Errorf("check %d: expected CertIDHash to be %s, but got %s", i, crypto.SHA1, serverResult.CertIDHash)
+			}
+			if !reflect.DeepEqual(transport.hashes, expectedHashes) {
+				t.Errorf("check %d: expected requests with hashes %v, but got %v", i, expectedHashes, transport.hashes)
+			}
+		}
+	})
+
+	t.Run("unsupported hash", func(t *testing.T) {
+		certResults, err := CheckStatus(Options{
+			CertChain:  revokableChain,
+			HTTPClient: &http.Client{Transport: good},
+			Hash:       crypto.MD5,
+		})
+		expectedErr := errors.New("invalid input: unsupported hash algorithm MD5 for OCSP CertID")
+		if err == nil || err.Error() != expectedErr.Error() {
+			t.Errorf("Expected CheckStatus to fail with %v, but got: %v", expectedErr, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+	})
+}
+
+type staticRoundTripper struct {
+	body []byte
+}
+
+func (s staticRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Body:       io.NopCloser(bytes.NewReader(s.body)),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
+func TestCheckStatusWithNonce(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(2)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert}
+	server := revokableChain[0].OCSPServer[0]
+	client := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true)
+
+	// fetchResponse retrieves a response for a request with the nonce via POST
+	fetchResponse := func(t *testing.T, nonce []byte) []byte {
+		ocspRequest, err := createRequest(revokableChain[0], revokableChain[1], crypto.SHA1, nonce)
+		if err != nil {
+			t.Fatalf("Expected createRequest to succeed, but got error: %v", err)
+		}
+		resp, err := postRequest(context.Background(), ocspRequest, server, client)
+		if err != nil {
+			t.Fatalf("Expected postRequest to succeed, but got error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("Expected to read response, but got error: %v", err)
+		}
+		return body
+	}
+
+	t.Run("nonce echoed via GET", func(t *testing.T) {
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: client, Nonce: true})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(server),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("nonce echoed via POST", func(t *testing.T) {
+		nonce := []byte("0123456789abcdef0123456789abcdef")
+		resp, err := ocsp.ParseResponseForCert(fetchResponse(t, nonce), revokableChain[0], revokableChain[1])
+		if err != nil {
+			t.Fatalf("Expected ParseResponseForCert to succeed, but got error: %v", err)
+		}
+		if err := checkNonce(resp, nonce); err != nil {
+			t.Errorf("Expected checkNonce to succeed, but got error: %v", err)
+		}
+	})
+
+	t.Run("responder ignores nonce", func(t *testing.T) {
+		staticClient := &http.Client{Transport: staticRoundTripper{body: fetchResponse(t, nil)}}
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: staticClient, Nonce: true})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, server, NonceNotSupportedError{}),
+				},
+			},
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("replayed response", func(t *testing.T) {
+		staticClient := &http.Client{Transport: staticRoundTripper{body: fetchResponse(t, []byte("previous nonce"))}}
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: staticClient, Nonce: true})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, server, GenericError{Err: errors.New("nonce of OCSP response does not match the nonce of the request")}),
+				},
+			},
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("nonce disabled", func(t *testing.T) {
+		staticClient := &http.Client{Transport: staticRoundTripper{body: fetchResponse(t, nil)}}
+		certResults, err := CheckStatus(Options{CertChain: revokableChain, HTTPClient: staticClient})
+		if err != nil {
+			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(server),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+}
+
+func TestCheckStatusWithServerURL(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(2)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert}
+	server := revokableChain[0].OCSPServer[0]
+	mockTransport := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true).Transport
+
+	t.Run("https server with rewritten URL", func(t *testing.T) {
+		tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			resp, err := mockTransport.RoundTrip(r)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			defer resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+			io.Copy(w, resp.Body)
+		}))
+		defer tlsServer.Close()
+
+		var rewritten []string
+		certResults, err := CheckStatus(Options{
+			CertChain:      revokableChain,
+			HTTPClient:     tlsServer.Client(),
+			AllowedSchemes: []string{"https"},
+			URLRewriter: func(server string) (string, error) {
+				mirrorURL := strings.Replace(server, "http://example.com", tlsServer.URL, 1)
+				rewritten = append(rewritten, mirrorURL)
+				return mirrorURL, nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(server),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+		if len(rewritten) != 1 || !strings.HasPrefix(rewritten[0], "https://") {
+			t.Errorf("Expected the server URL to be rewritten to the https mirror, but got %v", rewritten)
+		}
+	})
+
+	t.Run("scheme not allowed", func(t *testing.T) {
+		certResults, err := CheckStatus(Options{
+			CertChain:      revokableChain,
+			HTTPClient:     &http.Client{Transport: mockTransport},
+			AllowedSchemes: []string{"https"},
+		})
+		if err != nil {
+			t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, server, GenericError{Err: errors.New("OCSPServer protocol http is not supported")}),
+				},
+			},
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("unsupported allowed scheme", func(t *testing.T) {
+		certResults, err := CheckStatus(Options{
+			CertChain:      revokableChain,
+			HTTPClient:     &http.Client{Transport: mockTransport},
+			AllowedSchemes: []string{"ldap"},
+		})
+		expectedErr := errors.New("invalid input: unsupported OCSP server URL scheme \"ldap\"")
+		if err == nil || err.Error() != expectedErr.Error() {
+			t.Errorf("Expected CheckStatus to fail with %v, but got: %v", expectedErr, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+	})
+
+	t.Run("URL rewriter error", func(t *testing.T) {
+		certResults, err := CheckStatus(Options{
+			CertChain:  revokableChain,
+			HTTPClient: &http.Client{Transport: mockTransport},
+			URLRewriter: func(server string) (string, error) {
+				return "", errors.New("no mirror")
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		rewriteErr := GenericError{Err: fmt.Errorf("failed to rewrite OCSP server URL %q: no mirror", server)}
+		if certResults[0].Result != result.ResultUnknown || certResults[0].ServerResults[0].Error.Error() != rewriteErr.Error() {
+			t.Errorf("Expected result Unknown with error %v, but got %s with error %v", rewriteErr, certResults[0].Result, certResults[0].ServerResults[0].Error)
+		}
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		certResults, err := CheckStatus(Options{
+			CertChain:  revokableChain,
+			HTTPClient: &http.Client{Transport: mockTransport},
+			URLRewriter: func(server string) (string, error) {
+				return "://invalid", nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		invalidURLErr := GenericError{Err: errors.New("invalid OCSP server URL \"://invalid\": parse \"://invalid\": missing protocol scheme")}
+		if certResults[0].Result != result.ResultUnknown || certResults[0].ServerResults[0].Error.Error() != invalidURLErr.Error() {
+			t.Errorf("Expected result Unknown with error %v, but got %s with error %v", invalidURLErr, certResults[0].Result, certResults[0].ServerResults[0].Error)
+		}
+	})
+}
+
+// flakyRoundTripper fails the first failures requests, either with the
+// statusCode or with the body, before passing requests to the transport.
+type flakyRoundTripper struct {
+	transport  http.RoundTripper
+	failures   int
+	statusCode int
+	body       []byte
+	attempts   int
+}
+
+func (f *flakyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.attempts++
+	if f.attempts > f.failures {
+		return f.transport.RoundTrip(req)
+	}
+	return &http.Response{
+		Body:       io.NopCloser(bytes.NewReader(f.body)),
+		StatusCode: f.statusCode,
+	}, nil
+}
+
+func TestCheckStatusWithRetry(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(2)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert}
+	server := revokableChain[0].OCSPServer[0]
+	mockTransport := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true).Transport
+	retryPolicy := RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name             string
+		transport        *flakyRoundTripper
+		retry            RetryPolicy
+		expectedResult   result.Result
+		expectedAttempts int
+	}{
+		{"retry on 5xx", &flakyRoundTripper{failures: 2, statusCode: http.StatusServiceUnavailable}, retryPolicy, result.ResultOK, 3},
+		{"retry on tryLater", &flakyRoundTripper{failures: 1, statusCode: http.StatusOK, body: ocsp.TryLaterErrorResponse}, retryPolicy, result.ResultOK, 2},
+		{"max attempts exceeded", &flakyRoundTripper{failures: 3, statusCode: http.StatusInternalServerError}, retryPolicy, result.ResultUnknown, 3},
+		{"no retry on 4xx", &flakyRoundTripper{failures: 1, statusCode: http.StatusNotFound}, retryPolicy, result.ResultUnknown, 1},
+		{"no retry by default", &flakyRoundTripper{failures: 1, statusCode: http.StatusServiceUnavailable}, RetryPolicy{}, result.ResultUnknown, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.transport.transport = mockTransport
+			certResults, err := CheckStatus(Options{
+				CertChain:  revokableChain,
+				HTTPClient: &http.Client{Transport: tt.transport},
+				Retry:      tt.retry,
+			})
+			if err != nil {
+				t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+			}
+			if certResults[0].Result != tt.expectedResult {
+				t.Errorf("Expected result to be %s, but got %s with error %v", tt.expectedResult, certResults[0].Result, certResults[0].ServerResults[0].Error)
+			}
+			if tt.transport.attempts != tt.expectedAttempts {
+				t.Errorf("Expected %d attempts, but got %d", tt.expectedAttempts, tt.transport.attempts)
+			}
+		})
+	}
+
+	t.Run("per-attempt timeout", func(t *testing.T) {
+		certResults, err := CheckStatus(Options{
+			CertChain:  revokableChain,
+			HTTPClient: &http.Client{Transport: blockingRoundTripper{}},
+			Retry: RetryPolicy{
+				Timeout:     50 * time.Millisecond,
+				MaxAttempts: 2,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected CheckStatus to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, server, errors.New("exceeded timeout threshold of 50ms for OCSP check")),
+				},
+			},
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("cancelled during backoff", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		transport := &flakyRoundTripper{transport: mockTransport, failures: 10, statusCode: http.StatusServiceUnavailable}
+		_, err := CheckStatus(Options{
+			CertChain:  revokableChain,
+			HTTPClient: &http.Client{Transport: transport},
+			Context:    ctx,
+			Retry: RetryPolicy{
+				MaxAttempts:    10,
+				InitialBackoff: time.Hour,
+			},
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected CheckStatus to fail with %v, but got: %v", context.DeadlineExceeded, err)
+		}
+		if transport.attempts != 1 {
+			t.Errorf("Expected 1 attempt, but got %d", transport.attempts)
+		}
+	})
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{50, time.Second},
+	}
+	for _, tt := range tests {
+		backoff := p.backoff(tt.attempt)
+		if backoff < tt.max/2 || backoff > tt.max {
+			t.Errorf("Expected backoff of attempt %d to be between %v and %v, but got %v", tt.attempt, tt.max/2, tt.max, backoff)
+		}
+	}
+
+	if backoff := (RetryPolicy{}).backoff(3); backoff != 0 {
+		t.Errorf("Expected no backoff, but got %v", backoff)
+	}
+}
+
+// hostRoundTripper simulates a slow OCSP server at slow.example.com, which
+// only answers when the request is cancelled, and a failing OCSP server at
+// fail.example.com. Other requests are passed to the transport.
+type hostRoundTripper struct {
+	transport http.RoundTripper
+}
+
+func (h hostRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.URL.Host {
+	case "slow.example.com":
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	case "fail.example.com":
+		return &http.Response{
+			Body:       http.NoBody,
+			StatusCode: http.StatusServiceUnavailable,
+		}, nil
+	default:
+		return h.transport.RoundTrip(req)
+	}
+}
+
+func TestCheckStatusWithQueryMode(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(2)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert}
+	client := &http.Client{Transport: hostRoundTripper{
+		transport: testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true).Transport,
+	}}
+	goodServer := "http://example.com/chain_ocsp/0"
+	slowServer := "http://slow.example.com/chain_ocsp/0"
+	failServer := "http://fail.example.com/chain_ocsp/0"
+	failErr := errors.New("failed to retrieve OCSP: response had status code 503")
+
+	tests := []struct {
+		name       string
+		mode       QueryMode
+		hedgeDelay time.Duration
+		servers    []string
+		expected   *result.CertRevocationResult
+	}{
+		{
+			name:     "parallel with slow first server",
+			mode:     QueryParallel,
+			servers:  []string{slowServer, goodServer},
+			expected: getOKCertResult(goodServer),
+		},
+		{
+			name:       "hedged with slow first server",
+			mode:       QueryHedged,
+			hedgeDelay: 10 * time.Millisecond,
+			servers:    []string{slowServer, goodServer},
+			expected:   getOKCertResult(goodServer),
+		},
+		{
+			name:       "hedged with failing first server",
+			mode:       QueryHedged,
+			hedgeDelay: time.Hour,
+			servers:    []string{failServer, goodServer},
+			expected:   getOKCertResult(goodServer),
+		},
+		{
+			name:    "parallel with all servers failing",
+			mode:    QueryParallel,
+			servers: []string{failServer, "ldap://ds.example.com:123/chain_ocsp/0", failServer},
+			expected: &result.CertRevocationResult{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, failServer, failErr),
+					result.NewServerResult(result.ResultUnknown, "ldap://ds.example.com:123/chain_ocsp/0", GenericError{Err: errors.New("OCSPServer protocol ldap is not supported")}),
+					result.NewServerResult(result.ResultUnknown, failServer, failErr),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leaf, _ := x509.ParseCertificate(revokableChain[0].Raw)
+			leaf.OCSPServer = tt.servers
+			opts := Options{
+				HTTPClient: client,
+				QueryMode:  tt.mode,
+				HedgeDelay: tt.hedgeDelay,
+			}
+
+			start := time.Now()
+			certResult := certCheckStatus(leaf, revokableChain[1], opts)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Expected certCheckStatus to return after the first answer, but it took %v", elapsed)
+			}
+			validateEquivalentCertResults([]*result.CertRevocationResult{certResult}, []*result.CertRevocationResult{tt.expected}, t)
+		})
+	}
+}
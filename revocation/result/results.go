@@ -14,7 +14,10 @@
 // Package result provides general objects that are used across revocation
 package result
 
-import "strconv"
+import (
+	"crypto"
+	"strconv"
+)
 
 // Result is a type of enumerated value to help characterize errors. It can be
 // OK, Unknown, or Revoked
@@ -59,14 +62,20 @@ type ServerResult struct {
 	// prevents the retrieval of a valid status)
 	Result Result
 
-	// Server is the URI associated with this result. If no server is associated
-	// with the result (e.g. it is a root certificate or no OCSPServers are
-	// specified), then this will be an empty string ("")
+	// Server is the URI of the OCSP server or CRL distribution point
+	// associated with this result. If no server is associated with the result
+	// (e.g. it is a root certificate or no OCSPServers are specified), then
+	// this will be an empty string ("")
 	Server string
 
 	// Error is set if there is an error associated with the revocation check
 	// to this server
 	Error error
+
+	// CertIDHash is the hash algorithm used to build the CertID of the OCSP
+	// request sent to this server. It is zero if no OCSP request is sent
+	// (e.g. for CRL distribution points)
+	CertIDHash crypto.Hash
 }
 
 // NewServerResult creates a ServerResult object from its individual parts: a
@@ -92,6 +101,8 @@ type CertRevocationResult struct {
 
 	// An array of results for each server associated with the certificate.
 	// The length will be either 1 or the number of OCSPServers for the cert.
+	// If CRL is used as a fallback for OCSP, the length will be either 1 or
+	// the number of OCSPServers plus the number of CRLDistributionPoints.
 	//
 	// If the length is 1, then a valid status was able to be retrieved. Only
 	// this server result is contained. Any errors for other servers are
@@ -101,3 +112,25 @@ type CertRevocationResult struct {
 	// status from being retrieved. These are all contained here for evaluation
 	ServerResults []*ServerResult
 }
+
+// ChainRevocationResult encapsulates the result for a certificate chain,
+// aggregated from the results of its certificates according to a revocation
+// policy
+type ChainRevocationResult struct {
+	// Result of revocation for the whole chain
+	//
+	// It is ResultRevoked if any certificate in the chain is revoked.
+	// Otherwise, it is ResultUnknown if the status of any certificate cannot
+	// be determined and the policy does not tolerate it. Otherwise, it is
+	// ResultOK if any certificate has been checked, or ResultNonRevokable if
+	// no certificate could be checked or the check is skipped
+	Result Result
+
+	// CertResults contains the result for each certificate in the chain. The
+	// length will always be equal to the length of the certificate chain
+	CertResults []*CertRevocationResult
+
+	// Warnings contains an error for each certificate whose status cannot be
+	// determined but is tolerated by the policy
+	Warnings []error
+}
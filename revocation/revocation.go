@@ -16,35 +16,179 @@
 package revocation
 
 import (
+	"context"
+	"crypto"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/notaryproject/notation-core-go/revocation/cache"
+	"github.com/notaryproject/notation-core-go/revocation/crl"
 	"github.com/notaryproject/notation-core-go/revocation/ocsp"
 	"github.com/notaryproject/notation-core-go/revocation/result"
 )
 
 // Revocation is an interface that specifies methods used for revocation checking
 type Revocation interface {
-	// Validate checks the revocation status for a certificate chain using OCSP
-	// and returns an array of CertRevocationResults that contain the results
-	// and any errors that are encountered during the process
+	// Validate checks the revocation status for a certificate chain using OCSP,
+	// falling back to CRL for certificates whose status cannot be determined
+	// via OCSP, and returns an array of CertRevocationResults that contain the
+	// results and any errors that are encountered during the process
 	Validate(certChain []*x509.Certificate, signingTime time.Time) ([]*result.CertRevocationResult, error)
 }
 
+// ContextRevocation is a Revocation that supports cancellation of the checks
+// through a context. The Revocation returned by New also implements
+// ContextRevocation
+type ContextRevocation interface {
+	Revocation
+
+	// ValidateContext is like Validate but passes ctx into every HTTP request
+	// and stops the remaining checks when ctx is cancelled or its deadline is
+	// exceeded. In that case, the context error is returned
+	ValidateContext(ctx context.Context, certChain []*x509.Certificate, signingTime time.Time) ([]*result.CertRevocationResult, error)
+}
+
+// ChainRevocation is a ContextRevocation that evaluates the certificate chain
+// as a whole under a Policy. The Revocation returned by New also implements
+// ChainRevocation
+type ChainRevocation interface {
+	ContextRevocation
+
+	// ValidateChain is like ValidateContext but aggregates the results of the
+	// certificates into a single result for the chain according to the
+	// policy of the Revocation
+	ValidateChain(ctx context.Context, certChain []*x509.Certificate, signingTime time.Time) (*result.ChainRevocationResult, error)
+}
+
+// Policy specifies how a certificate with unknown revocation status affects
+// the result of a certificate chain
+type Policy int
+
+const (
+	// PolicyStrict is a Policy that fails the chain if the status of any
+	// certificate is unknown. This is the default policy
+	PolicyStrict Policy = iota
+	// PolicySoftFail is a Policy that passes the chain if the status of a
+	// certificate is unknown, but records a warning for the certificate
+	PolicySoftFail
+	// PolicySkip is a Policy that skips the revocation check, so that every
+	// certificate is non-revokable
+	PolicySkip
+)
+
+// String provides a conversion from a Policy to a string
+func (p Policy) String() string {
+	switch p {
+	case PolicyStrict:
+		return "strict"
+	case PolicySoftFail:
+		return "soft-fail"
+	case PolicySkip:
+		return "skip"
+	default:
+		return "invalid policy with value " + strconv.Itoa(int(p))
+	}
+}
+
+// Options specifies values that are needed to construct a Revocation
+type Options struct {
+	// HTTPClient is used to query OCSP servers and download CRLs. It must be
+	// non-nil
+	HTTPClient *http.Client
+
+	// Cache is used to store OCSP responses and CRLs until their NextUpdate,
+	// so that they can be reused by later checks. If it is nil, nothing is
+	// cached
+	Cache cache.Cache
+
+	// Policy specifies how a certificate with unknown revocation status
+	// affects the result of ValidateChain. If it is PolicySkip, no
+	// certificate is checked at all. The default is PolicyStrict
+	Policy Policy
+
+	// OCSPHash is the hash algorithm used to build the CertID of OCSP
+	// requests. See ocsp.Options.Hash for the supported values
+	OCSPHash crypto.Hash
+
+	// OCSPHashFallback enables a second OCSP request with a CertID built
+	// with SHA-1 if the server does not support OCSPHash. See
+	// ocsp.Options.HashFallback for details
+	OCSPHashFallback bool
+
+	// OCSPNonce enables the nonce extension in OCSP requests. See
+	// ocsp.Options.Nonce for details
+	OCSPNonce bool
+
+	// OCSPAllowedSchemes is the list of URL schemes of the OCSP servers that
+	// can be queried. See ocsp.Options.AllowedSchemes for details
+	OCSPAllowedSchemes []string
+
+	// OCSPURLRewriter is called with the URL of every OCSP server before it
+	// is queried. See ocsp.Options.URLRewriter for details
+	OCSPURLRewriter func(server string) (string, error)
+
+	// OCSPRetry specifies how requests to an OCSP server are retried. See
+	// ocsp.Options.Retry for details
+	OCSPRetry ocsp.RetryPolicy
+
+	// OCSPQueryMode specifies how the OCSP servers of a certificate are
+	// queried. See ocsp.Options.QueryMode for details
+	OCSPQueryMode ocsp.QueryMode
+
+	// OCSPHedgeDelay is the delay after which the next OCSP server is queried
+	// in ocsp.QueryHedged mode. See ocsp.Options.HedgeDelay for details
+	OCSPHedgeDelay time.Duration
+}
+
 // revocation is an internal struct used for revocation checking
 type revocation struct {
-	httpClient *http.Client
+	httpClient         *http.Client
+	cache              cache.Cache
+	policy             Policy
+	ocspHash           crypto.Hash
+	ocspHashFallback   bool
+	ocspNonce          bool
+	ocspAllowedSchemes []string
+	ocspURLRewriter    func(server string) (string, error)
+	ocspRetry          ocsp.RetryPolicy
+	ocspQueryMode      ocsp.QueryMode
+	ocspHedgeDelay     time.Duration
 }
 
 // New constructs a revocation object
 func New(httpClient *http.Client) (Revocation, error) {
-	if httpClient == nil {
+	return NewWithOptions(Options{
+		HTTPClient: httpClient,
+	})
+}
+
+// NewWithOptions constructs a revocation object with the specified options
+func NewWithOptions(opts Options) (ChainRevocation, error) {
+	if opts.HTTPClient == nil {
 		return nil, errors.New("invalid input: a non-nil httpClient must be specified")
 	}
+	switch opts.Policy {
+	case PolicyStrict, PolicySoftFail, PolicySkip:
+	default:
+		return nil, fmt.Errorf("invalid input: unsupported revocation policy %s", opts.Policy)
+	}
 	return &revocation{
-		httpClient: httpClient,
+		httpClient:         opts.HTTPClient,
+		cache:              opts.Cache,
+		policy:             opts.Policy,
+		ocspHash:           opts.OCSPHash,
+		ocspHashFallback:   opts.OCSPHashFallback,
+		ocspNonce:          opts.OCSPNonce,
+		ocspAllowedSchemes: opts.OCSPAllowedSchemes,
+		ocspURLRewriter:    opts.OCSPURLRewriter,
+		ocspRetry:          opts.OCSPRetry,
+		ocspQueryMode:      opts.OCSPQueryMode,
+		ocspHedgeDelay:     opts.OCSPHedgeDelay,
 	}, nil
 }
 
@@ -52,14 +196,156 @@ func New(httpClient *http.Client) (Revocation, error) {
 // returns an array of CertRevocationResults that contain the results and any
 // errors that are encountered during the process
 //
-// TODO: add CRL support
-// https://github.com/notaryproject/notation-core-go/issues/125
+// For each certificate whose OCSP result is Unknown or NonRevokable, the
+// CRLs published at its CRLDistributionPoints are checked instead.
 func (r *revocation) Validate(certChain []*x509.Certificate, signingTime time.Time) ([]*result.CertRevocationResult, error) {
-	return ocsp.CheckStatus(ocsp.Options{
+	return r.ValidateContext(context.Background(), certChain, signingTime)
+}
+
+// ValidateContext is like Validate but passes ctx into every HTTP request and
+// stops the remaining checks when ctx is cancelled or its deadline is
+// exceeded. In that case, the context error is returned
+//
+// If the policy is PolicySkip, no certificate is checked and every result is
+// NonRevokable.
+func (r *revocation) ValidateContext(ctx context.Context, certChain []*x509.Certificate, signingTime time.Time) ([]*result.CertRevocationResult, error) {
+	if ctx == nil {
+		return nil, errors.New("invalid input: a non-nil context must be specified")
+	}
+	if r.policy == PolicySkip {
+		return skippedCertResults(len(certChain)), nil
+	}
+	certResults, err := ocsp.CheckStatus(ocsp.Options{
+		CertChain:      certChain,
+		SigningTime:    signingTime,
+		HTTPClient:     r.httpClient,
+		Context:        ctx,
+		Cache:          r.cache,
+		Hash:           r.ocspHash,
+		HashFallback:   r.ocspHashFallback,
+		Nonce:          r.ocspNonce,
+		AllowedSchemes: r.ocspAllowedSchemes,
+		URLRewriter:    r.ocspURLRewriter,
+		Retry:          r.ocspRetry,
+		QueryMode:      r.ocspQueryMode,
+		HedgeDelay:     r.ocspHedgeDelay,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	crlOpts := crl.Options{
 		CertChain:   certChain,
 		SigningTime: signingTime,
 		HTTPClient:  r.httpClient,
-	})
-	// TODO: add CRL support
-	// https://github.com/notaryproject/notation-core-go/issues/125
+		Context:     ctx,
+		Cache:       r.cache,
+	}
+	var wg sync.WaitGroup
+	// Last is root cert, which will never be revoked
+	for i, cert := range certChain[:len(certChain)-1] {
+		if !needsCRLFallback(cert, certResults[i]) {
+			continue
+		}
+		wg.Add(1)
+		// ocsp.CheckStatus has already validated that the next cert in chain
+		// is the issuer
+		go func(i int, cert *x509.Certificate) {
+			defer wg.Done()
+			crlResult := crl.CertCheckStatus(cert, certChain[i+1], crlOpts)
+			certResults[i] = mergeCertResults(certResults[i], crlResult)
+		}(i, cert)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return certResults, nil
+}
+
+// ValidateChain is like ValidateContext but aggregates the results of the
+// certificates into a single result for the chain according to the policy
+func (r *revocation) ValidateChain(ctx context.Context, certChain []*x509.Certificate, signingTime time.Time) (*result.ChainRevocationResult, error) {
+	certResults, err := r.ValidateContext(ctx, certChain, signingTime)
+	if err != nil {
+		return nil, err
+	}
+	return aggregateCertResults(certResults, certChain, r.policy), nil
+}
+
+// skippedCertResults returns a NonRevokable result for each of the n
+// certificates of a chain.
+func skippedCertResults(n int) []*result.CertRevocationResult {
+	certResults := make([]*result.CertRevocationResult, n)
+	for i := range certResults {
+		certResults[i] = &result.CertRevocationResult{
+			Result: result.ResultNonRevokable,
+			ServerResults: []*result.ServerResult{
+				result.NewServerResult(result.ResultNonRevokable, "", nil),
+			},
+		}
+	}
+	return certResults
+}
+
+// aggregateCertResults evaluates the results of the certificates in the chain
+// under the policy. A revoked certificate always fails the chain, while a
+// certificate with unknown status only fails it under PolicyStrict.
+func aggregateCertResults(certResults []*result.CertRevocationResult, certChain []*x509.Certificate, policy Policy) *result.ChainRevocationResult {
+	chainResult := &result.ChainRevocationResult{
+		Result:      result.ResultNonRevokable,
+		CertResults: certResults,
+	}
+	var revoked, unknown, checked bool
+	for i, certResult := range certResults {
+		switch certResult.Result {
+		case result.ResultRevoked:
+			revoked = true
+		case result.ResultUnknown:
+			unknown = true
+			if policy == PolicySoftFail {
+				serverResults := certResult.ServerResults
+				chainResult.Warnings = append(chainResult.Warnings, fmt.Errorf("revocation status of certificate with subject %q is unknown: %w", certChain[i].Subject, serverResults[len(serverResults)-1].Error))
+			}
+		case result.ResultOK:
+			checked = true
+		}
+	}
+
+	switch {
+	case revoked:
+		chainResult.Result = result.ResultRevoked
+	case unknown && policy == PolicyStrict:
+		chainResult.Result = result.ResultUnknown
+	case unknown || checked:
+		chainResult.Result = result.ResultOK
+	}
+	return chainResult
+}
+
+// needsCRLFallback returns true if the status of the certificate could not be
+// determined via OCSP and the certificate specifies CRL distribution points.
+func needsCRLFallback(cert *x509.Certificate, ocspResult *result.CertRevocationResult) bool {
+	if len(cert.CRLDistributionPoints) == 0 {
+		return false
+	}
+	return ocspResult.Result == result.ResultUnknown || ocspResult.Result == result.ResultNonRevokable
+}
+
+// mergeCertResults combines the OCSP result of a certificate with its CRL
+// result. A valid CRL result takes precedence. Otherwise, the errors from both
+// OCSP and CRL are kept for evaluation.
+func mergeCertResults(ocspResult, crlResult *result.CertRevocationResult) *result.CertRevocationResult {
+	switch {
+	case crlResult.Result == result.ResultOK || crlResult.Result == result.ResultRevoked:
+		return crlResult
+	case ocspResult.Result == result.ResultNonRevokable:
+		// OCSP is not enabled for this certificate
+		return crlResult
+	default:
+		return &result.CertRevocationResult{
+			Result:        result.ResultUnknown,
+			ServerResults: append(ocspResult.ServerResults, crlResult.ServerResults...),
+		}
+	}
 }
@@ -14,6 +14,7 @@
 package revocation
 
 import (
+	"context"
 	"crypto/x509"
 	"errors"
 	"fmt"
@@ -21,6 +22,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/notaryproject/notation-core-go/revocation/cache"
+	"github.com/notaryproject/notation-core-go/revocation/crl"
 	revocationocsp "github.com/notaryproject/notation-core-go/revocation/ocsp"
 	"github.com/notaryproject/notation-core-go/revocation/result"
 	"github.com/notaryproject/notation-core-go/testhelper"
@@ -99,6 +102,47 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewWithOptions(t *testing.T) {
+	c, _ := cache.NewMemoryCache(10)
+	r, err := NewWithOptions(Options{Cache: c})
+	expectedError := errors.New("invalid input: a non-nil httpClient must be specified")
+	if r != nil || err == nil || err.Error() != expectedError.Error() {
+		t.Errorf("Expected NewWithOptions to fail with %v, but received %v and %v", expectedError, r, err)
+	}
+
+	client := http.DefaultClient
+	r, err = NewWithOptions(Options{HTTPClient: client, Cache: c})
+	if err != nil {
+		t.Errorf("Expected to succeed with default client, but received error %v", err)
+	}
+	revR, ok := r.(*revocation)
+	if !ok {
+		t.Error("Expected NewWithOptions to create an object matching the internal revocation struct")
+	} else if revR.httpClient != client || revR.cache != c {
+		t.Errorf("Expected NewWithOptions to set client to %v and cache to %v, but they were set to %v and %v", client, c, revR.httpClient, revR.cache)
+	}
+}
+
+func TestNewWithPolicy(t *testing.T) {
+	client := http.DefaultClient
+	for _, policy := range []Policy{PolicyStrict, PolicySoftFail, PolicySkip} {
+		r, err := NewWithOptions(Options{HTTPClient: client, Policy: policy})
+		if err != nil {
+			t.Errorf("Expected to succeed with policy %s, but received error %v", policy, err)
+			continue
+		}
+		if revR := r.(*revocation); revR.policy != policy {
+			t.Errorf("Expected NewWithOptions to set policy to %s, but it was set to %s", policy, revR.policy)
+		}
+	}
+
+	r, err := NewWithOptions(Options{HTTPClient: client, Policy: Policy(10)})
+	expectedError := errors.New("invalid input: unsupported revocation policy invalid policy with value 10")
+	if r != nil || err == nil || err.Error() != expectedError.Error() {
+		t.Errorf("Expected NewWithOptions to fail with %v, but received %v and %v", expectedError, r, err)
+	}
+}
+
 func TestCheckRevocationStatusForSingleCert(t *testing.T) {
 	revokableCertTuple := testhelper.GetRevokableRSALeafCertificate()
 	revokableIssuerTuple := testhelper.GetRSARootCertificate()
@@ -538,17 +582,18 @@ func TestCheckRevocationErrors(t *testing.T) {
 		if err != nil {
 			t.Errorf("Expected CheckStatus to succeed, but got error: %v", err)
 		}
+		timeoutErr := errors.New("exceeded timeout threshold of 1ns for OCSP check")
 		expectedCertResults := []*result.CertRevocationResult{
 			{
 				Result: result.ResultUnknown,
 				ServerResults: []*result.ServerResult{
-					result.NewServerResult(result.ResultUnknown, okChain[0].OCSPServer[0], revocationocsp.TimeoutError{}),
+					result.NewServerResult(result.ResultUnknown, okChain[0].OCSPServer[0], timeoutErr),
 				},
 			},
 			{
 				Result: result.ResultUnknown,
 				ServerResults: []*result.ServerResult{
-					result.NewServerResult(result.ResultUnknown, okChain[1].OCSPServer[0], revocationocsp.TimeoutError{}),
+					result.NewServerResult(result.ResultUnknown, okChain[1].OCSPServer[0], timeoutErr),
 				},
 			},
 			getRootCertResult(),
@@ -676,3 +721,317 @@ func TestCheckRevocationInvalidChain(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckRevocationWithCRLFallback(t *testing.T) {
+	zeroTime := time.Time{}
+	testChain := testhelper.GetRevokableRSAChainWithCRL(4)
+	revokableChain := make([]*x509.Certificate, 4)
+	for i, tuple := range testChain {
+		revokableChain[i] = tuple.Cert
+		revokableChain[i].NotBefore = zeroTime
+	}
+
+	t.Run("OCSP unknown and CRL good", func(t *testing.T) {
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good, ocsp.Unknown, ocsp.Good}, nil, true, nil)
+		r, err := New(client)
+		if err != nil {
+			t.Errorf("Expected successful creation of revocation, but received error: %v", err)
+		}
+
+		certResults, err := r.Validate(revokableChain, time.Now())
+		if err != nil {
+			t.Errorf("Expected Validate to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].OCSPServer[0]),
+			getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getOKCertResult(revokableChain[2].OCSPServer[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+	t.Run("OCSP unknown and CRL revoked", func(t *testing.T) {
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good, ocsp.Unknown, ocsp.Good}, nil, true, []int{1})
+		r, err := New(client)
+		if err != nil {
+			t.Errorf("Expected successful creation of revocation, but received error: %v", err)
+		}
+
+		certResults, err := r.Validate(revokableChain, time.Now())
+		if err != nil {
+			t.Errorf("Expected Validate to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].OCSPServer[0]),
+			{
+				Result: result.ResultRevoked,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultRevoked, revokableChain[1].CRLDistributionPoints[0], crl.RevokedError{}),
+				},
+			},
+			getOKCertResult(revokableChain[2].OCSPServer[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+	t.Run("OCSP good is not overridden by CRL", func(t *testing.T) {
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, []int{0})
+		r, err := New(client)
+		if err != nil {
+			t.Errorf("Expected successful creation of revocation, but received error: %v", err)
+		}
+
+		certResults, err := r.Validate(revokableChain, time.Now())
+		if err != nil {
+			t.Errorf("Expected Validate to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].OCSPServer[0]),
+			getOKCertResult(revokableChain[1].OCSPServer[0]),
+			getOKCertResult(revokableChain[2].OCSPServer[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+	t.Run("no OCSP server and CRL revoked", func(t *testing.T) {
+		noOCSPLeaf, _ := x509.ParseCertificate(revokableChain[0].Raw)
+		noOCSPLeaf.OCSPServer = nil
+		noOCSPChain := []*x509.Certificate{noOCSPLeaf, revokableChain[1], revokableChain[2], revokableChain[3]}
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true, []int{0})
+		r, err := New(client)
+		if err != nil {
+			t.Errorf("Expected successful creation of revocation, but received error: %v", err)
+		}
+
+		certResults, err := r.Validate(noOCSPChain, time.Now())
+		if err != nil {
+			t.Errorf("Expected Validate to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultRevoked,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultRevoked, noOCSPChain[0].CRLDistributionPoints[0], crl.RevokedError{}),
+				},
+			},
+			getOKCertResult(noOCSPChain[1].OCSPServer[0]),
+			getOKCertResult(noOCSPChain[2].OCSPServer[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+	t.Run("OCSP unknown and CRL error", func(t *testing.T) {
+		expiredCRLLeaf, _ := x509.ParseCertificate(revokableChain[0].Raw)
+		expiredCRLLeaf.CRLDistributionPoints = []string{"http://example.com/expired_crl"}
+		expiredCRLChain := []*x509.Certificate{expiredCRLLeaf, revokableChain[1], revokableChain[2], revokableChain[3]}
+		client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Unknown, ocsp.Good}, nil, true, nil)
+		r, err := New(client)
+		if err != nil {
+			t.Errorf("Expected successful creation of revocation, but received error: %v", err)
+		}
+
+		certResults, err := r.Validate(expiredCRLChain, time.Now())
+		if err != nil {
+			t.Errorf("Expected Validate to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			{
+				Result: result.ResultUnknown,
+				ServerResults: []*result.ServerResult{
+					result.NewServerResult(result.ResultUnknown, expiredCRLChain[0].OCSPServer[0], revocationocsp.UnknownStatusError{}),
+					result.NewServerResult(result.ResultUnknown, expiredCRLChain[0].CRLDistributionPoints[0], crl.GenericError{Err: errors.New("expired CRL")}),
+				},
+			},
+			getOKCertResult(expiredCRLChain[1].OCSPServer[0]),
+			getOKCertResult(expiredCRLChain[2].OCSPServer[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+}
+
+func TestValidateContext(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(3)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert, testChain[2].Cert}
+	client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good, ocsp.Unknown}, nil, true, nil)
+	rev, err := New(client)
+	if err != nil {
+		t.Fatalf("Expected successful creation of revocation, but received error: %v", err)
+	}
+	r, ok := rev.(ChainRevocation)
+	if !ok {
+		t.Fatal("Expected New to create a ChainRevocation")
+	}
+
+	t.Run("nil context", func(t *testing.T) {
+		certResults, err := r.ValidateContext(nil, revokableChain, time.Now())
+		expectedErr := errors.New("invalid input: a non-nil context must be specified")
+		if err == nil || err.Error() != expectedErr.Error() {
+			t.Errorf("Expected ValidateContext to fail with %v, but got: %v", expectedErr, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+	})
+
+	t.Run("valid context", func(t *testing.T) {
+		certResults, err := r.ValidateContext(context.Background(), revokableChain, time.Now())
+		if err != nil {
+			t.Errorf("Expected ValidateContext to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getOKCertResult(revokableChain[0].OCSPServer[0]),
+			getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		certResults, err := r.ValidateContext(ctx, revokableChain, time.Now())
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected ValidateContext to fail with %v, but got: %v", context.Canceled, err)
+		}
+		if certResults != nil {
+			t.Error("Expected certResults to be nil when there is an error")
+		}
+	})
+}
+
+func TestValidateChain(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChain(3)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert, testChain[2].Cert}
+
+	tests := []struct {
+		name           string
+		policy         Policy
+		statuses       []ocsp.ResponseStatus
+		expectedResult result.Result
+		warnings       int
+	}{
+		{"strict with non-revoked chain", PolicyStrict, []ocsp.ResponseStatus{ocsp.Good}, result.ResultOK, 0},
+		{"strict with unknown cert", PolicyStrict, []ocsp.ResponseStatus{ocsp.Good, ocsp.Unknown}, result.ResultUnknown, 0},
+		{"strict with revoked cert", PolicyStrict, []ocsp.ResponseStatus{ocsp.Revoked}, result.ResultRevoked, 0},
+		{"soft-fail with non-revoked chain", PolicySoftFail, []ocsp.ResponseStatus{ocsp.Good}, result.ResultOK, 0},
+		{"soft-fail with unknown cert", PolicySoftFail, []ocsp.ResponseStatus{ocsp.Good, ocsp.Unknown}, result.ResultOK, 1},
+		{"soft-fail with unknown and revoked cert", PolicySoftFail, []ocsp.ResponseStatus{ocsp.Unknown, ocsp.Revoked}, result.ResultRevoked, 1},
+		{"skip with revoked cert", PolicySkip, []ocsp.ResponseStatus{ocsp.Revoked}, result.ResultNonRevokable, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := testhelper.MockClient(testChain, tt.statuses, nil, true)
+			r, err := NewWithOptions(Options{HTTPClient: client, Policy: tt.policy})
+			if err != nil {
+				t.Fatalf("Expected successful creation of revocation, but received error: %v", err)
+			}
+			chainResult, err := r.ValidateChain(context.Background(), revokableChain, time.Now())
+			if err != nil {
+				t.Fatalf("Expected ValidateChain to succeed, but got error: %v", err)
+			}
+			if chainResult.Result != tt.expectedResult {
+				t.Errorf("Expected chainResult.Result to be %s, but got %s", tt.expectedResult, chainResult.Result)
+			}
+			if len(chainResult.CertResults) != len(revokableChain) {
+				t.Errorf("Length of chainResult.CertResults (%d) did not match expected length (%d)", len(chainResult.CertResults), len(revokableChain))
+			}
+			if len(chainResult.Warnings) != tt.warnings {
+				t.Errorf("Expected %d warnings, but got %v", tt.warnings, chainResult.Warnings)
+			}
+		})
+	}
+
+	t.Run("skip does not query any server", func(t *testing.T) {
+		client := &http.Client{Transport: &statusRoundTripper{statusCode: http.StatusServiceUnavailable}}
+		r, err := NewWithOptions(Options{HTTPClient: client, Policy: PolicySkip})
+		if err != nil {
+			t.Fatalf("Expected successful creation of revocation, but received error: %v", err)
+		}
+		certResults, err := r.Validate(revokableChain, time.Now())
+		if err != nil {
+			t.Errorf("Expected Validate to succeed, but got error: %v", err)
+		}
+		expectedCertResults := []*result.CertRevocationResult{
+			getRootCertResult(),
+			getRootCertResult(),
+			getRootCertResult(),
+		}
+		validateEquivalentCertResults(certResults, expectedCertResults, t)
+	})
+
+	t.Run("soft-fail warning", func(t *testing.T) {
+		client := testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Unknown, ocsp.Good}, nil, true)
+		r, err := NewWithOptions(Options{HTTPClient: client, Policy: PolicySoftFail})
+		if err != nil {
+			t.Fatalf("Expected successful creation of revocation, but received error: %v", err)
+		}
+		chainResult, err := r.ValidateChain(context.Background(), revokableChain, time.Now())
+		if err != nil {
+			t.Fatalf("Expected ValidateChain to succeed, but got error: %v", err)
+		}
+		expectedWarning := fmt.Sprintf("revocation status of certificate with subject %q is unknown: certificate has unknown status via OCSP", revokableChain[0].Subject)
+		if len(chainResult.Warnings) != 1 || chainResult.Warnings[0].Error() != expectedWarning {
+			t.Fatalf("Expected warning %q, but got %v", expectedWarning, chainResult.Warnings)
+		}
+		if !errors.Is(chainResult.Warnings[0], revocationocsp.UnknownStatusError{}) {
+			t.Errorf("Expected warning to wrap %v", revocationocsp.UnknownStatusError{})
+		}
+	})
+
+	t.Run("invalid chain", func(t *testing.T) {
+		r, err := NewWithOptions(Options{HTTPClient: testhelper.MockClient(testChain, []ocsp.ResponseStatus{ocsp.Good}, nil, true)})
+		if err != nil {
+			t.Fatalf("Expected successful creation of revocation, but received error: %v", err)
+		}
+		chainResult, err := r.ValidateChain(context.Background(), nil, time.Now())
+		if err == nil {
+			t.Error("Expected ValidateChain to fail with an empty chain")
+		}
+		if chainResult != nil {
+			t.Error("Expected chainResult to be nil when there is an error")
+		}
+	})
+}
+
+func TestValidateWithCache(t *testing.T) {
+	testChain := testhelper.GetRevokableRSAChainWithCRL(3)
+	revokableChain := []*x509.Certificate{testChain[0].Cert, testChain[1].Cert, testChain[2].Cert}
+	expectedCertResults := []*result.CertRevocationResult{
+		getOKCertResult(revokableChain[0].OCSPServer[0]),
+		getOKCertResult(revokableChain[1].CRLDistributionPoints[0]),
+		getRootCertResult(),
+	}
+
+	c, _ := cache.NewMemoryCache(10)
+	client := testhelper.MockClientWithCRL(testChain, []ocsp.ResponseStatus{ocsp.Good, ocsp.Unknown}, nil, true, nil)
+	r, err := NewWithOptions(Options{HTTPClient: client, Cache: c})
+	if err != nil {
+		t.Fatalf("Expected successful creation of revocation, but received error: %v", err)
+	}
+	certResults, err := r.Validate(revokableChain, time.Now())
+	if err != nil {
+		t.Errorf("Expected Validate to succeed, but got error: %v", err)
+	}
+	validateEquivalentCertResults(certResults, expectedCertResults, t)
+
+	// the servers are no longer reachable, both the OCSP response and the
+	// CRL are served from the cache
+	client.Transport = &statusRoundTripper{statusCode: http.StatusServiceUnavailable}
+	certResults, err = r.Validate(revokableChain, time.Now())
+	if err != nil {
+		t.Errorf("Expected Validate to succeed, but got error: %v", err)
+	}
+	validateEquivalentCertResults(certResults, expectedCertResults, t)
+}
+
+type statusRoundTripper struct {
+	statusCode int
+}
+
+func (s *statusRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Body:       http.NoBody,
+		StatusCode: s.statusCode,
+	}, nil
+}
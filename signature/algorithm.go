@@ -16,6 +16,7 @@ package signature
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"fmt"
@@ -34,14 +35,22 @@ const (
 	AlgorithmES256                      // ECDSA on secp256r1 with SHA-256
 	AlgorithmES384                      // ECDSA on secp384r1 with SHA-384
 	AlgorithmES512                      // ECDSA on secp521r1 with SHA-512
+	AlgorithmEdDSA                      // Ed25519 (PureEdDSA)
+
+	// Legacy signature algorithms which are only supported for verification
+	// when explicitly allowed by VerifyOptions.AllowLegacyAlgorithms.
+	AlgorithmRS256 // RSASSA-PKCS1-v1_5 with SHA-256
+	AlgorithmRS384 // RSASSA-PKCS1-v1_5 with SHA-384
+	AlgorithmRS512 // RSASSA-PKCS1-v1_5 with SHA-512
 )
 
 // KeyType defines the key type.
 type KeyType int
 
 const (
-	KeyTypeRSA KeyType = 1 + iota // KeyType RSA
-	KeyTypeEC                     // KeyType EC
+	KeyTypeRSA     KeyType = 1 + iota // KeyType RSA
+	KeyTypeEC                         // KeyType EC
+	KeyTypeEd25519                    // KeyType Ed25519
 )
 
 // KeySpec defines a key type and size.
@@ -54,18 +63,59 @@ type KeySpec struct {
 }
 
 // Hash returns the hash function of the algorithm.
+//
+// Ed25519 does not take a pre-hashed message. SHA-512, the hash used
+// internally by Ed25519, is returned for AlgorithmEdDSA so that callers can
+// still pick a digest, e.g. for timestamping the signature.
 func (alg Algorithm) Hash() crypto.Hash {
 	switch alg {
-	case AlgorithmPS256, AlgorithmES256:
+	case AlgorithmPS256, AlgorithmES256, AlgorithmRS256:
 		return crypto.SHA256
-	case AlgorithmPS384, AlgorithmES384:
+	case AlgorithmPS384, AlgorithmES384, AlgorithmRS384:
 		return crypto.SHA384
-	case AlgorithmPS512, AlgorithmES512:
+	case AlgorithmPS512, AlgorithmES512, AlgorithmEdDSA, AlgorithmRS512:
 		return crypto.SHA512
 	}
 	return 0
 }
 
+// String returns the name of the algorithm, e.g. "RSASSA-PSS-SHA-256".
+func (alg Algorithm) String() string {
+	switch alg {
+	case AlgorithmPS256:
+		return "RSASSA-PSS-SHA-256"
+	case AlgorithmPS384:
+		return "RSASSA-PSS-SHA-384"
+	case AlgorithmPS512:
+		return "RSASSA-PSS-SHA-512"
+	case AlgorithmES256:
+		return "ECDSA-SHA-256"
+	case AlgorithmES384:
+		return "ECDSA-SHA-384"
+	case AlgorithmES512:
+		return "ECDSA-SHA-512"
+	case AlgorithmEdDSA:
+		return "Ed25519"
+	case AlgorithmRS256:
+		return "RSASSA-PKCS1-v1_5-SHA-256"
+	case AlgorithmRS384:
+		return "RSASSA-PKCS1-v1_5-SHA-384"
+	case AlgorithmRS512:
+		return "RSASSA-PKCS1-v1_5-SHA-512"
+	}
+	return fmt.Sprintf("Algorithm(%d)", int(alg))
+}
+
+// IsLegacy reports whether the algorithm is a legacy algorithm, which is
+// supported for verification only and never for signing.
+func (alg Algorithm) IsLegacy() bool {
+	switch alg {
+	case AlgorithmRS256, AlgorithmRS384, AlgorithmRS512:
+		return true
+	}
+	return false
+}
+
 // ExtractKeySpec extracts KeySpec from the signing certificate.
 func ExtractKeySpec(signingCert *x509.Certificate) (KeySpec, error) {
 	switch key := signingCert.PublicKey.(type) {
@@ -93,6 +143,16 @@ func ExtractKeySpec(signingCert *x509.Certificate) (KeySpec, error) {
 				Msg: fmt.Sprintf("ecdsa key size %d bits is not supported", bitSize),
 			}
 		}
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return KeySpec{}, &UnsupportedSigningKeyError{
+				Msg: fmt.Sprintf("ed25519 public key size %d bytes is not supported", len(key)),
+			}
+		}
+		return KeySpec{
+			Type: KeyTypeEd25519,
+			Size: ed25519.PublicKeySize << 3,
+		}, nil
 	}
 	return KeySpec{}, &UnsupportedSigningKeyError{
 		Msg: "unsupported public key type",
@@ -120,6 +180,10 @@ func (k KeySpec) SignatureAlgorithm() Algorithm {
 		case 4096:
 			return AlgorithmPS512
 		}
+	case KeyTypeEd25519:
+		if k.Size == ed25519.PublicKeySize<<3 {
+			return AlgorithmEdDSA
+		}
 	}
 	return 0
 }
@@ -64,6 +64,26 @@ func TestHash(t *testing.T) {
 			alg:    AlgorithmES512,
 			expect: crypto.SHA512,
 		},
+		{
+			name:   "EdDSA",
+			alg:    AlgorithmEdDSA,
+			expect: crypto.SHA512,
+		},
+		{
+			name:   "RS256",
+			alg:    AlgorithmRS256,
+			expect: crypto.SHA256,
+		},
+		{
+			name:   "RS384",
+			alg:    AlgorithmRS384,
+			expect: crypto.SHA384,
+		},
+		{
+			name:   "RS512",
+			alg:    AlgorithmRS512,
+			expect: crypto.SHA512,
+		},
 		{
 			name:   "UnsupportedAlgorithm",
 			alg:    0,
@@ -81,6 +101,43 @@ func TestHash(t *testing.T) {
 	}
 }
 
+func TestAlgorithmString(t *testing.T) {
+	tests := []struct {
+		alg  Algorithm
+		want string
+	}{
+		{AlgorithmPS256, "RSASSA-PSS-SHA-256"},
+		{AlgorithmPS384, "RSASSA-PSS-SHA-384"},
+		{AlgorithmPS512, "RSASSA-PSS-SHA-512"},
+		{AlgorithmES256, "ECDSA-SHA-256"},
+		{AlgorithmES384, "ECDSA-SHA-384"},
+		{AlgorithmES512, "ECDSA-SHA-512"},
+		{AlgorithmEdDSA, "Ed25519"},
+		{AlgorithmRS256, "RSASSA-PKCS1-v1_5-SHA-256"},
+		{AlgorithmRS384, "RSASSA-PKCS1-v1_5-SHA-384"},
+		{AlgorithmRS512, "RSASSA-PKCS1-v1_5-SHA-512"},
+		{Algorithm(0), "Algorithm(0)"},
+	}
+	for _, tt := range tests {
+		if got := tt.alg.String(); got != tt.want {
+			t.Errorf("Algorithm(%d).String() = %q, want %q", int(tt.alg), got, tt.want)
+		}
+	}
+}
+
+func TestIsLegacy(t *testing.T) {
+	for _, alg := range []Algorithm{AlgorithmRS256, AlgorithmRS384, AlgorithmRS512} {
+		if !alg.IsLegacy() {
+			t.Errorf("expected algorithm %v to be legacy", alg)
+		}
+	}
+	for _, alg := range []Algorithm{AlgorithmPS256, AlgorithmPS384, AlgorithmPS512, AlgorithmES256, AlgorithmES384, AlgorithmES512, AlgorithmEdDSA} {
+		if alg.IsLegacy() {
+			t.Errorf("expected algorithm %v not to be legacy", alg)
+		}
+	}
+}
+
 func TestExtractKeySpec(t *testing.T) {
 	type testCase struct {
 		name      string
@@ -103,13 +160,22 @@ func TestExtractKeySpec(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name: "Unsupported type",
+			name: "Ed25519 wrong size",
 			cert: &x509.Certificate{
 				PublicKey: ed25519.PublicKey{},
 			},
 			expect:    KeySpec{},
 			expectErr: true,
 		},
+		{
+			name: "Ed25519",
+			cert: testhelper.GetEd25519LeafCertificate().Cert,
+			expect: KeySpec{
+				Type: KeyTypeEd25519,
+				Size: 256,
+			},
+			expectErr: false,
+		},
 	}
 
 	// append valid RSA cases
@@ -223,6 +289,22 @@ func TestSignatureAlgorithm(t *testing.T) {
 			},
 			expect: AlgorithmPS512,
 		},
+		{
+			name: "Ed25519",
+			keySpec: KeySpec{
+				Type: KeyTypeEd25519,
+				Size: 256,
+			},
+			expect: AlgorithmEdDSA,
+		},
+		{
+			name: "Ed25519 wrong size",
+			keySpec: KeySpec{
+				Type: KeyTypeEd25519,
+				Size: 512,
+			},
+			expect: 0,
+		},
 		{
 			name: "Unsupported key spec",
 			keySpec: KeySpec{
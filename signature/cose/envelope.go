@@ -16,6 +16,7 @@ package cose
 import (
 	"crypto"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"errors"
 	"fmt"
@@ -82,12 +83,30 @@ const (
 
 // Map of cose.Algorithm to signature.Algorithm
 var coseAlgSignatureAlgMap = map[cose.Algorithm]signature.Algorithm{
-	cose.AlgorithmPS256: signature.AlgorithmPS256,
-	cose.AlgorithmPS384: signature.AlgorithmPS384,
-	cose.AlgorithmPS512: signature.AlgorithmPS512,
-	cose.AlgorithmES256: signature.AlgorithmES256,
-	cose.AlgorithmES384: signature.AlgorithmES384,
-	cose.AlgorithmES512: signature.AlgorithmES512,
+	cose.AlgorithmPS256:   signature.AlgorithmPS256,
+	cose.AlgorithmPS384:   signature.AlgorithmPS384,
+	cose.AlgorithmPS512:   signature.AlgorithmPS512,
+	cose.AlgorithmES256:   signature.AlgorithmES256,
+	cose.AlgorithmES384:   signature.AlgorithmES384,
+	cose.AlgorithmES512:   signature.AlgorithmES512,
+	cose.AlgorithmEd25519: signature.AlgorithmEdDSA,
+}
+
+// COSE algorithms of RSASSA-PKCS1-v1_5, which are only supported for
+// verification.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc8812.html#section-2
+const (
+	algorithmRS256 cose.Algorithm = -257
+	algorithmRS384 cose.Algorithm = -258
+	algorithmRS512 cose.Algorithm = -259
+)
+
+// Map of legacy cose.Algorithm to signature.Algorithm
+var legacyCoseAlgSignatureAlgMap = map[cose.Algorithm]signature.Algorithm{
+	algorithmRS256: signature.AlgorithmRS256,
+	algorithmRS384: signature.AlgorithmRS384,
+	algorithmRS512: signature.AlgorithmRS512,
 }
 
 // Map of signingScheme to signingTime header label
@@ -233,11 +252,22 @@ func (e *envelope) Sign(req *signature.SignRequest) ([]byte, error) {
 	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
 		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
 	}
+	if req.DetachedPayload {
+		// a nil payload is encoded as CBOR null to indicate detached content
+		msg.Payload = nil
+	}
 
 	// generate unprotected headers of COSE envelope
 	generateUnprotectedHeaders(req, signer, msg.Headers.Unprotected)
 
-	// TODO: needs to add headerKeyTimeStampSignature.
+	// timestamp the signature of COSE envelope
+	if req.Timestamper != nil {
+		token, err := timestampSignature(req.Timestamper, signer.Algorithm(), msg.Signature)
+		if err != nil {
+			return nil, err
+		}
+		msg.Headers.Unprotected[headerLabelTimeStampSignature] = token
+	}
 
 	// encode Sign1Message into COSE_Sign1_Tagged object
 	encoded, err := msg.MarshalCBOR()
@@ -249,15 +279,91 @@ func (e *envelope) Sign(req *signature.SignRequest) ([]byte, error) {
 	return encoded, nil
 }
 
+// SetTimestampSignature implements signature.TimestampSetter interface.
+// Only the unprotected headers of the COSE envelope are updated.
+func (e *envelope) SetTimestampSignature(token []byte) ([]byte, error) {
+	// sanity check
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+
+	if e.base.Headers.Unprotected == nil {
+		e.base.Headers.Unprotected = cose.UnprotectedHeader{}
+	}
+	e.base.Headers.Unprotected[headerLabelTimeStampSignature] = token
+	// re-encode the unprotected headers while the raw protected headers are
+	// kept as is
+	e.base.Headers.RawUnprotected = nil
+
+	encoded, err := e.base.MarshalCBOR()
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return encoded, nil
+}
+
 // Verify implements signature.Envelope interface.
 // Note: Verfiy only verifies integrity of the given COSE envelope.
 func (e *envelope) Verify() (*signature.EnvelopeContent, error) {
+	return e.VerifyWithOptions(signature.VerifyOptions{})
+}
+
+// VerifyWithOptions implements signature.OptionsVerifier interface.
+func (e *envelope) VerifyWithOptions(opts signature.VerifyOptions) (*signature.EnvelopeContent, error) {
 	// sanity check
 	if e.base == nil {
 		return nil, &signature.SignatureEnvelopeNotFoundError{}
 	}
 
-	certs, ok := e.base.Headers.Unprotected[cose.HeaderLabelX5Chain].([]any)
+	cert, err := parseSigningCertificate(e.base.Headers.Unprotected)
+	if err != nil {
+		return nil, err
+	}
+
+	// core verify process, verify integrity of COSE envelope
+	verifier, err := getVerifier(e.base.Headers.Protected, cert, opts)
+	if err != nil {
+		return nil, err
+	}
+	msg := e.base
+	detached := e.base.Payload == nil
+	if detached {
+		if len(opts.DetachedPayload) == 0 {
+			return nil, &signature.InvalidArgumentError{
+				Param: "opts.DetachedPayload",
+				Err:   errors.New("payload is detached from COSE envelope but not provided"),
+			}
+		}
+		// verify a copy of the message with the detached payload
+		detachedMsg := *e.base
+		detachedMsg.Payload = opts.DetachedPayload
+		msg = &detachedMsg
+	} else if opts.DetachedPayload != nil {
+		return nil, &signature.InvalidArgumentError{
+			Param: "opts.DetachedPayload",
+			Err:   errors.New("payload is embedded in COSE envelope"),
+		}
+	}
+	err = msg.Verify(nil, verifier)
+	if err != nil {
+		return nil, &signature.SignatureIntegrityError{Err: err}
+	}
+
+	// extract content
+	content, err := e.Content()
+	if err != nil {
+		return nil, err
+	}
+	if detached {
+		content.Payload.Content = opts.DetachedPayload
+	}
+	return content, nil
+}
+
+// parseSigningCertificate parses the leaf certificate of the certificate chain
+// in the unprotected headers.
+func parseSigningCertificate(unprotected cose.UnprotectedHeader) (*x509.Certificate, error) {
+	certs, ok := unprotected[cose.HeaderLabelX5Chain].([]any)
 	if !ok || len(certs) == 0 {
 		return nil, &signature.InvalidSignatureError{Msg: "certificate chain is not present"}
 	}
@@ -269,23 +375,64 @@ func (e *envelope) Verify() (*signature.EnvelopeContent, error) {
 	if err != nil {
 		return nil, &signature.InvalidSignatureError{Msg: "malformed leaf certificate"}
 	}
+	return cert, nil
+}
 
-	// core verify process, verify integrity of COSE envelope
-	publicKeyAlg, err := getSignatureAlgorithm(cert)
+// getVerifier returns the cose.Verifier for the signing certificate. Legacy
+// algorithms specified in the protected headers are only verified if allowed
+// by opts.
+func getVerifier(protected cose.ProtectedHeader, cert *x509.Certificate, opts signature.VerifyOptions) (cose.Verifier, error) {
+	alg, err := protected.Algorithm()
 	if err != nil {
 		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
 	}
-	verifier, err := cose.NewVerifier(publicKeyAlg, cert.PublicKey)
+	if sigAlg, ok := legacyCoseAlgSignatureAlgMap[alg]; ok {
+		if !opts.AllowLegacyAlgorithms {
+			return nil, &signature.UnsupportedSignatureAlgoError{Alg: strconv.Itoa(int(alg))}
+		}
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, &signature.InvalidSignatureError{Msg: "legacy signature algorithm requires an RSA signing certificate"}
+		}
+		return &legacyVerifier{
+			alg:  alg,
+			hash: sigAlg.Hash(),
+			key:  key,
+		}, nil
+	}
+
+	publicKeyAlg, err := getSignatureAlgorithm(cert)
 	if err != nil {
 		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
 	}
-	err = e.base.Verify(nil, verifier)
+	verifier, err := cose.NewVerifier(publicKeyAlg, cert.PublicKey)
 	if err != nil {
-		return nil, &signature.SignatureIntegrityError{Err: err}
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
 	}
+	return verifier, nil
+}
 
-	// extract content
-	return e.Content()
+// legacyVerifier implements cose.Verifier for RSASSA-PKCS1-v1_5 algorithms,
+// which are not supported by go-cose.
+type legacyVerifier struct {
+	alg  cose.Algorithm
+	hash crypto.Hash
+	key  *rsa.PublicKey
+}
+
+// Algorithm implements cose.Verifier interface.
+func (v *legacyVerifier) Algorithm() cose.Algorithm {
+	return v.alg
+}
+
+// Verify implements cose.Verifier interface.
+func (v *legacyVerifier) Verify(content, sig []byte) error {
+	h := v.hash.New()
+	h.Write(content)
+	if err := rsa.VerifyPKCS1v15(v.key, v.hash, h.Sum(nil), sig); err != nil {
+		return cose.ErrVerification
+	}
+	return nil
 }
 
 // Content implements signature.Envelope interface.
@@ -311,7 +458,13 @@ func (e *envelope) Content() (*signature.EnvelopeContent, error) {
 
 // Given a COSE envelope, extracts its signature.Payload.
 func (e *envelope) payload() (*signature.Payload, error) {
-	cty, ok := e.base.Headers.Protected[cose.HeaderLabelContentType]
+	return parsePayload(e.base.Headers.Protected, e.base.Payload)
+}
+
+// parsePayload extracts signature.Payload from the protected headers and the
+// payload of a COSE message.
+func parsePayload(protected cose.ProtectedHeader, content []byte) (*signature.Payload, error) {
+	cty, ok := protected[cose.HeaderLabelContentType]
 	if !ok {
 		return nil, &signature.InvalidSignatureError{Msg: "missing content type"}
 	}
@@ -321,16 +474,22 @@ func (e *envelope) payload() (*signature.Payload, error) {
 	}
 	return &signature.Payload{
 		ContentType: contentType,
-		Content:     e.base.Payload,
+		Content:     content,
+		Detached:    content == nil,
 	}, nil
 }
 
 // Given a COSE envelope, extracts its signature.SignerInfo.
 func (e *envelope) signerInfo() (*signature.SignerInfo, error) {
+	return parseSignerInfo(&e.base.Headers, e.base.Signature)
+}
+
+// parseSignerInfo extracts signature.SignerInfo from the headers and the
+// signature of a COSE_Sign1 message or a COSE_Signature.
+func parseSignerInfo(headers *cose.Headers, sig []byte) (*signature.SignerInfo, error) {
 	var signerInfo signature.SignerInfo
 
 	// parse signature of COSE envelope, populate signerInfo.Signature
-	sig := e.base.Signature
 	if len(sig) == 0 {
 		return nil, &signature.InvalidSignatureError{Msg: "signature missing in COSE envelope"}
 	}
@@ -338,13 +497,13 @@ func (e *envelope) signerInfo() (*signature.SignerInfo, error) {
 
 	// parse protected headers of COSE envelope and populate related
 	// signerInfo fields
-	err := parseProtectedHeaders(e.base.Headers.RawProtected, e.base.Headers.Protected, &signerInfo)
+	err := parseProtectedHeaders(headers.RawProtected, headers.Protected, &signerInfo)
 	if err != nil {
 		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
 	}
 
 	// parse unprotected headers of COSE envelope
-	certs, ok := e.base.Headers.Unprotected[cose.HeaderLabelX5Chain].([]any)
+	certs, ok := headers.Unprotected[cose.HeaderLabelX5Chain].([]any)
 	if !ok || len(certs) == 0 {
 		return nil, &signature.InvalidSignatureError{Msg: "certificate chain is not present"}
 	}
@@ -364,11 +523,18 @@ func (e *envelope) signerInfo() (*signature.SignerInfo, error) {
 	signerInfo.CertificateChain = certChain
 
 	// populate signerInfo.UnsignedAttributes.SigningAgent
-	if h, ok := e.base.Headers.Unprotected[headerLabelSigningAgent].(string); ok {
+	if h, ok := headers.Unprotected[headerLabelSigningAgent].(string); ok {
 		signerInfo.UnsignedAttributes.SigningAgent = h
 	}
 
-	// TODO: needs to add headerKeyTimeStampSignature.
+	// populate signerInfo.UnsignedAttributes.TimestampSignature
+	if h, ok := headers.Unprotected[headerLabelTimeStampSignature]; ok {
+		token, ok := h.([]byte)
+		if !ok || len(token) == 0 {
+			return nil, &signature.InvalidSignatureError{Msg: "malformed timestamp signature"}
+		}
+		signerInfo.UnsignedAttributes.TimestampSignature = token
+	}
 
 	return &signerInfo, nil
 }
@@ -409,6 +575,11 @@ func getSignatureAlgorithmFromKeySpec(keySpec signature.KeySpec) (cose.Algorithm
 		default:
 			return 0, &signature.UnsupportedSigningKeyError{Msg: fmt.Sprintf("EC: key size %d not supported", keySpec.Size)}
 		}
+	case signature.KeyTypeEd25519:
+		if keySpec.Size != 256 {
+			return 0, &signature.UnsupportedSigningKeyError{Msg: fmt.Sprintf("Ed25519: key size %d not supported", keySpec.Size)}
+		}
+		return cose.AlgorithmEd25519, nil
 	default:
 		return 0, &signature.UnsupportedSigningKeyError{Msg: "key type not supported"}
 	}
@@ -488,6 +659,20 @@ func generateUnprotectedHeaders(req *signature.SignRequest, signer signer, unpro
 	unprotected[cose.HeaderLabelX5Chain] = certChain
 }
 
+// timestampSignature obtains a timestamp token over the signature of COSE
+// envelope from the timestamper.
+func timestampSignature(timestamper signature.Timestamper, alg cose.Algorithm, sig []byte) ([]byte, error) {
+	sigAlg, ok := coseAlgSignatureAlgMap[alg]
+	if !ok {
+		return nil, &signature.UnsupportedSignatureAlgoError{Alg: alg.String()}
+	}
+	token, err := timestamper.Timestamp(sig, sigAlg.Hash())
+	if err != nil {
+		return nil, &signature.TimestampError{Err: err}
+	}
+	return token, nil
+}
+
 // parseProtectedHeaders parses COSE envelope's protected headers and
 // populates signature.SignerInfo.
 func parseProtectedHeaders(rawProtected cbor.RawMessage, protected cose.ProtectedHeader, signerInfo *signature.SignerInfo) error {
@@ -503,6 +688,9 @@ func parseProtectedHeaders(rawProtected cbor.RawMessage, protected cose.Protecte
 		return err
 	}
 	sigAlg, ok := coseAlgSignatureAlgMap[alg]
+	if !ok {
+		sigAlg, ok = legacyCoseAlgSignatureAlgMap[alg]
+	}
 	if !ok {
 		return &signature.InvalidSignatureError{Msg: "signature algorithm not supported: " + strconv.Itoa(int(alg))}
 	}
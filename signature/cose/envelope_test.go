@@ -14,10 +14,14 @@
 package cose
 
 import (
+	"bytes"
 	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"io"
 	"testing"
 	"time"
 
@@ -544,9 +548,10 @@ func TestSignerInfoErrors(t *testing.T) {
 		if err != nil {
 			t.Fatalf("getVerifyCOSE() failed. Error = %s", err)
 		}
-		env.base.Headers.Protected.SetAlgorithm(cose.AlgorithmEd25519)
+		// ES256K
+		env.base.Headers.Protected.SetAlgorithm(cose.Algorithm(-47))
 		_, err = env.Content()
-		expected := errors.New("signature algorithm not supported: -8")
+		expected := errors.New("signature algorithm not supported: -47")
 		if !isErrEqual(expected, err) {
 			t.Fatalf("Content() expects error: %v, but got: %v.", expected, err)
 		}
@@ -770,6 +775,35 @@ func TestSignAndVerify(t *testing.T) {
 	}
 }
 
+func TestSignAndVerifyWithSigner(t *testing.T) {
+	for _, keyType := range signaturetest.KeyTypes {
+		for _, size := range signaturetest.GetKeySizes(keyType) {
+			t.Run(fmt.Sprintf("%v keyType, %v keySize", keyType, size), func(t *testing.T) {
+				signer, err := signaturetest.GetTestSigner(keyType, size)
+				if err != nil {
+					t.Fatalf("GetTestSigner() failed. Error = %s", err)
+				}
+				signRequest, err := newSignRequest("notary.x509", keyType, size)
+				if err != nil {
+					t.Fatalf("newSignRequest() failed. Error = %s", err)
+				}
+				signRequest.Signer = signer
+				encoded, err := NewEnvelope().Sign(signRequest)
+				if err != nil {
+					t.Fatalf("Sign() failed. Error = %s", err)
+				}
+				env, err := ParseEnvelope(encoded)
+				if err != nil {
+					t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+				}
+				if _, err = env.Verify(); err != nil {
+					t.Fatalf("Verify() failed. Error = %s", err)
+				}
+			})
+		}
+	}
+}
+
 func TestSignAndParseVerify(t *testing.T) {
 	for _, signingScheme := range signingSchemeString {
 		for _, keyType := range signaturetest.KeyTypes {
@@ -928,7 +962,7 @@ func (s *errorRemoteSigner) KeySpec() (signature.KeySpec, error) {
 	}
 	if s.keyTypeError {
 		return signature.KeySpec{
-			Type: 3,
+			Type: 0,
 			Size: 3072,
 		}, nil
 	}
@@ -993,3 +1027,365 @@ func generateTestRawMessage(raw cbor.RawMessage, label string, unmarshalError bo
 
 	return resRaw
 }
+
+func TestSignWithTimestamper(t *testing.T) {
+	signRequest, err := getSignRequest()
+	if err != nil {
+		t.Fatalf("getSignRequest() failed. Error = %s", err)
+	}
+
+	t.Run("timestamp signature", func(t *testing.T) {
+		timestamper := &mockTimestamper{token: []byte("timestamp token")}
+		signRequest.Timestamper = timestamper
+		env := createNewEnv(nil)
+		if _, err := env.Sign(signRequest); err != nil {
+			t.Fatalf("Sign() failed. Error = %s", err)
+		}
+		if !bytes.Equal(timestamper.signature, env.base.Signature) {
+			t.Fatalf("expected timestamped signature %x, but got %x", env.base.Signature, timestamper.signature)
+		}
+		if timestamper.hash != crypto.SHA384 {
+			t.Fatalf("expected hash %v, but got %v", crypto.SHA384, timestamper.hash)
+		}
+		token, ok := env.base.Headers.Unprotected[headerLabelTimeStampSignature].([]byte)
+		if !ok || !bytes.Equal(token, timestamper.token) {
+			t.Fatalf("expected timestamp token %q, but got %v", timestamper.token, env.base.Headers.Unprotected[headerLabelTimeStampSignature])
+		}
+	})
+
+	t.Run("timestamper error", func(t *testing.T) {
+		signRequest.Timestamper = &mockTimestamper{err: errors.New("tsa error")}
+		env := createNewEnv(nil)
+		_, err := env.Sign(signRequest)
+		var timestampErr *signature.TimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("expected TimestampError, but got %v", err)
+		}
+	})
+}
+
+func TestSetTimestampSignature(t *testing.T) {
+	signRequest, err := getSignRequest()
+	if err != nil {
+		t.Fatalf("getSignRequest() failed. Error = %s", err)
+	}
+	encoded, err := NewEnvelope().Sign(signRequest)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	content, err := env.Content()
+	if err != nil {
+		t.Fatalf("Content() failed. Error = %s", err)
+	}
+	if len(content.SignerInfo.UnsignedAttributes.TimestampSignature) != 0 {
+		t.Fatalf("expected no timestamp signature, but got %q", content.SignerInfo.UnsignedAttributes.TimestampSignature)
+	}
+
+	// add timestamp signature after signing
+	token := []byte("timestamp token")
+	setter, ok := env.(signature.TimestampSetter)
+	if !ok {
+		t.Fatal("expected COSE envelope to implement signature.TimestampSetter")
+	}
+	timestamped, err := setter.SetTimestampSignature(token)
+	if err != nil {
+		t.Fatalf("SetTimestampSignature() failed. Error = %s", err)
+	}
+
+	env, err = ParseEnvelope(timestamped)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	timestampedContent, err := env.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	if !bytes.Equal(timestampedContent.SignerInfo.UnsignedAttributes.TimestampSignature, token) {
+		t.Fatalf("expected timestamp signature %q, but got %q", token, timestampedContent.SignerInfo.UnsignedAttributes.TimestampSignature)
+	}
+	if !bytes.Equal(timestampedContent.SignerInfo.Signature, content.SignerInfo.Signature) {
+		t.Fatal("expected signature to be unchanged")
+	}
+
+	var original, updated cose.Sign1Message
+	if err := original.UnmarshalCBOR(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := updated.UnmarshalCBOR(timestamped); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original.Headers.RawProtected, updated.Headers.RawProtected) {
+		t.Fatal("expected protected headers to be unchanged")
+	}
+}
+
+func TestAddTimestamp(t *testing.T) {
+	signRequest, err := getSignRequest()
+	if err != nil {
+		t.Fatalf("getSignRequest() failed. Error = %s", err)
+	}
+	encoded, err := NewEnvelope().Sign(signRequest)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	content, err := env.Content()
+	if err != nil {
+		t.Fatalf("Content() failed. Error = %s", err)
+	}
+
+	root := testhelper.GetRSARootCertificate()
+	tsa := testhelper.GetRSATimeStampingCertTuple(root)
+	h := crypto.SHA384.New()
+	h.Write(content.SignerInfo.Signature)
+	genTime := time.Now().Truncate(time.Second)
+	token, err := testhelper.CreateTimestampToken(testhelper.TimestampTokenOptions{
+		TSA:           tsa,
+		Certificates:  []*x509.Certificate{tsa.Cert, root.Cert},
+		Hash:          crypto.SHA384,
+		HashedMessage: h.Sum(nil),
+		GenTime:       genTime,
+	})
+	if err != nil {
+		t.Fatalf("CreateTimestampToken() failed. Error = %s", err)
+	}
+
+	timestamped, err := signature.AddTimestamp(MediaTypeEnvelope, encoded, token)
+	if err != nil {
+		t.Fatalf("AddTimestamp() failed. Error = %s", err)
+	}
+	env, err = ParseEnvelope(timestamped)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	timestampedContent, err := env.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Cert)
+	ts, err := timestampedContent.SignerInfo.AuthenticTimestamp(x509.VerifyOptions{Roots: roots})
+	if err != nil {
+		t.Fatalf("AuthenticTimestamp() failed. Error = %s", err)
+	}
+	if !ts.Value.Equal(genTime) {
+		t.Fatalf("expected authentic signing time %v, but got %v", genTime, ts.Value)
+	}
+}
+
+func TestSignerInfoMalformedTimestampSignature(t *testing.T) {
+	env, err := getVerifyCOSE("notary.x509", signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("getVerifyCOSE() failed. Error = %s", err)
+	}
+	env.base.Headers.Unprotected[headerLabelTimeStampSignature] = "token"
+	_, err = env.Content()
+	expected := &signature.InvalidSignatureError{Msg: "malformed timestamp signature"}
+	if !isErrEqual(expected, err) {
+		t.Fatalf("Content() expects error: %v, but got: %v.", expected, err)
+	}
+}
+
+type mockTimestamper struct {
+	token     []byte
+	err       error
+	signature []byte
+	hash      crypto.Hash
+}
+
+func (m *mockTimestamper) Timestamp(signature []byte, hash crypto.Hash) ([]byte, error) {
+	m.signature = signature
+	m.hash = hash
+	return m.token, m.err
+}
+
+// legacySigner implements cose.Signer for RSASSA-PKCS1-v1_5 algorithms.
+type legacySigner struct {
+	alg  cose.Algorithm
+	hash crypto.Hash
+	key  *rsa.PrivateKey
+}
+
+// Algorithm implements cose.Signer interface.
+func (s *legacySigner) Algorithm() cose.Algorithm {
+	return s.alg
+}
+
+// Sign implements cose.Signer interface.
+func (s *legacySigner) Sign(rand io.Reader, content []byte) ([]byte, error) {
+	h := s.hash.New()
+	h.Write(content)
+	return rsa.SignPKCS1v15(rand, s.key, s.hash, h.Sum(nil))
+}
+
+// getLegacyVerifyCOSE returns a COSE envelope signed by the legacy alg.
+func getLegacyVerifyCOSE(alg cose.Algorithm, hash crypto.Hash) (envelope, error) {
+	signRequest, err := getSignRequest()
+	if err != nil {
+		return createNewEnv(nil), err
+	}
+	encoded, err := NewEnvelope().Sign(signRequest)
+	if err != nil {
+		return createNewEnv(nil), err
+	}
+	var msg cose.Sign1Message
+	if err := msg.UnmarshalCBOR(encoded); err != nil {
+		return createNewEnv(nil), err
+	}
+
+	// re-sign the message with the legacy algorithm, keeping the tagged
+	// time values of the original protected headers
+	headerMap, err := generateRawProtectedCBORMap(msg.Headers.RawProtected)
+	if err != nil {
+		return createNewEnv(nil), err
+	}
+	for label, value := range msg.Headers.Protected {
+		if _, ok := value.(time.Time); ok {
+			msg.Headers.Protected[label] = headerMap[label]
+		}
+	}
+	msg.Headers.RawProtected = nil
+	msg.Headers.Protected.SetAlgorithm(alg)
+	msg.Signature = nil
+	signer := &legacySigner{
+		alg:  alg,
+		hash: hash,
+		key:  signRequest.Signer.(signature.LocalSigner).PrivateKey().(*rsa.PrivateKey),
+	}
+	if err := msg.Sign(rand.Reader, nil, signer); err != nil {
+		return createNewEnv(nil), err
+	}
+	if encoded, err = msg.MarshalCBOR(); err != nil {
+		return createNewEnv(nil), err
+	}
+	var legacyMsg cose.Sign1Message
+	if err := legacyMsg.UnmarshalCBOR(encoded); err != nil {
+		return createNewEnv(nil), err
+	}
+	return createNewEnv(&legacyMsg), nil
+}
+
+func TestVerifyLegacyAlgorithms(t *testing.T) {
+	tests := []struct {
+		name   string
+		alg    cose.Algorithm
+		hash   crypto.Hash
+		expect signature.Algorithm
+	}{
+		{name: "RS256", alg: algorithmRS256, hash: crypto.SHA256, expect: signature.AlgorithmRS256},
+		{name: "RS384", alg: algorithmRS384, hash: crypto.SHA384, expect: signature.AlgorithmRS384},
+		{name: "RS512", alg: algorithmRS512, hash: crypto.SHA512, expect: signature.AlgorithmRS512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := getLegacyVerifyCOSE(tt.alg, tt.hash)
+			if err != nil {
+				t.Fatalf("getLegacyVerifyCOSE() failed. Error = %s", err)
+			}
+
+			// legacy algorithms are rejected by default
+			_, err = env.Verify()
+			var unsupportedErr *signature.UnsupportedSignatureAlgoError
+			if !errors.As(err, &unsupportedErr) {
+				t.Fatalf("Verify() expects UnsupportedSignatureAlgoError, but got: %v.", err)
+			}
+
+			content, err := env.VerifyWithOptions(signature.VerifyOptions{AllowLegacyAlgorithms: true})
+			if err != nil {
+				t.Fatalf("VerifyWithOptions() failed. Error = %s", err)
+			}
+			if alg := content.SignerInfo.SignatureAlgorithm; alg != tt.expect || !alg.IsLegacy() {
+				t.Fatalf("expected legacy signature algorithm %v, but got %v", tt.expect, alg)
+			}
+		})
+	}
+
+	t.Run("tamper signature", func(t *testing.T) {
+		env, err := getLegacyVerifyCOSE(algorithmRS256, crypto.SHA256)
+		if err != nil {
+			t.Fatalf("getLegacyVerifyCOSE() failed. Error = %s", err)
+		}
+		env.base.Signature[0] ^= 0xff
+		_, err = env.VerifyWithOptions(signature.VerifyOptions{AllowLegacyAlgorithms: true})
+		expected := errors.New("signature is invalid. Error: verification error")
+		if !isErrEqual(expected, err) {
+			t.Fatalf("VerifyWithOptions() expects error: %v, but got: %v.", expected, err)
+		}
+	})
+}
+
+func TestSignAndVerifyDetachedPayload(t *testing.T) {
+	signRequest, err := getSignRequest()
+	if err != nil {
+		t.Fatalf("getSignRequest() failed. Error = %s", err)
+	}
+	signRequest.DetachedPayload = true
+	encoded, err := NewEnvelope().Sign(signRequest)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	var msg cose.Sign1Message
+	if err := msg.UnmarshalCBOR(encoded); err != nil {
+		t.Fatalf("UnmarshalCBOR() failed. Error = %s", err)
+	}
+	if msg.Payload != nil {
+		t.Fatalf("expected detached payload, but got %q", msg.Payload)
+	}
+
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	content, err := env.Content()
+	if err != nil {
+		t.Fatalf("Content() failed. Error = %s", err)
+	}
+	if !content.Payload.Detached || content.Payload.Content != nil {
+		t.Fatalf("Content() expects detached payload without content, but got %+v", content.Payload)
+	}
+
+	t.Run("verify with detached payload", func(t *testing.T) {
+		content, err := signature.Verify(env, signature.VerifyOptions{DetachedPayload: []byte(payloadString)})
+		if err != nil {
+			t.Fatalf("Verify() failed. Error = %s", err)
+		}
+		if !content.Payload.Detached || string(content.Payload.Content) != payloadString {
+			t.Fatalf("Verify() expects detached payload %q, but got %+v", payloadString, content.Payload)
+		}
+	})
+
+	t.Run("verify without detached payload", func(t *testing.T) {
+		_, err := env.Verify()
+		expected := errors.New(`"opts.DetachedPayload" param is invalid. Error: payload is detached from COSE envelope but not provided`)
+		if !isErrEqual(expected, err) {
+			t.Fatalf("Verify() expects error: %v, but got: %v.", expected, err)
+		}
+	})
+
+	t.Run("verify with tampered detached payload", func(t *testing.T) {
+		_, err := signature.Verify(env, signature.VerifyOptions{DetachedPayload: []byte("tampered")})
+		var integrityErr *signature.SignatureIntegrityError
+		if !errors.As(err, &integrityErr) {
+			t.Fatalf("Verify() expects SignatureIntegrityError, but got: %v.", err)
+		}
+	})
+
+	t.Run("verify embedded payload with detached payload", func(t *testing.T) {
+		env, err := getVerifyCOSE("notary.x509", signature.KeyTypeRSA, 3072)
+		if err != nil {
+			t.Fatalf("getVerifyCOSE() failed. Error = %s", err)
+		}
+		_, err = env.VerifyWithOptions(signature.VerifyOptions{DetachedPayload: []byte(payloadString)})
+		expected := errors.New(`"opts.DetachedPayload" param is invalid. Error: payload is embedded in COSE envelope`)
+		if !isErrEqual(expected, err) {
+			t.Fatalf("VerifyWithOptions() expects error: %v, but got: %v.", expected, err)
+		}
+	})
+}
@@ -0,0 +1,258 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cose
+
+import (
+	"crypto/rand"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/internal/base"
+	"github.com/veraison/go-cose"
+)
+
+// MediaTypeMultiSignatureEnvelope is the mediaType of COSE signature envelope
+// blobs holding multiple signatures in a COSE_Sign message.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc9052.html#section-4.1
+const MediaTypeMultiSignatureEnvelope = `application/cose; cose-type="cose-sign"`
+
+func init() {
+	err := signature.RegisterEnvelopeType(MediaTypeMultiSignatureEnvelope, NewMultiSignatureEnvelope, ParseMultiSignatureEnvelope)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// multiEnvelope implements signature.MultiSignatureEnvelope with a COSE_Sign
+// message. The content type of the payload is in the body protected headers,
+// while the signed attributes of each signer are in the protected headers of
+// the corresponding COSE_Signature.
+type multiEnvelope struct {
+	base *cose.SignMessage
+}
+
+// NewMultiSignatureEnvelope initializes an empty COSE signature envelope
+// holding multiple signatures.
+func NewMultiSignatureEnvelope() signature.Envelope {
+	return &base.MultiEnvelope{
+		Envelope: base.Envelope{
+			Envelope: &multiEnvelope{},
+		},
+	}
+}
+
+// ParseMultiSignatureEnvelope parses envelopeBytes to a COSE signature
+// envelope holding multiple signatures.
+func ParseMultiSignatureEnvelope(envelopeBytes []byte) (signature.Envelope, error) {
+	var msg cose.SignMessage
+	if err := msg.UnmarshalCBOR(envelopeBytes); err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return &base.MultiEnvelope{
+		Envelope: base.Envelope{
+			Envelope: &multiEnvelope{
+				base: &msg,
+			},
+			Raw: envelopeBytes,
+		},
+	}, nil
+}
+
+// Sign implements signature.Envelope interface.
+// On success, this function returns the COSE_Sign message with a single
+// signature.
+func (e *multiEnvelope) Sign(req *signature.SignRequest) ([]byte, error) {
+	if req.DetachedPayload {
+		return nil, &signature.InvalidSignRequestError{Msg: "detached payload is not supported by COSE_Sign envelope"}
+	}
+
+	// prepare COSE_Sign message
+	msg := cose.NewSignMessage()
+	msg.Headers.Protected[cose.HeaderLabelContentType] = req.Payload.ContentType
+	msg.Payload = req.Payload.Content
+	if err := addSignature(msg, req); err != nil {
+		return nil, err
+	}
+
+	// encode SignMessage into COSE_Sign_Tagged object
+	encoded, err := msg.MarshalCBOR()
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	e.base = msg
+
+	return encoded, nil
+}
+
+// AddSignature implements signature.MultiSignatureEnvelope interface.
+// The body headers and the existing signatures are encoded as is.
+func (e *multiEnvelope) AddSignature(req *signature.SignRequest) ([]byte, error) {
+	// sanity check
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+	if req.DetachedPayload {
+		return nil, &signature.InvalidSignRequestError{Msg: "detached payload is not supported by COSE_Sign envelope"}
+	}
+
+	if err := addSignature(e.base, req); err != nil {
+		return nil, err
+	}
+	encoded, err := e.base.MarshalCBOR()
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return encoded, nil
+}
+
+// Clone returns a copy of the envelope. Signatures added to the copy are not
+// added to e.
+func (e *multiEnvelope) Clone() signature.MultiSignatureEnvelope {
+	if e.base == nil {
+		return &multiEnvelope{}
+	}
+	msg := *e.base
+	msg.Signatures = append([]*cose.Signature(nil), e.base.Signatures...)
+	return &multiEnvelope{base: &msg}
+}
+
+// Verify implements signature.Envelope interface.
+// All the signatures are verified, and the signer info of the first signature
+// is returned.
+func (e *multiEnvelope) Verify() (*signature.EnvelopeContent, error) {
+	content, err := e.VerifyAll()
+	if err != nil {
+		return nil, err
+	}
+	return &signature.EnvelopeContent{
+		SignerInfo: content.SignerInfos[0],
+		Payload:    content.Payload,
+	}, nil
+}
+
+// VerifyAll implements signature.MultiSignatureEnvelope interface.
+// Note: VerifyAll only verifies integrity of the given COSE envelope.
+func (e *multiEnvelope) VerifyAll() (*signature.MultiSignatureContent, error) {
+	// sanity check
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+	if len(e.base.Signatures) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "signature missing in COSE envelope"}
+	}
+
+	payload, err := parsePayload(e.base.Headers.Protected, e.base.Payload)
+	if err != nil {
+		return nil, err
+	}
+	bodyProtected, err := e.base.Headers.MarshalProtected()
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+
+	// core verify process, verify integrity of each COSE_Signature
+	signerInfos := make([]signature.SignerInfo, 0, len(e.base.Signatures))
+	for _, sig := range e.base.Signatures {
+		cert, err := parseSigningCertificate(sig.Headers.Unprotected)
+		if err != nil {
+			return nil, err
+		}
+		verifier, err := getVerifier(sig.Headers.Protected, cert, signature.VerifyOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if err := sig.Verify(verifier, bodyProtected, e.base.Payload, nil); err != nil {
+			return nil, &signature.SignatureIntegrityError{Err: err}
+		}
+		signerInfo, err := parseSignerInfo(&sig.Headers, sig.Signature)
+		if err != nil {
+			return nil, err
+		}
+		signerInfos = append(signerInfos, *signerInfo)
+	}
+
+	return &signature.MultiSignatureContent{
+		SignerInfos: signerInfos,
+		Payload:     *payload,
+	}, nil
+}
+
+// Content implements signature.Envelope interface.
+// The signer info of the first signature is returned.
+func (e *multiEnvelope) Content() (*signature.EnvelopeContent, error) {
+	// sanity check
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+	if len(e.base.Signatures) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "signature missing in COSE envelope"}
+	}
+
+	payload, err := parsePayload(e.base.Headers.Protected, e.base.Payload)
+	if err != nil {
+		return nil, err
+	}
+	sig := e.base.Signatures[0]
+	signerInfo, err := parseSignerInfo(&sig.Headers, sig.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return &signature.EnvelopeContent{
+		SignerInfo: *signerInfo,
+		Payload:    *payload,
+	}, nil
+}
+
+// addSignature signs the payload of the COSE_Sign message according to the
+// sign request and appends the COSE_Signature to the message.
+func addSignature(msg *cose.SignMessage, req *signature.SignRequest) error {
+	// get built-in signer from go-cose or remote signer based on req.Signer
+	signer, err := getSigner(req.Signer)
+	if err != nil {
+		return &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// generate protected headers of COSE_Signature
+	sig := cose.NewSignature()
+	sig.Headers.Protected.SetAlgorithm(signer.Algorithm())
+	if err := generateProtectedHeaders(req, sig.Headers.Protected); err != nil {
+		return &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// core sign process, the body protected headers are signed by each
+	// COSE_Signature
+	bodyProtected, err := msg.Headers.MarshalProtected()
+	if err != nil {
+		return &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	if err := sig.Sign(rand.Reader, signer, bodyProtected, msg.Payload, nil); err != nil {
+		return &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	msg.Headers.RawProtected = bodyProtected
+
+	// generate unprotected headers of COSE_Signature
+	generateUnprotectedHeaders(req, signer, sig.Headers.Unprotected)
+
+	// timestamp the signature of COSE_Signature
+	if req.Timestamper != nil {
+		token, err := timestampSignature(req.Timestamper, signer.Algorithm(), sig.Signature)
+		if err != nil {
+			return err
+		}
+		sig.Headers.Unprotected[headerLabelTimeStampSignature] = token
+	}
+
+	msg.Signatures = append(msg.Signatures, sig)
+	return nil
+}
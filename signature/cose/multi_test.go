@@ -0,0 +1,186 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cose
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/veraison/go-cose"
+)
+
+func signMultiSignatureEnvelope(t *testing.T) []byte {
+	t.Helper()
+	signRequest, err := newSignRequest("notary.x509", signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+	encoded, err := NewMultiSignatureEnvelope().Sign(signRequest)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+
+	coSignRequest, err := newSignRequest("notary.x509.signingAuthority", signature.KeyTypeEC, 256)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+	coSignRequest.Payload = signature.Payload{}
+	encoded, err = signature.AddSignature(MediaTypeMultiSignatureEnvelope, encoded, coSignRequest)
+	if err != nil {
+		t.Fatalf("AddSignature() failed. Error = %s", err)
+	}
+	return encoded
+}
+
+func TestMultiSignatureEnvelope(t *testing.T) {
+	encoded := signMultiSignatureEnvelope(t)
+	var msg cose.SignMessage
+	if err := msg.UnmarshalCBOR(encoded); err != nil {
+		t.Fatalf("UnmarshalCBOR() failed. Error = %s", err)
+	}
+	if len(msg.Signatures) != 2 {
+		t.Fatalf("expected 2 signatures, but got %d", len(msg.Signatures))
+	}
+
+	env, err := signature.ParseEnvelope(MediaTypeMultiSignatureEnvelope, encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	multiEnv, ok := env.(signature.MultiSignatureEnvelope)
+	if !ok {
+		t.Fatal("expected signature.MultiSignatureEnvelope")
+	}
+	content, err := multiEnv.VerifyAll()
+	if err != nil {
+		t.Fatalf("VerifyAll() failed. Error = %s", err)
+	}
+	if string(content.Payload.Content) != payloadString {
+		t.Fatalf("VerifyAll() expects payload %q, but got %q", payloadString, content.Payload.Content)
+	}
+	if len(content.SignerInfos) != 2 {
+		t.Fatalf("VerifyAll() expects 2 signer infos, but got %d", len(content.SignerInfos))
+	}
+	if alg := content.SignerInfos[0].SignatureAlgorithm; alg != signature.AlgorithmPS384 {
+		t.Errorf("expected algorithm %v for the first signature, but got %v", signature.AlgorithmPS384, alg)
+	}
+	if alg := content.SignerInfos[1].SignatureAlgorithm; alg != signature.AlgorithmES256 {
+		t.Errorf("expected algorithm %v for the second signature, but got %v", signature.AlgorithmES256, alg)
+	}
+	if scheme := content.SignerInfos[1].SignedAttributes.SigningScheme; scheme != signature.SigningSchemeX509SigningAuthority {
+		t.Errorf("expected signing scheme %v for the second signature, but got %v", signature.SigningSchemeX509SigningAuthority, scheme)
+	}
+
+	verified, err := env.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	if alg := verified.SignerInfo.SignatureAlgorithm; alg != signature.AlgorithmPS384 {
+		t.Errorf("Verify() expects the first signature, but got algorithm %v", alg)
+	}
+}
+
+func TestMultiSignatureEnvelopeTampered(t *testing.T) {
+	var msg cose.SignMessage
+	if err := msg.UnmarshalCBOR(signMultiSignatureEnvelope(t)); err != nil {
+		t.Fatalf("UnmarshalCBOR() failed. Error = %s", err)
+	}
+	msg.Signatures[1].Signature[0] ^= 0xff
+	encoded, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("MarshalCBOR() failed. Error = %s", err)
+	}
+
+	env, err := ParseMultiSignatureEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseMultiSignatureEnvelope() failed. Error = %s", err)
+	}
+	if _, err := env.Content(); err != nil {
+		t.Fatalf("Content() failed. Error = %s", err)
+	}
+	_, err = env.Verify()
+	var integrityErr *signature.SignatureIntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Fatalf("Verify() expects SignatureIntegrityError, but got: %v.", err)
+	}
+}
+
+func TestMultiSignatureEnvelopeErrors(t *testing.T) {
+	t.Run("parse single signature envelope", func(t *testing.T) {
+		env, err := getVerifyCOSE("notary.x509", signature.KeyTypeRSA, 3072)
+		if err != nil {
+			t.Fatalf("getVerifyCOSE() failed. Error = %s", err)
+		}
+		encoded, err := env.base.MarshalCBOR()
+		if err != nil {
+			t.Fatalf("MarshalCBOR() failed. Error = %s", err)
+		}
+		_, err = ParseMultiSignatureEnvelope(encoded)
+		var sigErr *signature.InvalidSignatureError
+		if !errors.As(err, &sigErr) {
+			t.Fatalf("ParseMultiSignatureEnvelope() expects InvalidSignatureError, but got: %v.", err)
+		}
+	})
+
+	t.Run("sign with detached payload", func(t *testing.T) {
+		signRequest, err := getSignRequest()
+		if err != nil {
+			t.Fatalf("getSignRequest() failed. Error = %s", err)
+		}
+		signRequest.DetachedPayload = true
+		_, err = NewMultiSignatureEnvelope().Sign(signRequest)
+		var reqErr *signature.InvalidSignRequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("Sign() expects InvalidSignRequestError, but got: %v.", err)
+		}
+	})
+
+	t.Run("add signature with mismatched payload", func(t *testing.T) {
+		signRequest, err := getSignRequest()
+		if err != nil {
+			t.Fatalf("getSignRequest() failed. Error = %s", err)
+		}
+		signRequest.Payload.Content = []byte("{}")
+		_, err = signature.AddSignature(MediaTypeMultiSignatureEnvelope, signMultiSignatureEnvelope(t), signRequest)
+		var reqErr *signature.InvalidSignRequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("AddSignature() expects InvalidSignRequestError, but got: %v.", err)
+		}
+	})
+
+	t.Run("add signature to empty envelope", func(t *testing.T) {
+		signRequest, err := getSignRequest()
+		if err != nil {
+			t.Fatalf("getSignRequest() failed. Error = %s", err)
+		}
+		env := &multiEnvelope{}
+		_, err = env.AddSignature(signRequest)
+		var notFoundErr *signature.SignatureEnvelopeNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Fatalf("AddSignature() expects SignatureEnvelopeNotFoundError, but got: %v.", err)
+		}
+	})
+
+	t.Run("verify with detached payload", func(t *testing.T) {
+		env, err := ParseMultiSignatureEnvelope(signMultiSignatureEnvelope(t))
+		if err != nil {
+			t.Fatalf("ParseMultiSignatureEnvelope() failed. Error = %s", err)
+		}
+		_, err = signature.Verify(env, signature.VerifyOptions{DetachedPayload: []byte(payloadString)})
+		var argErr *signature.InvalidArgumentError
+		if !errors.As(err, &argErr) {
+			t.Fatalf("Verify() expects InvalidArgumentError, but got: %v.", err)
+		}
+	})
+}
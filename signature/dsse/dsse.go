@@ -0,0 +1,145 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dsse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// signedAttributesType is the type of the notary signed attributes in the
+// pre-authentication encoding signed by the signed attributes signature.
+const signedAttributesType = "application/vnd.cncf.notary.dsse.signedattributes+json"
+
+// dsseSignedAttributes is the JSON serialization of the notary signed
+// attributes of a DSSE signature.
+type dsseSignedAttributes struct {
+	// Type of the payload signed by the DSSE signature.
+	PayloadType string `json:"payloadType"`
+
+	// Digest of the payload signed by the DSSE signature, which binds the
+	// signed attributes to the payload.
+	PayloadDigest string `json:"payloadDigest"`
+
+	// The "best by use" time for the artifact, as defined by the signer.
+	Expiry *time.Time `json:"io.cncf.notary.expiry,omitempty"`
+
+	// Specifies the Notary Project Signing Scheme used by the signature.
+	SigningScheme signature.SigningScheme `json:"io.cncf.notary.signingScheme"`
+
+	// The time at which the signature was generated. only valid when signing
+	// scheme is `notary.x509`.
+	SigningTime *time.Time `json:"io.cncf.notary.signingTime,omitempty"`
+
+	// The time at which the signature was generated. only valid when signing
+	// scheme is `notary.x509.signingAuthority`.
+	AuthenticSigningTime *time.Time `json:"io.cncf.notary.authenticSigningTime,omitempty"`
+}
+
+// preAuthEncode returns the pre-authentication encoding of the payload type
+// and the payload, which is the content to be signed.
+//
+// Reference: https://github.com/secure-systems-lab/dsse/blob/v1.0.0/protocol.md#signature-definition
+func preAuthEncode(payloadType string, payload []byte) []byte {
+	header := "DSSEv1 " + strconv.Itoa(len(payloadType)) + " " + payloadType + " " + strconv.Itoa(len(payload)) + " "
+	return append([]byte(header), payload...)
+}
+
+// payloadDigest returns the SHA-256 digest of the payload in the form of
+// `sha256:<hex>`.
+func payloadDigest(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// encodeSignedAttributes returns the JSON serialization of the signed
+// attributes of the sign request.
+func encodeSignedAttributes(req *signature.SignRequest) ([]byte, error) {
+	attrs := dsseSignedAttributes{
+		PayloadType:   req.Payload.ContentType,
+		PayloadDigest: payloadDigest(req.Payload.Content),
+		SigningScheme: req.SigningScheme,
+	}
+
+	// signingTime/authenticSigningTime
+	signingTime := req.SigningTime.UTC()
+	switch req.SigningScheme {
+	case signature.SigningSchemeX509:
+		attrs.SigningTime = &signingTime
+	case signature.SigningSchemeX509SigningAuthority:
+		attrs.AuthenticSigningTime = &signingTime
+	default:
+		return nil, errors.New("signing scheme: require notary.x509 or notary.x509.signingAuthority")
+	}
+
+	// expiry
+	if !req.Expiry.IsZero() {
+		expiry := req.Expiry.UTC()
+		attrs.Expiry = &expiry
+	}
+	return json.Marshal(attrs)
+}
+
+// decodeSignedAttributes parses the JSON serialization of the signed
+// attributes, which must be bound to the payload type and the payload.
+func decodeSignedAttributes(raw []byte, payloadType string, payload []byte) (*signature.SignedAttributes, error) {
+	var attrs dsseSignedAttributes
+	if err := json.Unmarshal(raw, &attrs); err != nil {
+		return nil, fmt.Errorf("invalid signed attributes: %w", err)
+	}
+	if attrs.PayloadType != payloadType {
+		return nil, fmt.Errorf("signed attributes are for payload type %q, but got %q", attrs.PayloadType, payloadType)
+	}
+	if attrs.PayloadDigest != payloadDigest(payload) {
+		return nil, errors.New("signed attributes are not for the payload")
+	}
+
+	// signingScheme and signingTime/authenticSigningTime
+	signedAttrs := signature.SignedAttributes{
+		SigningScheme: attrs.SigningScheme,
+	}
+	switch attrs.SigningScheme {
+	case signature.SigningSchemeX509:
+		if attrs.AuthenticSigningTime != nil {
+			return nil, fmt.Errorf("%q attribute must not be present for %s signing scheme", "io.cncf.notary.authenticSigningTime", attrs.SigningScheme)
+		}
+		if attrs.SigningTime == nil {
+			return nil, fmt.Errorf("%q attribute is missing", "io.cncf.notary.signingTime")
+		}
+		signedAttrs.SigningTime = *attrs.SigningTime
+	case signature.SigningSchemeX509SigningAuthority:
+		if attrs.SigningTime != nil {
+			return nil, fmt.Errorf("%q attribute must not be present for %s signing scheme", "io.cncf.notary.signingTime", attrs.SigningScheme)
+		}
+		if attrs.AuthenticSigningTime == nil {
+			return nil, fmt.Errorf("%q attribute is missing", "io.cncf.notary.authenticSigningTime")
+		}
+		signedAttrs.SigningTime = *attrs.AuthenticSigningTime
+	default:
+		return nil, fmt.Errorf("unsupported SigningScheme: `%v`", attrs.SigningScheme)
+	}
+
+	// expiry
+	if attrs.Expiry != nil {
+		signedAttrs.Expiry = *attrs.Expiry
+	}
+	return &signedAttrs, nil
+}
@@ -0,0 +1,124 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dsse
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+func TestPreAuthEncode(t *testing.T) {
+	// test vector from the DSSE protocol specification
+	got := preAuthEncode("http://example.com/HelloWorld", []byte("hello world"))
+	want := "DSSEv1 29 http://example.com/HelloWorld 11 hello world"
+	if string(got) != want {
+		t.Fatalf("preAuthEncode() = %q, want %q", got, want)
+	}
+}
+
+func TestEncodeSignedAttributes(t *testing.T) {
+	signingTime := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	req := &signature.SignRequest{
+		Payload: signature.Payload{
+			ContentType: "application/vnd.in-toto+json",
+			Content:     []byte("hello world"),
+		},
+		SigningTime:   signingTime,
+		SigningScheme: signature.SigningSchemeX509SigningAuthority,
+	}
+	raw, err := encodeSignedAttributes(req)
+	if err != nil {
+		t.Fatalf("encodeSignedAttributes() error = %v", err)
+	}
+	want := `{"payloadType":"application/vnd.in-toto+json","payloadDigest":"sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9","io.cncf.notary.signingScheme":"notary.x509.signingAuthority","io.cncf.notary.authenticSigningTime":"2023-01-02T03:04:05Z"}`
+	if string(raw) != want {
+		t.Fatalf("encodeSignedAttributes() = %s, want %s", raw, want)
+	}
+
+	signedAttrs, err := decodeSignedAttributes(raw, req.Payload.ContentType, req.Payload.Content)
+	if err != nil {
+		t.Fatalf("decodeSignedAttributes() error = %v", err)
+	}
+	if signedAttrs.SigningScheme != req.SigningScheme || !signedAttrs.SigningTime.Equal(signingTime) || !signedAttrs.Expiry.IsZero() {
+		t.Errorf("unexpected signed attributes %+v", signedAttrs)
+	}
+
+	req.SigningScheme = "unsupported"
+	if _, err := encodeSignedAttributes(req); err == nil {
+		t.Error("encodeSignedAttributes() expects error for unsupported signing scheme")
+	}
+}
+
+func TestDecodeSignedAttributes(t *testing.T) {
+	const (
+		payloadType = "application/vnd.in-toto+json"
+		digest      = "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	)
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{
+			name: "signing time",
+			raw:  `{"payloadType":"` + payloadType + `","payloadDigest":"` + digest + `","io.cncf.notary.signingScheme":"notary.x509","io.cncf.notary.signingTime":"2023-01-02T03:04:05Z","io.cncf.notary.expiry":"2023-01-03T03:04:05Z"}`,
+		},
+		{
+			name:    "malformed signed attributes",
+			raw:     `{`,
+			wantErr: "invalid signed attributes: unexpected end of JSON input",
+		},
+		{
+			name:    "mismatched payload type",
+			raw:     `{"payloadType":"application/json","payloadDigest":"` + digest + `"}`,
+			wantErr: `signed attributes are for payload type "application/json", but got "application/vnd.in-toto+json"`,
+		},
+		{
+			name:    "mismatched payload digest",
+			raw:     `{"payloadType":"` + payloadType + `","payloadDigest":"sha256:00"}`,
+			wantErr: "signed attributes are not for the payload",
+		},
+		{
+			name:    "unsupported signing scheme",
+			raw:     `{"payloadType":"` + payloadType + `","payloadDigest":"` + digest + `"}`,
+			wantErr: "unsupported SigningScheme: ``",
+		},
+		{
+			name:    "missing signing time",
+			raw:     `{"payloadType":"` + payloadType + `","payloadDigest":"` + digest + `","io.cncf.notary.signingScheme":"notary.x509"}`,
+			wantErr: `"io.cncf.notary.signingTime" attribute is missing`,
+		},
+		{
+			name:    "signing time for signing authority",
+			raw:     `{"payloadType":"` + payloadType + `","payloadDigest":"` + digest + `","io.cncf.notary.signingScheme":"notary.x509.signingAuthority","io.cncf.notary.signingTime":"2023-01-02T03:04:05Z","io.cncf.notary.authenticSigningTime":"2023-01-02T03:04:05Z"}`,
+			wantErr: `"io.cncf.notary.signingTime" attribute must not be present for notary.x509.signingAuthority signing scheme`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeSignedAttributes([]byte(tt.raw), payloadType, []byte("hello world"))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("decodeSignedAttributes() error = %v, wantErr %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeSignedAttributes() error = %v", err)
+			}
+		})
+	}
+}
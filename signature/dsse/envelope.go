@@ -0,0 +1,325 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dsse implements the signature.Envelope interface with the Dead
+// Simple Signing Envelope (DSSE), which is used by in-toto attestations.
+//
+// DSSE only signs the payload type and the payload, which are kept as is so
+// that any DSSE verifier is able to verify the signature. The notary signed
+// attributes are carried in the "io.cncf.notary.signedAttributes" extension
+// field of the signature object, and are signed with the same key over their
+// pre-authentication encoding with the type
+// "application/vnd.cncf.notary.dsse.signedattributes+json". The signed
+// attributes include the payload type and the SHA-256 digest of the payload,
+// which bind them to the DSSE signature. The certificate chain and the
+// unsigned attributes are carried in the signature object as well.
+//
+// Envelopes without the signed attributes, such as in-toto attestations
+// signed by other DSSE signers, carry no signing time and are therefore
+// rejected on verification.
+//
+// Reference: https://github.com/secure-systems-lab/dsse/blob/v1.0.0/envelope.md
+package dsse
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/internal/base"
+)
+
+// MediaTypeEnvelope defines the media type name of DSSE envelope.
+const MediaTypeEnvelope = "application/vnd.dsse.envelope.v1+json"
+
+func init() {
+	if err := signature.RegisterEnvelopeType(MediaTypeEnvelope, NewEnvelope, ParseEnvelope); err != nil {
+		panic(err)
+	}
+}
+
+// dsseEnvelope is the JSON serialization of DSSE envelope.
+type dsseEnvelope struct {
+	// Base64-encoded payload.
+	Payload string `json:"payload"`
+
+	// Type of the payload.
+	PayloadType string `json:"payloadType"`
+
+	// Signatures over the pre-authentication encoding of the payload.
+	Signatures []dsseSignature `json:"signatures"`
+}
+
+// dsseSignature is a signature in DSSE envelope.
+type dsseSignature struct {
+	// Optional, unauthenticated hint of the signing key.
+	KeyID string `json:"keyid,omitempty"`
+
+	// Base64-encoded signature.
+	Sig string `json:"sig"`
+
+	// List of X.509 Base64-DER-encoded certificates.
+	CertChain [][]byte `json:"x5c"`
+
+	// Notary signed attributes Base64-encoded.
+	SignedAttributes []byte `json:"io.cncf.notary.signedAttributes,omitempty"`
+
+	// Signature over the pre-authentication encoding of the signed
+	// attributes Base64-encoded.
+	SignedAttributesSig []byte `json:"io.cncf.notary.signedAttributesSig,omitempty"`
+
+	// RFC3161 time stamp token Base64-encoded.
+	TimestampSignature []byte `json:"io.cncf.notary.timestampSignature,omitempty"`
+
+	// SigningAgent used for signing.
+	SigningAgent string `json:"io.cncf.notary.signingAgent,omitempty"`
+}
+
+type envelope struct {
+	base *dsseEnvelope
+}
+
+// NewEnvelope generates a DSSE envelope.
+func NewEnvelope() signature.Envelope {
+	return &base.Envelope{
+		Envelope: &envelope{},
+	}
+}
+
+// ParseEnvelope parses the envelope bytes and return a DSSE envelope.
+func ParseEnvelope(envelopeBytes []byte) (signature.Envelope, error) {
+	var e dsseEnvelope
+	if err := json.Unmarshal(envelopeBytes, &e); err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return &base.Envelope{
+		Envelope: &envelope{base: &e},
+		Raw:      envelopeBytes,
+	}, nil
+}
+
+// Sign generates and sign the envelope according to the sign request.
+func (e *envelope) Sign(req *signature.SignRequest) ([]byte, error) {
+	if req.DetachedPayload {
+		return nil, &signature.InvalidSignRequestError{Msg: "detached payload is not supported by DSSE envelope"}
+	}
+	if len(req.ExtendedSignedAttributes) > 0 {
+		return nil, &signature.InvalidSignRequestError{Msg: "extended signed attributes are not supported by DSSE envelope"}
+	}
+
+	// local signers do not sign by themselves, and are backed by a signer
+	// signing the pre-authentication encoding as is
+	signer := req.Signer
+	if localSigner, ok := signer.(signature.LocalSigner); ok {
+		var err error
+		if signer, err = signature.NewSignerFromLocal(localSigner); err != nil {
+			return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+		}
+	}
+	keySpec, err := signer.KeySpec()
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// sign the payload type and the payload, and then the signed attributes
+	signedAttrs, err := encodeSignedAttributes(req)
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	sig, certs, err := signer.Sign(preAuthEncode(req.Payload.ContentType, req.Payload.Content))
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	signedAttrsSig, _, err := signer.Sign(preAuthEncode(signedAttributesType, signedAttrs))
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// generate envelope
+	rawCerts := make([][]byte, len(certs))
+	for i, cert := range certs {
+		rawCerts[i] = cert.Raw
+	}
+	env := &dsseEnvelope{
+		Payload:     base64.StdEncoding.EncodeToString(req.Payload.Content),
+		PayloadType: req.Payload.ContentType,
+		Signatures: []dsseSignature{{
+			Sig:                 base64.StdEncoding.EncodeToString(sig),
+			CertChain:           rawCerts,
+			SignedAttributes:    signedAttrs,
+			SignedAttributesSig: signedAttrsSig,
+			SigningAgent:        req.SigningAgent,
+		}},
+	}
+
+	// timestamp the signature of DSSE envelope
+	if req.Timestamper != nil {
+		token, err := req.Timestamper.Timestamp(sig, keySpec.SignatureAlgorithm().Hash())
+		if err != nil {
+			return nil, &signature.TimestampError{Err: err}
+		}
+		env.Signatures[0].TimestampSignature = token
+	}
+
+	encoded, err := json.Marshal(env)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	e.base = env
+	return encoded, nil
+}
+
+// SetTimestampSignature implements signature.TimestampSetter interface.
+// Only the signature object of the DSSE envelope is updated.
+func (e *envelope) SetTimestampSignature(token []byte) ([]byte, error) {
+	sig, err := e.signature()
+	if err != nil {
+		return nil, err
+	}
+
+	sig.TimestampSignature = token
+	encoded, err := json.Marshal(e.base)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return encoded, nil
+}
+
+// Verify verifies the envelope and returns its enclosed payload and signer info.
+func (e *envelope) Verify() (*signature.EnvelopeContent, error) {
+	sig, err := e.signature()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sig.CertChain) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "certificate chain is not present"}
+	}
+	cert, err := x509.ParseCertificate(sig.CertChain[0])
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: "malformed leaf certificate"}
+	}
+	keySpec, err := signature.ExtractKeySpec(cert)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+
+	// verify the signature over the pre-authentication encoding
+	payload, err := base64.StdEncoding.DecodeString(e.base.Payload)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: fmt.Sprintf("payload error: %v", err)}
+	}
+	sigBytes, err := base64.StdEncoding.DecodeString(sig.Sig)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	pae := preAuthEncode(e.base.PayloadType, payload)
+	if err := signature.VerifyRawSignature(keySpec.SignatureAlgorithm(), cert.PublicKey, pae, sigBytes); err != nil {
+		return nil, &signature.SignatureIntegrityError{Err: err}
+	}
+
+	// verify the signature over the signed attributes, if any
+	if len(sig.SignedAttributes) > 0 || len(sig.SignedAttributesSig) > 0 {
+		if len(sig.SignedAttributes) == 0 || len(sig.SignedAttributesSig) == 0 {
+			return nil, &signature.InvalidSignatureError{Msg: "signed attributes and their signature must be present together"}
+		}
+		pae := preAuthEncode(signedAttributesType, sig.SignedAttributes)
+		if err := signature.VerifyRawSignature(keySpec.SignatureAlgorithm(), cert.PublicKey, pae, sig.SignedAttributesSig); err != nil {
+			return nil, &signature.SignatureIntegrityError{Err: err}
+		}
+	}
+
+	return e.Content()
+}
+
+// Content returns the payload and signer information of the envelope.
+// Content is trusted only after the successful call to `Verify()`.
+func (e *envelope) Content() (*signature.EnvelopeContent, error) {
+	sig, err := e.signature()
+	if err != nil {
+		return nil, err
+	}
+
+	// extract payload and signed attributes
+	payload, err := base64.StdEncoding.DecodeString(e.base.Payload)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: fmt.Sprintf("payload error: %v", err)}
+	}
+	signedAttrs := &signature.SignedAttributes{
+		SigningScheme: signature.SigningSchemeX509,
+	}
+	if len(sig.SignedAttributes) > 0 {
+		signedAttrs, err = decodeSignedAttributes(sig.SignedAttributes, e.base.PayloadType, payload)
+		if err != nil {
+			return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+		}
+	}
+
+	// extract signer info
+	sigBytes, err := base64.StdEncoding.DecodeString(sig.Sig)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	if len(sigBytes) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "signature missing in DSSE envelope"}
+	}
+	var certs []*x509.Certificate
+	for _, certBytes := range sig.CertChain {
+		cert, err := x509.ParseCertificate(certBytes)
+		if err != nil {
+			return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "certificate chain is not present"}
+	}
+
+	// DSSE does not specify the signature algorithm, which is derived from
+	// the signing certificate.
+	keySpec, err := signature.ExtractKeySpec(certs[0])
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+
+	return &signature.EnvelopeContent{
+		SignerInfo: signature.SignerInfo{
+			SignedAttributes: *signedAttrs,
+			UnsignedAttributes: signature.UnsignedAttributes{
+				TimestampSignature: sig.TimestampSignature,
+				SigningAgent:       sig.SigningAgent,
+			},
+			SignatureAlgorithm: keySpec.SignatureAlgorithm(),
+			CertificateChain:   certs,
+			Signature:          sigBytes,
+		},
+		Payload: signature.Payload{
+			ContentType: e.base.PayloadType,
+			Content:     payload,
+		},
+	}, nil
+}
+
+// signature returns the only signature of the DSSE envelope.
+func (e *envelope) signature() (*dsseSignature, error) {
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+	if len(e.base.Signatures) != 1 {
+		return nil, &signature.InvalidSignatureError{
+			Msg: fmt.Sprintf("DSSE envelope must have exactly one signature, got %d", len(e.base.Signatures))}
+	}
+	return &e.base.Signatures[0], nil
+}
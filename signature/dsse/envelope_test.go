@@ -0,0 +1,387 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dsse
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/internal/signaturetest"
+)
+
+const payloadString = `{"predicateType":"https://slsa.dev/provenance/v1","subject":[{"name":"artifact","digest":{"sha256":"73c803930ea3ba1e54bc25c2bdc53edd0284c62ed651fe7b00369da519a3c333"}}]}`
+
+func newSignRequest(signingScheme signature.SigningScheme, keyType signature.KeyType, size int) (*signature.SignRequest, error) {
+	signer, err := signaturetest.GetTestLocalSigner(keyType, size)
+	if err != nil {
+		return nil, err
+	}
+	return &signature.SignRequest{
+		Payload: signature.Payload{
+			ContentType: "application/vnd.in-toto+json",
+			Content:     []byte(payloadString),
+		},
+		Signer:        signer,
+		SigningTime:   time.Now().Truncate(time.Second),
+		Expiry:        time.Now().AddDate(0, 0, 1).Truncate(time.Second),
+		SigningAgent:  "NotationUnitTest/1.0.0",
+		SigningScheme: signingScheme,
+	}, nil
+}
+
+func signEnvelope(t *testing.T) (*signature.SignRequest, []byte) {
+	t.Helper()
+	req, err := newSignRequest(signature.SigningSchemeX509, signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+	encoded, err := NewEnvelope().Sign(req)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	return req, encoded
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, signingScheme := range []signature.SigningScheme{signature.SigningSchemeX509, signature.SigningSchemeX509SigningAuthority} {
+		for _, keyType := range signaturetest.KeyTypes {
+			for _, size := range signaturetest.GetKeySizes(keyType) {
+				t.Run(fmt.Sprintf("with %s scheme, %v keyType, %v keySize", signingScheme, keyType, size), func(t *testing.T) {
+					req, err := newSignRequest(signingScheme, keyType, size)
+					if err != nil {
+						t.Fatalf("newSignRequest() failed. Error = %s", err)
+					}
+					encoded, err := NewEnvelope().Sign(req)
+					if err != nil {
+						t.Fatalf("Sign() failed. Error = %s", err)
+					}
+
+					env, err := signature.ParseEnvelope(MediaTypeEnvelope, encoded)
+					if err != nil {
+						t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+					}
+					content, err := env.Verify()
+					if err != nil {
+						t.Fatalf("Verify() failed. Error = %s", err)
+					}
+
+					var signed dsseEnvelope
+					if err := json.Unmarshal(encoded, &signed); err != nil {
+						t.Fatal(err)
+					}
+					if signed.PayloadType != req.Payload.ContentType {
+						t.Errorf("expected payload type %q, but got %q", req.Payload.ContentType, signed.PayloadType)
+					}
+					if content.Payload.ContentType != req.Payload.ContentType {
+						t.Errorf("expected content type %q, but got %q", req.Payload.ContentType, content.Payload.ContentType)
+					}
+					if !bytes.Equal(content.Payload.Content, req.Payload.Content) {
+						t.Errorf("expected payload %q, but got %q", req.Payload.Content, content.Payload.Content)
+					}
+					signedAttrs := content.SignerInfo.SignedAttributes
+					if signedAttrs.SigningScheme != signingScheme {
+						t.Errorf("expected signing scheme %v, but got %v", signingScheme, signedAttrs.SigningScheme)
+					}
+					if !signedAttrs.SigningTime.Equal(req.SigningTime) {
+						t.Errorf("expected signing time %v, but got %v", req.SigningTime, signedAttrs.SigningTime)
+					}
+					if !signedAttrs.Expiry.Equal(req.Expiry) {
+						t.Errorf("expected expiry %v, but got %v", req.Expiry, signedAttrs.Expiry)
+					}
+					if content.SignerInfo.UnsignedAttributes.SigningAgent != req.SigningAgent {
+						t.Errorf("expected signing agent %q, but got %q", req.SigningAgent, content.SignerInfo.UnsignedAttributes.SigningAgent)
+					}
+					keySpec, _ := req.Signer.KeySpec()
+					if alg := content.SignerInfo.SignatureAlgorithm; alg != keySpec.SignatureAlgorithm() {
+						t.Errorf("expected signature algorithm %v, but got %v", keySpec.SignatureAlgorithm(), alg)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestSignAndVerifyWithSigner(t *testing.T) {
+	signer, err := signaturetest.GetTestSigner(signature.KeyTypeEC, 384)
+	if err != nil {
+		t.Fatalf("GetTestSigner() failed. Error = %s", err)
+	}
+	req, err := newSignRequest(signature.SigningSchemeX509, signature.KeyTypeEC, 384)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+	req.Signer = signer
+	encoded, err := NewEnvelope().Sign(req)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	if _, err := env.Verify(); err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *signature.SignRequest)
+	}{
+		{
+			name: "detached payload",
+			modify: func(req *signature.SignRequest) {
+				req.DetachedPayload = true
+			},
+		},
+		{
+			name: "extended signed attributes",
+			modify: func(req *signature.SignRequest) {
+				req.ExtendedSignedAttributes = []signature.Attribute{{Key: "key", Value: "value"}}
+			},
+		},
+		{
+			name: "unsupported signing scheme",
+			modify: func(req *signature.SignRequest) {
+				req.SigningScheme = "unsupported"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := newSignRequest(signature.SigningSchemeX509, signature.KeyTypeRSA, 3072)
+			if err != nil {
+				t.Fatalf("newSignRequest() failed. Error = %s", err)
+			}
+			tt.modify(req)
+			_, err = (&envelope{}).Sign(req)
+			var reqErr *signature.InvalidSignRequestError
+			if !errors.As(err, &reqErr) {
+				t.Fatalf("Sign() expects InvalidSignRequestError, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	_, encoded := signEnvelope(t)
+	var signed dsseEnvelope
+	if err := json.Unmarshal(encoded, &signed); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(env *dsseEnvelope)
+		errAs  any
+	}{
+		{
+			name: "tampered payload",
+			modify: func(env *dsseEnvelope) {
+				env.Payload = base64.StdEncoding.EncodeToString([]byte(`{}`))
+			},
+			errAs: new(*signature.SignatureIntegrityError),
+		},
+		{
+			name: "tampered payload type",
+			modify: func(env *dsseEnvelope) {
+				env.PayloadType = "application/json"
+			},
+			errAs: new(*signature.SignatureIntegrityError),
+		},
+		{
+			name: "tampered signed attributes",
+			modify: func(env *dsseEnvelope) {
+				env.Signatures[0].SignedAttributes = bytes.Replace(env.Signatures[0].SignedAttributes, []byte("notary.x509"), []byte("notary.x509.signingAuthority"), 1)
+			},
+			errAs: new(*signature.SignatureIntegrityError),
+		},
+		{
+			name: "signed attributes without signature",
+			modify: func(env *dsseEnvelope) {
+				env.Signatures[0].SignedAttributesSig = nil
+			},
+			errAs: new(*signature.InvalidSignatureError),
+		},
+		{
+			name: "no signatures",
+			modify: func(env *dsseEnvelope) {
+				env.Signatures = nil
+			},
+			errAs: new(*signature.InvalidSignatureError),
+		},
+		{
+			name: "multiple signatures",
+			modify: func(env *dsseEnvelope) {
+				env.Signatures = append(env.Signatures, env.Signatures[0])
+			},
+			errAs: new(*signature.InvalidSignatureError),
+		},
+		{
+			name: "no certificate chain",
+			modify: func(env *dsseEnvelope) {
+				env.Signatures[0].CertChain = nil
+			},
+			errAs: new(*signature.InvalidSignatureError),
+		},
+		{
+			name: "malformed signature",
+			modify: func(env *dsseEnvelope) {
+				env.Signatures[0].Sig = "!"
+			},
+			errAs: new(*signature.InvalidSignatureError),
+		},
+		{
+			name: "malformed payload",
+			modify: func(env *dsseEnvelope) {
+				env.Payload = "!"
+			},
+			errAs: new(*signature.InvalidSignatureError),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := signed
+			env.Signatures = append([]dsseSignature{}, signed.Signatures...)
+			tt.modify(&env)
+			_, err := (&envelope{base: &env}).Verify()
+			switch errAs := tt.errAs.(type) {
+			case **signature.SignatureIntegrityError:
+				if !errors.As(err, errAs) {
+					t.Fatalf("Verify() expects SignatureIntegrityError, but got: %v", err)
+				}
+			case **signature.InvalidSignatureError:
+				if !errors.As(err, errAs) {
+					t.Fatalf("Verify() expects InvalidSignatureError, but got: %v", err)
+				}
+			}
+		})
+	}
+
+	t.Run("empty envelope", func(t *testing.T) {
+		_, err := (&envelope{}).Verify()
+		var notFoundErr *signature.SignatureEnvelopeNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Fatalf("Verify() expects SignatureEnvelopeNotFoundError, but got: %v", err)
+		}
+	})
+}
+
+func TestVerifyInTotoEnvelope(t *testing.T) {
+	// in-toto attestation signed by a DSSE signer without the notary signed
+	// attributes, which carries no signing time.
+	encoded, err := os.ReadFile(filepath.FromSlash("testdata/intoto.dsse.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	_, err = env.Verify()
+	var sigErr *signature.InvalidSignatureError
+	if !errors.As(err, &sigErr) || sigErr.Msg != "signing-time not present" {
+		t.Fatalf("Verify() expects InvalidSignatureError for missing signing time, but got: %v", err)
+	}
+}
+
+type mockTimestamper struct {
+	token     []byte
+	err       error
+	signature []byte
+	hash      crypto.Hash
+}
+
+func (m *mockTimestamper) Timestamp(signature []byte, hash crypto.Hash) ([]byte, error) {
+	m.signature = signature
+	m.hash = hash
+	return m.token, m.err
+}
+
+func TestSignWithTimestamper(t *testing.T) {
+	req, err := newSignRequest(signature.SigningSchemeX509, signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+
+	t.Run("timestamp signature", func(t *testing.T) {
+		timestamper := &mockTimestamper{token: []byte("timestamp token")}
+		req.Timestamper = timestamper
+		env := &envelope{}
+		if _, err := env.Sign(req); err != nil {
+			t.Fatalf("Sign() failed. Error = %s", err)
+		}
+		content, err := env.Content()
+		if err != nil {
+			t.Fatalf("Content() failed. Error = %s", err)
+		}
+		if !bytes.Equal(timestamper.signature, content.SignerInfo.Signature) {
+			t.Fatalf("expected timestamped signature %x, but got %x", content.SignerInfo.Signature, timestamper.signature)
+		}
+		if timestamper.hash != crypto.SHA384 {
+			t.Fatalf("expected hash %v, but got %v", crypto.SHA384, timestamper.hash)
+		}
+		if token := content.SignerInfo.UnsignedAttributes.TimestampSignature; !bytes.Equal(token, timestamper.token) {
+			t.Fatalf("expected timestamp token %q, but got %q", timestamper.token, token)
+		}
+	})
+
+	t.Run("timestamper error", func(t *testing.T) {
+		req.Timestamper = &mockTimestamper{err: errors.New("tsa error")}
+		_, err := (&envelope{}).Sign(req)
+		var timestampErr *signature.TimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("expected TimestampError, but got %v", err)
+		}
+	})
+}
+
+func TestSetTimestampSignature(t *testing.T) {
+	_, encoded := signEnvelope(t)
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	setter, ok := env.(signature.TimestampSetter)
+	if !ok {
+		t.Fatal("expected signature.TimestampSetter")
+	}
+	token := []byte("timestamp token")
+	timestamped, err := setter.SetTimestampSignature(token)
+	if err != nil {
+		t.Fatalf("SetTimestampSignature() failed. Error = %s", err)
+	}
+
+	env, err = ParseEnvelope(timestamped)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	content, err := env.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	if got := content.SignerInfo.UnsignedAttributes.TimestampSignature; !bytes.Equal(got, token) {
+		t.Fatalf("expected timestamp token %q, but got %q", token, got)
+	}
+}
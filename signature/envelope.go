@@ -24,6 +24,8 @@
 package signature
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"sync"
 )
@@ -42,6 +44,42 @@ type Envelope interface {
 	Content() (*EnvelopeContent, error)
 }
 
+// OptionsVerifier is implemented by envelopes supporting verification with
+// options.
+type OptionsVerifier interface {
+	// VerifyWithOptions verifies the envelope with the given options and
+	// returns its enclosed payload and signer info.
+	VerifyWithOptions(opts VerifyOptions) (*EnvelopeContent, error)
+}
+
+// TimestampSetter is implemented by envelopes supporting timestamp signatures
+// added after signing.
+type TimestampSetter interface {
+	// SetTimestampSignature sets the timestamp signature in the unsigned
+	// attributes of the signed envelope and returns the re-encoded envelope.
+	// The signed attributes and the signature are left unchanged.
+	SetTimestampSignature(token []byte) ([]byte, error)
+}
+
+// MultiSignatureEnvelope is implemented by envelopes holding multiple
+// signatures over the same payload, e.g. a build signature and a release
+// signature. Sign generates the envelope with the first signature. Verify
+// verifies all the signatures, and Verify and Content return the signer info
+// of the first signature.
+type MultiSignatureEnvelope interface {
+	Envelope
+
+	// AddSignature signs the payload of the signed envelope as requested by
+	// req, adds the signature to the envelope and returns the re-encoded
+	// envelope. req.Payload must be either empty or the same as the payload
+	// of the envelope. The existing signatures are left unchanged.
+	AddSignature(req *SignRequest) ([]byte, error)
+
+	// VerifyAll verifies all the signatures of the envelope and returns its
+	// enclosed payload and the signer info of each signature.
+	VerifyAll() (*MultiSignatureContent, error)
+}
+
 // NewEnvelopeFunc defines a function to create a new Envelope.
 type NewEnvelopeFunc func() Envelope
 
@@ -103,3 +141,107 @@ func ParseEnvelope(mediaType string, envelopeBytes []byte) (Envelope, error) {
 	}
 	return val.(envelopeFunc).parseFunc(envelopeBytes)
 }
+
+// Verify verifies the envelope with the given options. It falls back to the
+// Verify method of the envelope if the envelope does not implement
+// OptionsVerifier, in which case legacy algorithms and detached payloads are
+// not allowed.
+func Verify(env Envelope, opts VerifyOptions) (*EnvelopeContent, error) {
+	if verifier, ok := env.(OptionsVerifier); ok {
+		return verifier.VerifyWithOptions(opts)
+	}
+	if opts.DetachedPayload != nil {
+		return nil, &InvalidArgumentError{
+			Param: "opts.DetachedPayload",
+			Err:   errors.New("envelope does not support detached payload"),
+		}
+	}
+	content, err := env.Verify()
+	if err != nil {
+		return nil, err
+	}
+	if alg := content.SignerInfo.SignatureAlgorithm; alg.IsLegacy() {
+		return nil, &UnsupportedSignatureAlgoError{Alg: alg.String()}
+	}
+	return content, nil
+}
+
+// AddSignature adds a co-signature generated as requested by req to the
+// signed envelope with specified media type, and returns the re-encoded
+// envelope. The envelope must support multiple signatures.
+func AddSignature(mediaType string, envelopeBytes []byte, req *SignRequest) ([]byte, error) {
+	env, err := ParseEnvelope(mediaType, envelopeBytes)
+	if err != nil {
+		return nil, err
+	}
+	multiEnv, ok := env.(MultiSignatureEnvelope)
+	if !ok {
+		return nil, &UnsupportedSignatureFormatError{MediaType: mediaType}
+	}
+	return multiEnv.AddSignature(req)
+}
+
+// AddTimestamp adds the timestamp token to the unsigned attributes of the
+// signed envelope with specified media type, and returns the re-encoded
+// envelope. The message imprint of the token must match the signature of the
+// envelope. The signature of the envelope is left unchanged.
+func AddTimestamp(mediaType string, envelopeBytes, token []byte) ([]byte, error) {
+	env, err := ParseEnvelope(mediaType, envelopeBytes)
+	if err != nil {
+		return nil, err
+	}
+	setter, ok := env.(TimestampSetter)
+	if !ok {
+		return nil, &UnsupportedSignatureFormatError{MediaType: mediaType}
+	}
+	content, err := env.Content()
+	if err != nil {
+		return nil, err
+	}
+	sig := content.SignerInfo.Signature
+	if err := verifyTimestampImprint(token, sig); err != nil {
+		return nil, &InvalidTimestampError{Err: err}
+	}
+
+	timestamped, err := setter.SetTimestampSignature(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure that the signature is intact after re-encoding
+	env, err = ParseEnvelope(mediaType, timestamped)
+	if err != nil {
+		return nil, err
+	}
+	content, err = env.Content()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(content.SignerInfo.Signature, sig) {
+		return nil, &InvalidSignatureError{Msg: "signature is changed after adding the timestamp signature"}
+	}
+	return timestamped, nil
+}
+
+// TimestampEnvelope obtains a timestamp token over the signature of the signed
+// envelope with specified media type from the timestamper, and adds it to the
+// envelope by AddTimestamp.
+func TimestampEnvelope(mediaType string, envelopeBytes []byte, timestamper Timestamper) ([]byte, error) {
+	if timestamper == nil {
+		return nil, &InvalidArgumentError{Param: "timestamper"}
+	}
+	env, err := ParseEnvelope(mediaType, envelopeBytes)
+	if err != nil {
+		return nil, err
+	}
+	content, err := env.Content()
+	if err != nil {
+		return nil, err
+	}
+	signerInfo := content.SignerInfo
+	token, err := timestamper.Timestamp(signerInfo.Signature, signerInfo.SignatureAlgorithm.Hash())
+	if err != nil {
+		return nil, &TimestampError{Err: err}
+	}
+	return AddTimestamp(mediaType, envelopeBytes, token)
+}
@@ -14,9 +14,16 @@
 package signature
 
 import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"reflect"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/testhelper"
 )
 
 var (
@@ -208,3 +215,184 @@ func TestParseEnvelope(t *testing.T) {
 		})
 	}
 }
+
+const testTimestampMediaType = "application/vnd.test.timestamp"
+
+// mock an envelope that implements signature.TimestampSetter. Its encoding
+// is the JSON of the signature and the timestamp signature.
+type testTimestampEnvelope struct {
+	Signature          []byte
+	TimestampSignature []byte
+	ChangeSignature    bool
+}
+
+// Sign implements Sign of signature.Envelope.
+func (e *testTimestampEnvelope) Sign(req *SignRequest) ([]byte, error) {
+	return nil, nil
+}
+
+// Verify implements Verify of signature.Envelope.
+func (e *testTimestampEnvelope) Verify() (*EnvelopeContent, error) {
+	return e.Content()
+}
+
+// Content implements Content of signature.Envelope.
+func (e *testTimestampEnvelope) Content() (*EnvelopeContent, error) {
+	return &EnvelopeContent{
+		SignerInfo: SignerInfo{
+			Signature:          e.Signature,
+			SignatureAlgorithm: AlgorithmPS256,
+			UnsignedAttributes: UnsignedAttributes{
+				TimestampSignature: e.TimestampSignature,
+			},
+		},
+	}, nil
+}
+
+// SetTimestampSignature implements SetTimestampSignature of
+// signature.TimestampSetter.
+func (e *testTimestampEnvelope) SetTimestampSignature(token []byte) ([]byte, error) {
+	e.TimestampSignature = token
+	if e.ChangeSignature {
+		e.Signature = []byte("changed")
+	}
+	return json.Marshal(e)
+}
+
+// mock a timestamper that implements signature.Timestamper.
+type testTimestamper struct {
+	token func(signature []byte, hash crypto.Hash) ([]byte, error)
+}
+
+// Timestamp implements Timestamp of signature.Timestamper.
+func (ts *testTimestamper) Timestamp(signature []byte, hash crypto.Hash) ([]byte, error) {
+	return ts.token(signature, hash)
+}
+
+func TestAddTimestamp(t *testing.T) {
+	if err := RegisterEnvelopeType(testTimestampMediaType, func() Envelope {
+		return &testTimestampEnvelope{}
+	}, func(b []byte) (Envelope, error) {
+		var env testTimestampEnvelope
+		err := json.Unmarshal(b, &env)
+		return &env, err
+	}); err != nil {
+		t.Fatalf("RegisterEnvelopeType() error = %v", err)
+	}
+
+	sig := []byte("signature")
+	envelopeBytes, err := json.Marshal(testTimestampEnvelope{Signature: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := createTimestampToken(t, sig, crypto.SHA256)
+
+	t.Run("add timestamp", func(t *testing.T) {
+		timestamped, err := AddTimestamp(testTimestampMediaType, envelopeBytes, token)
+		if err != nil {
+			t.Fatalf("AddTimestamp() error = %v", err)
+		}
+		var env testTimestampEnvelope
+		if err := json.Unmarshal(timestamped, &env); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(env.Signature, sig) {
+			t.Errorf("expected signature %q, got %q", sig, env.Signature)
+		}
+		if !reflect.DeepEqual(env.TimestampSignature, token) {
+			t.Errorf("expected timestamp signature %x, got %x", token, env.TimestampSignature)
+		}
+	})
+
+	t.Run("timestamp envelope", func(t *testing.T) {
+		timestamper := &testTimestamper{
+			token: func(signature []byte, hash crypto.Hash) ([]byte, error) {
+				if hash != crypto.SHA256 {
+					t.Errorf("expected hash %v, got %v", crypto.SHA256, hash)
+				}
+				return createTimestampToken(t, signature, hash), nil
+			},
+		}
+		if _, err := TimestampEnvelope(testTimestampMediaType, envelopeBytes, timestamper); err != nil {
+			t.Fatalf("TimestampEnvelope() error = %v", err)
+		}
+	})
+
+	t.Run("message imprint mismatch", func(t *testing.T) {
+		_, err := AddTimestamp(testTimestampMediaType, envelopeBytes, createTimestampToken(t, []byte("other"), crypto.SHA256))
+		if _, ok := err.(*InvalidTimestampError); !ok {
+			t.Fatalf("expected InvalidTimestampError, got %v", err)
+		}
+	})
+
+	t.Run("signature changed", func(t *testing.T) {
+		envelopeBytes, err := json.Marshal(testTimestampEnvelope{Signature: sig, ChangeSignature: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = AddTimestamp(testTimestampMediaType, envelopeBytes, token)
+		if _, ok := err.(*InvalidSignatureError); !ok {
+			t.Fatalf("expected InvalidSignatureError, got %v", err)
+		}
+	})
+
+	t.Run("unsupported envelope", func(t *testing.T) {
+		if err := RegisterEnvelopeType(testMediaType, testNewFunc, testParseFunc); err != nil {
+			t.Fatalf("RegisterEnvelopeType() error = %v", err)
+		}
+		_, err := AddTimestamp(testMediaType, envelopeBytes, token)
+		if _, ok := err.(*UnsupportedSignatureFormatError); !ok {
+			t.Fatalf("expected UnsupportedSignatureFormatError, got %v", err)
+		}
+	})
+
+	t.Run("timestamper error", func(t *testing.T) {
+		timestamper := &testTimestamper{
+			token: func([]byte, crypto.Hash) ([]byte, error) {
+				return nil, errors.New("tsa error")
+			},
+		}
+		_, err := TimestampEnvelope(testTimestampMediaType, envelopeBytes, timestamper)
+		if _, ok := err.(*TimestampError); !ok {
+			t.Fatalf("expected TimestampError, got %v", err)
+		}
+	})
+}
+
+func createTimestampToken(t *testing.T, content []byte, hash crypto.Hash) []byte {
+	t.Helper()
+	root := testhelper.GetRSARootCertificate()
+	tsa := testhelper.GetRSATimeStampingCertTuple(root)
+	h := hash.New()
+	h.Write(content)
+	token, err := testhelper.CreateTimestampToken(testhelper.TimestampTokenOptions{
+		TSA:           tsa,
+		Certificates:  []*x509.Certificate{tsa.Cert, root.Cert},
+		Hash:          hash,
+		HashedMessage: h.Sum(nil),
+		GenTime:       time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyDetachedPayloadNotSupported(t *testing.T) {
+	_, err := Verify(testEnvelope{}, VerifyOptions{DetachedPayload: []byte("payload")})
+	var argErr *InvalidArgumentError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("Verify() expects InvalidArgumentError, got %v", err)
+	}
+}
+
+func TestAddSignatureNotSupported(t *testing.T) {
+	if err := RegisterEnvelopeType(testMediaType, testNewFunc, testParseFunc); err != nil {
+		t.Fatalf("RegisterEnvelopeType() error = %v", err)
+	}
+	_, err := AddSignature(testMediaType, []byte("envelope"), &SignRequest{})
+	var formatErr *UnsupportedSignatureFormatError
+	if !errors.As(err, &formatErr) {
+		t.Fatalf("AddSignature() expects UnsupportedSignatureFormatError, got %v", err)
+	}
+}
@@ -142,3 +142,39 @@ type DuplicateKeyError struct {
 func (e *DuplicateKeyError) Error() string {
 	return fmt.Sprintf("repeated key: %q exists.", e.Key)
 }
+
+// TimestampError is used when the signature cannot be timestamped.
+type TimestampError struct {
+	Err error
+}
+
+// Error returns the formatted error message.
+func (e *TimestampError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("failed to timestamp the signature: %s", e.Err.Error())
+	}
+	return "failed to timestamp the signature"
+}
+
+// Unwrap returns the unwrapped error.
+func (e *TimestampError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidTimestampError is used when the timestamp signature is invalid.
+type InvalidTimestampError struct {
+	Err error
+}
+
+// Error returns the formatted error message.
+func (e *InvalidTimestampError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("invalid timestamp signature: %s", e.Err.Error())
+	}
+	return "invalid timestamp signature"
+}
+
+// Unwrap returns the unwrapped error.
+func (e *InvalidTimestampError) Unwrap() error {
+	return e.Err
+}
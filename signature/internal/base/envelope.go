@@ -53,6 +53,9 @@ func (e *Envelope) Sign(req *signature.SignRequest) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if alg := content.SignerInfo.SignatureAlgorithm; alg.IsLegacy() {
+		return nil, &signature.UnsupportedSignatureAlgoError{Alg: alg.String()}
+	}
 
 	if err := validateCertificateChain(
 		content.SignerInfo.CertificateChain,
@@ -73,16 +76,28 @@ func (e *Envelope) Sign(req *signature.SignRequest) ([]byte, error) {
 //
 // Reference: https://github.com/notaryproject/notaryproject/blob/main/specs/trust-store-trust-policy.md#steps
 func (e *Envelope) Verify() (*signature.EnvelopeContent, error) {
+	return e.VerifyWithOptions(signature.VerifyOptions{})
+}
+
+// VerifyWithOptions performs the same validations as Verify with the given
+// options.
+// Signatures generated by legacy algorithms are rejected unless
+// opts.AllowLegacyAlgorithms is set and the internal envelope supports
+// verification with options.
+func (e *Envelope) VerifyWithOptions(opts signature.VerifyOptions) (*signature.EnvelopeContent, error) {
 	// validation before the core verify process.
 	if len(e.Raw) == 0 {
 		return nil, &signature.SignatureNotFoundError{}
 	}
 
 	// core verify process.
-	content, err := e.Envelope.Verify()
+	content, err := signature.Verify(e.Envelope, opts)
 	if err != nil {
 		return nil, err
 	}
+	if alg := content.SignerInfo.SignatureAlgorithm; alg.IsLegacy() && !opts.AllowLegacyAlgorithms {
+		return nil, &signature.UnsupportedSignatureAlgoError{Alg: alg.String()}
+	}
 
 	// validation after the core verify process.
 	if err = validateEnvelopeContent(content); err != nil {
@@ -110,6 +125,31 @@ func (e *Envelope) Content() (*signature.EnvelopeContent, error) {
 	return content, nil
 }
 
+// SetTimestampSignature sets the timestamp signature in the unsigned
+// attributes of the signed envelope and returns the re-encoded envelope.
+func (e *Envelope) SetTimestampSignature(token []byte) ([]byte, error) {
+	if len(e.Raw) == 0 {
+		return nil, &signature.SignatureNotFoundError{}
+	}
+	if len(token) == 0 {
+		return nil, &signature.InvalidArgumentError{
+			Param: "token",
+			Err:   errors.New("timestamp signature is empty"),
+		}
+	}
+	setter, ok := e.Envelope.(signature.TimestampSetter)
+	if !ok {
+		return nil, &signature.InvalidSignatureError{Msg: "envelope does not support timestamp signature"}
+	}
+
+	raw, err := setter.SetTimestampSignature(token)
+	if err != nil {
+		return nil, err
+	}
+	e.Raw = raw
+	return e.Raw, nil
+}
+
 // validateSignRequest performs basic set of validations on SignRequest struct.
 func validateSignRequest(req *signature.SignRequest) error {
 	if err := validatePayload(&req.Payload); err != nil {
@@ -140,10 +180,13 @@ func validateSigningSchema(schema signature.SigningScheme) error {
 }
 
 // validateEnvelopeContent validates the content which includes signerInfo and
-// payload.
+// payload. The content of a detached payload is not validated as it may not be
+// available.
 func validateEnvelopeContent(content *signature.EnvelopeContent) error {
-	if err := validatePayload(&content.Payload); err != nil {
-		return &signature.InvalidSignatureError{Msg: err.Error()}
+	if !content.Payload.Detached {
+		if err := validatePayload(&content.Payload); err != nil {
+			return &signature.InvalidSignatureError{Msg: err.Error()}
+		}
 	}
 	return validateSignerInfo(&content.SignerInfo)
 }
@@ -209,6 +252,20 @@ func validateCertificateChain(certChain []*x509.Certificate, signTime *time.Time
 		}
 	}
 
+	if expectedAlg.IsLegacy() {
+		// legacy algorithms are not derived from the key spec of the signing
+		// certificate but still require a supported RSA key.
+		keySpec, err := signature.ExtractKeySpec(certChain[0])
+		if err != nil {
+			return &signature.InvalidSignatureError{Msg: err.Error()}
+		}
+		if keySpec.Type != signature.KeyTypeRSA {
+			return &signature.InvalidSignatureError{
+				Msg: fmt.Sprintf("signing algorithm specified (%v) requires an RSA signing certificate", expectedAlg),
+			}
+		}
+		return nil
+	}
 	signingAlg, err := getSignatureAlgorithm(certChain[0])
 	if err != nil {
 		return &signature.InvalidSignatureError{Msg: err.Error()}
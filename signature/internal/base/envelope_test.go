@@ -139,6 +139,34 @@ func (e mockEnvelope) Content() (*signature.EnvelopeContent, error) {
 	return e.content, nil
 }
 
+// Mock an internal envelope that implements signature.OptionsVerifier.
+type mockOptionsEnvelope struct {
+	mockEnvelope
+}
+
+// VerifyWithOptions implements VerifyWithOptions of
+// signature.OptionsVerifier.
+func (e mockOptionsEnvelope) VerifyWithOptions(opts signature.VerifyOptions) (*signature.EnvelopeContent, error) {
+	if e.content.SignerInfo.SignatureAlgorithm.IsLegacy() && !opts.AllowLegacyAlgorithms {
+		return nil, errors.New(errMsg)
+	}
+	return e.Verify()
+}
+
+// Mock an internal envelope that implements signature.TimestampSetter.
+type mockTimestampEnvelope struct {
+	mockEnvelope
+}
+
+// SetTimestampSignature implements SetTimestampSignature of
+// signature.TimestampSetter.
+func (e mockTimestampEnvelope) SetTimestampSignature(token []byte) ([]byte, error) {
+	if string(token) == errMsg {
+		return nil, errors.New(errMsg)
+	}
+	return append(append([]byte{}, validBytes...), token...), nil
+}
+
 // Mock a signer implements signature.Signer.
 type mockSigner struct {
 	certs   []*x509.Certificate
@@ -337,6 +365,66 @@ func TestVerify(t *testing.T) {
 	}
 }
 
+func TestVerifyWithOptions(t *testing.T) {
+	legacySignerInfo := *validSignerInfo
+	legacySignerInfo.SignatureAlgorithm = signature.AlgorithmRS384
+	legacyContent := &signature.EnvelopeContent{
+		Payload:    *validPayload,
+		SignerInfo: legacySignerInfo,
+	}
+
+	tests := []struct {
+		name          string
+		env           *Envelope
+		opts          signature.VerifyOptions
+		expectContent *signature.EnvelopeContent
+		expectErr     bool
+	}{
+		{
+			name: "legacy algorithm not allowed",
+			env: &Envelope{
+				Raw:      validBytes,
+				Envelope: &mockOptionsEnvelope{mockEnvelope{content: legacyContent}},
+			},
+			expectContent: nil,
+			expectErr:     true,
+		},
+		{
+			name: "legacy algorithm not supported by internal envelope",
+			env: &Envelope{
+				Raw:      validBytes,
+				Envelope: &mockEnvelope{content: legacyContent},
+			},
+			opts:          signature.VerifyOptions{AllowLegacyAlgorithms: true},
+			expectContent: nil,
+			expectErr:     true,
+		},
+		{
+			name: "legacy algorithm allowed",
+			env: &Envelope{
+				Raw:      validBytes,
+				Envelope: &mockOptionsEnvelope{mockEnvelope{content: legacyContent}},
+			},
+			opts:          signature.VerifyOptions{AllowLegacyAlgorithms: true},
+			expectContent: legacyContent,
+			expectErr:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := tt.env.VerifyWithOptions(tt.opts)
+
+			if (err != nil) != tt.expectErr {
+				t.Errorf("error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(content, tt.expectContent) {
+				t.Errorf("expect content: %+v, got %+v", tt.expectContent, content)
+			}
+		})
+	}
+}
+
 func TestContent(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -372,6 +460,29 @@ func TestContent(t *testing.T) {
 			expect:    nil,
 			expectErr: true,
 		},
+		{
+			name: "detached payload",
+			env: &Envelope{
+				Raw: validBytes,
+				Envelope: &mockEnvelope{
+					content: &signature.EnvelopeContent{
+						Payload: signature.Payload{
+							ContentType: validContentType,
+							Detached:    true,
+						},
+						SignerInfo: *validSignerInfo,
+					},
+				},
+			},
+			expect: &signature.EnvelopeContent{
+				Payload: signature.Payload{
+					ContentType: validContentType,
+					Detached:    true,
+				},
+				SignerInfo: *validSignerInfo,
+			},
+			expectErr: false,
+		},
 		{
 			name: "valid payload and invalid signerInfo",
 			env: &Envelope{
@@ -416,6 +527,75 @@ func TestContent(t *testing.T) {
 	}
 }
 
+func TestSetTimestampSignature(t *testing.T) {
+	token := []byte("token")
+	tests := []struct {
+		name      string
+		env       *Envelope
+		token     []byte
+		expect    []byte
+		expectErr bool
+	}{
+		{
+			name:      "empty raw",
+			env:       &Envelope{Envelope: &mockTimestampEnvelope{}},
+			token:     token,
+			expectErr: true,
+		},
+		{
+			name: "empty token",
+			env: &Envelope{
+				Raw:      validBytes,
+				Envelope: &mockTimestampEnvelope{},
+			},
+			expectErr: true,
+		},
+		{
+			name: "unsupported internal envelope",
+			env: &Envelope{
+				Raw:      validBytes,
+				Envelope: &mockEnvelope{},
+			},
+			token:     token,
+			expectErr: true,
+		},
+		{
+			name: "err returned by internal envelope",
+			env: &Envelope{
+				Raw:      validBytes,
+				Envelope: &mockTimestampEnvelope{},
+			},
+			token:     []byte(errMsg),
+			expectErr: true,
+		},
+		{
+			name: "valid token",
+			env: &Envelope{
+				Raw:      validBytes,
+				Envelope: &mockTimestampEnvelope{},
+			},
+			token:  token,
+			expect: append(append([]byte{}, validBytes...), token...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := tt.env.SetTimestampSignature(tt.token)
+
+			if (err != nil) != tt.expectErr {
+				t.Errorf("error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(raw, tt.expect) {
+				t.Errorf("expect %+v, got %+v", tt.expect, raw)
+			}
+			if !tt.expectErr && !reflect.DeepEqual(tt.env.Raw, tt.expect) {
+				t.Errorf("expect raw %+v, got %+v", tt.expect, tt.env.Raw)
+			}
+		})
+	}
+}
+
 func TestValidateSignRequest(t *testing.T) {
 	tests := []struct {
 		name      string
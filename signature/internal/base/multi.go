@@ -0,0 +1,178 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// MultiEnvelope represents a general envelope holding multiple signatures
+// over the same payload in specific format.
+// MultiEnvelope manipulates the common validation shared by internal
+// envelopes, which must implement signature.MultiSignatureEnvelope and
+// Clone() signature.MultiSignatureEnvelope.
+type MultiEnvelope struct {
+	Envelope
+}
+
+// cloner is implemented by internal envelopes holding multiple signatures.
+// Clone returns a copy of the envelope, which can be modified by
+// AddSignature without affecting the original one.
+type cloner interface {
+	Clone() signature.MultiSignatureEnvelope
+}
+
+// Verify performs integrity and other signature specification related
+// validations on all the signatures.
+// It returns envelope content containing the payload to be signed and
+// SignerInfo object containing the information about the first signature.
+func (e *MultiEnvelope) Verify() (*signature.EnvelopeContent, error) {
+	content, err := e.VerifyAll()
+	if err != nil {
+		return nil, err
+	}
+	return &signature.EnvelopeContent{
+		SignerInfo: content.SignerInfos[0],
+		Payload:    content.Payload,
+	}, nil
+}
+
+// VerifyWithOptions performs the same validations as Verify.
+// Legacy algorithms and detached payloads are not supported by envelopes
+// holding multiple signatures.
+func (e *MultiEnvelope) VerifyWithOptions(opts signature.VerifyOptions) (*signature.EnvelopeContent, error) {
+	if opts.DetachedPayload != nil {
+		return nil, &signature.InvalidArgumentError{
+			Param: "opts.DetachedPayload",
+			Err:   errors.New("envelope does not support detached payload"),
+		}
+	}
+	return e.Verify()
+}
+
+// VerifyAll performs integrity and other signature specification related
+// validations on all the signatures.
+// It returns the payload to be signed and a SignerInfo object for each
+// signature.
+func (e *MultiEnvelope) VerifyAll() (*signature.MultiSignatureContent, error) {
+	// validation before the core verify process.
+	if len(e.Raw) == 0 {
+		return nil, &signature.SignatureNotFoundError{}
+	}
+	multiEnv, err := e.multiSignatureEnvelope()
+	if err != nil {
+		return nil, err
+	}
+
+	// core verify process.
+	content, err := multiEnv.VerifyAll()
+	if err != nil {
+		return nil, err
+	}
+
+	// validation after the core verify process.
+	if len(content.SignerInfos) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "signature not present"}
+	}
+	if err := validatePayload(&content.Payload); err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	for i := range content.SignerInfos {
+		if err := validateSignerInfo(&content.SignerInfos[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return content, nil
+}
+
+// AddSignature signs the payload of the signed envelope in terms of given
+// SignRequest and adds the signature to the envelope. If req.Payload is
+// empty, the payload of the envelope is signed.
+// The signature is added to a copy of the internal envelope, and the envelope
+// is only updated once the new signature is validated. req is not modified.
+func (e *MultiEnvelope) AddSignature(req *signature.SignRequest) ([]byte, error) {
+	if len(e.Raw) == 0 {
+		return nil, &signature.SignatureNotFoundError{}
+	}
+	multiEnv, err := e.multiSignatureEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := multiEnv.(cloner)
+	if !ok {
+		return nil, &signature.InvalidSignatureError{Msg: "envelope does not support adding signatures"}
+	}
+
+	// the new signature must be over the payload of the envelope.
+	content, err := e.Content()
+	if err != nil {
+		return nil, err
+	}
+	signReq := *req
+	if len(signReq.Payload.Content) == 0 {
+		signReq.Payload = content.Payload
+	} else if !bytes.Equal(signReq.Payload.Content, content.Payload.Content) ||
+		signReq.Payload.ContentType != content.Payload.ContentType {
+		return nil, &signature.InvalidSignRequestError{Msg: "payload does not match the payload of the envelope"}
+	}
+
+	// Canonicalize request.
+	signReq.SigningTime = signReq.SigningTime.Truncate(time.Second)
+	signReq.Expiry = signReq.Expiry.Truncate(time.Second)
+	if err := validateSignRequest(&signReq); err != nil {
+		return nil, err
+	}
+
+	newEnv := c.Clone()
+	raw, err := newEnv.AddSignature(&signReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// validate certificate chain of the new signature.
+	all, err := newEnv.VerifyAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(all.SignerInfos) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "signature not present"}
+	}
+	signerInfo := all.SignerInfos[len(all.SignerInfos)-1]
+	if err := validateCertificateChain(
+		signerInfo.CertificateChain,
+		&signerInfo.SignedAttributes.SigningTime,
+		signerInfo.SignatureAlgorithm,
+	); err != nil {
+		return nil, err
+	}
+
+	e.Envelope.Envelope = newEnv
+	e.Raw = raw
+	return e.Raw, nil
+}
+
+// multiSignatureEnvelope returns the internal envelope as a
+// signature.MultiSignatureEnvelope.
+func (e *MultiEnvelope) multiSignatureEnvelope() (signature.MultiSignatureEnvelope, error) {
+	multiEnv, ok := e.Envelope.Envelope.(signature.MultiSignatureEnvelope)
+	if !ok {
+		return nil, &signature.InvalidSignatureError{Msg: "envelope does not support multiple signatures"}
+	}
+	return multiEnv, nil
+}
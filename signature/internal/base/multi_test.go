@@ -0,0 +1,241 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// Mock an internal envelope that implements
+// signature.MultiSignatureEnvelope.
+type mockMultiEnvelope struct {
+	mockEnvelope
+	signerInfos []signature.SignerInfo
+	req         *signature.SignRequest
+}
+
+// AddSignature implements AddSignature of
+// signature.MultiSignatureEnvelope.
+func (e *mockMultiEnvelope) AddSignature(req *signature.SignRequest) ([]byte, error) {
+	if req.SigningAgent == invalidSigningAgent {
+		return nil, errors.New(errMsg)
+	}
+	e.req = req
+	e.signerInfos = append(e.signerInfos, *validSignerInfo)
+	return validBytes, nil
+}
+
+// Clone returns a copy of the mock envelope.
+func (e *mockMultiEnvelope) Clone() signature.MultiSignatureEnvelope {
+	c := *e
+	c.signerInfos = append([]signature.SignerInfo(nil), e.signerInfos...)
+	return &c
+}
+
+// VerifyAll implements VerifyAll of signature.MultiSignatureEnvelope.
+func (e *mockMultiEnvelope) VerifyAll() (*signature.MultiSignatureContent, error) {
+	if e.failVerify {
+		return nil, errors.New(errMsg)
+	}
+	return &signature.MultiSignatureContent{
+		SignerInfos: e.signerInfos,
+		Payload:     *validPayload,
+	}, nil
+}
+
+func newMockMultiEnvelope(signerInfos ...signature.SignerInfo) *mockMultiEnvelope {
+	return &mockMultiEnvelope{
+		mockEnvelope: mockEnvelope{content: validEnvelopeContent},
+		signerInfos:  signerInfos,
+	}
+}
+
+func TestMultiEnvelopeVerifyAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       *MultiEnvelope
+		expect    *signature.MultiSignatureContent
+		expectErr bool
+	}{
+		{
+			name: "empty raw",
+			env: &MultiEnvelope{
+				Envelope: Envelope{Envelope: newMockMultiEnvelope(*validSignerInfo)},
+			},
+			expectErr: true,
+		},
+		{
+			name: "unsupported internal envelope",
+			env: &MultiEnvelope{
+				Envelope: Envelope{Envelope: &mockEnvelope{}, Raw: validBytes},
+			},
+			expectErr: true,
+		},
+		{
+			name: "no signatures",
+			env: &MultiEnvelope{
+				Envelope: Envelope{Envelope: newMockMultiEnvelope(), Raw: validBytes},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid signer info",
+			env: &MultiEnvelope{
+				Envelope: Envelope{
+					Envelope: newMockMultiEnvelope(*validSignerInfo, signature.SignerInfo{}),
+					Raw:      validBytes,
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "valid signatures",
+			env: &MultiEnvelope{
+				Envelope: Envelope{
+					Envelope: newMockMultiEnvelope(*validSignerInfo, *validSignerInfo),
+					Raw:      validBytes,
+				},
+			},
+			expect: &signature.MultiSignatureContent{
+				SignerInfos: []signature.SignerInfo{*validSignerInfo, *validSignerInfo},
+				Payload:     *validPayload,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := tt.env.VerifyAll()
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("VerifyAll() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(content, tt.expect) {
+				t.Errorf("expect %+v, got %+v", tt.expect, content)
+			}
+		})
+	}
+}
+
+func TestMultiEnvelopeVerify(t *testing.T) {
+	env := &MultiEnvelope{
+		Envelope: Envelope{
+			Envelope: newMockMultiEnvelope(*validSignerInfo),
+			Raw:      validBytes,
+		},
+	}
+	content, err := env.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !reflect.DeepEqual(content, validEnvelopeContent) {
+		t.Errorf("expect %+v, got %+v", validEnvelopeContent, content)
+	}
+
+	_, err = env.VerifyWithOptions(signature.VerifyOptions{DetachedPayload: validBytes})
+	var argErr *signature.InvalidArgumentError
+	if !errors.As(err, &argErr) {
+		t.Errorf("VerifyWithOptions() expects InvalidArgumentError, got %v", err)
+	}
+}
+
+func TestMultiEnvelopeAddSignature(t *testing.T) {
+	t.Run("empty raw", func(t *testing.T) {
+		env := &MultiEnvelope{
+			Envelope: Envelope{Envelope: newMockMultiEnvelope(*validSignerInfo)},
+		}
+		_, err := env.AddSignature(validReq)
+		var notFoundErr *signature.SignatureNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Fatalf("AddSignature() expects SignatureNotFoundError, got %v", err)
+		}
+	})
+
+	t.Run("payload of the envelope", func(t *testing.T) {
+		internal := newMockMultiEnvelope(*validSignerInfo)
+		env := &MultiEnvelope{
+			Envelope: Envelope{Envelope: internal, Raw: []byte("raw")},
+		}
+		req := *validReq
+		req.Payload = signature.Payload{}
+		raw, err := env.AddSignature(&req)
+		if err != nil {
+			t.Fatalf("AddSignature() error = %v", err)
+		}
+		if !reflect.DeepEqual(raw, validBytes) || !reflect.DeepEqual(env.Raw, validBytes) {
+			t.Errorf("expect raw %q, got %q", validBytes, raw)
+		}
+		added := env.Envelope.Envelope.(*mockMultiEnvelope)
+		if !reflect.DeepEqual(added.req.Payload, *validPayload) {
+			t.Errorf("expect payload %+v, got %+v", *validPayload, added.req.Payload)
+		}
+		if len(req.Payload.Content) != 0 {
+			t.Errorf("expect request payload to be unchanged, got %+v", req.Payload)
+		}
+		if len(internal.signerInfos) != 1 || len(added.signerInfos) != 2 {
+			t.Errorf("expect the signature to be added to a copy of the envelope")
+		}
+	})
+
+	t.Run("mismatched payload", func(t *testing.T) {
+		env := &MultiEnvelope{
+			Envelope: Envelope{Envelope: newMockMultiEnvelope(*validSignerInfo), Raw: validBytes},
+		}
+		req := *validReq
+		req.Payload.ContentType = invalidContentType
+		_, err := env.AddSignature(&req)
+		var reqErr *signature.InvalidSignRequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("AddSignature() expects InvalidSignRequestError, got %v", err)
+		}
+	})
+
+	t.Run("internal error", func(t *testing.T) {
+		env := &MultiEnvelope{
+			Envelope: Envelope{Envelope: newMockMultiEnvelope(*validSignerInfo), Raw: validBytes},
+		}
+		req := *validReq
+		req.SigningAgent = invalidSigningAgent
+		if _, err := env.AddSignature(&req); err == nil {
+			t.Fatal("AddSignature() expects error")
+		}
+	})
+
+	t.Run("envelope unchanged on failure", func(t *testing.T) {
+		internal := newMockMultiEnvelope(*validSignerInfo)
+		internal.failVerify = true
+		env := &MultiEnvelope{
+			Envelope: Envelope{Envelope: internal, Raw: []byte("raw")},
+		}
+		req := *validReq
+		req.Payload = signature.Payload{}
+		req.SigningTime = validReq.SigningTime.Add(time.Millisecond)
+		if _, err := env.AddSignature(&req); err == nil {
+			t.Fatal("AddSignature() expects error")
+		}
+		if env.Envelope.Envelope != internal || string(env.Raw) != "raw" {
+			t.Errorf("expect envelope to be unchanged")
+		}
+		if len(internal.signerInfos) != 1 || internal.req != nil {
+			t.Errorf("expect internal envelope to be unchanged, got %+v", internal.signerInfos)
+		}
+		if len(req.Payload.Content) != 0 || !req.SigningTime.Equal(validReq.SigningTime.Add(time.Millisecond)) {
+			t.Errorf("expect request to be unchanged, got %+v", req)
+		}
+	})
+}
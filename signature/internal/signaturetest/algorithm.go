@@ -18,7 +18,7 @@ package signaturetest
 import "github.com/notaryproject/notation-core-go/signature"
 
 // KeyTypes contains supported key type
-var KeyTypes = []signature.KeyType{signature.KeyTypeRSA, signature.KeyTypeEC}
+var KeyTypes = []signature.KeyType{signature.KeyTypeRSA, signature.KeyTypeEC, signature.KeyTypeEd25519}
 
 // GetKeySizes returns the supported key size for the named keyType
 func GetKeySizes(keyType signature.KeyType) []int {
@@ -27,6 +27,8 @@ func GetKeySizes(keyType signature.KeyType) []int {
 		return []int{2048, 3072, 4096}
 	case signature.KeyTypeEC:
 		return []int{256, 384, 521}
+	case signature.KeyTypeEd25519:
+		return []int{256}
 	default:
 		return nil
 	}
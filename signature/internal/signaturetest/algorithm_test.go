@@ -33,6 +33,7 @@ func TestGetKeySizes(t *testing.T) {
 	}{
 		{name: "RSA", args: args{keyType: signature.KeyTypeRSA}, want: []int{2048, 3072, 4096}},
 		{name: "EC", args: args{keyType: signature.KeyTypeEC}, want: []int{256, 384, 521}},
+		{name: "Ed25519", args: args{keyType: signature.KeyTypeEd25519}, want: []int{256}},
 		{name: "others", args: args{keyType: -1}, want: nil},
 	}
 	for _, tt := range tests {
@@ -14,6 +14,7 @@
 package signaturetest
 
 import (
+	"crypto"
 	"crypto/elliptic"
 	"crypto/x509"
 	"fmt"
@@ -59,7 +60,33 @@ func GetTestLocalSigner(keyType signature.KeyType, size int) (signature.Signer,
 		default:
 			return nil, fmt.Errorf("key size not supported")
 		}
+	case signature.KeyTypeEd25519:
+		if size != 256 {
+			return nil, fmt.Errorf("key size not supported")
+		}
+		leafCertTuple := testhelper.GetEd25519CertTuple()
+		certs := []*x509.Certificate{leafCertTuple.Cert, testhelper.GetRSARootCertificate().Cert}
+		return signature.NewLocalSigner(certs, leafCertTuple.PrivateKey)
 	default:
 		return nil, fmt.Errorf("keyType not supported")
 	}
 }
+
+// GetTestSigner returns the crypto.Signer backed signer with given keyType and
+// size for testing
+func GetTestSigner(keyType signature.KeyType, size int) (signature.Signer, error) {
+	s, err := GetTestLocalSigner(keyType, size)
+	if err != nil {
+		return nil, err
+	}
+	localSigner := s.(signature.LocalSigner)
+	certs, err := localSigner.CertificateChain()
+	if err != nil {
+		return nil, err
+	}
+	key, ok := localSigner.PrivateKey().(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("private key is not a crypto.Signer")
+	}
+	return signature.NewSigner(certs, key)
+}
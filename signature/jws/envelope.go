@@ -17,6 +17,7 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -80,7 +81,13 @@ func (e *envelope) Sign(req *signature.SignRequest) ([]byte, error) {
 	}
 
 	// JWT sign and get certificate chain
-	compact, certs, err := sign(payload, signedAttrs, method)
+	var compact string
+	var certs []*x509.Certificate
+	if req.DetachedPayload {
+		compact, certs, err = signContent(jwt.EncodeSegment(req.Payload.Content), signedAttrs, method)
+	} else {
+		compact, certs, err = sign(payload, signedAttrs, method)
+	}
 	if err != nil {
 		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
 	}
@@ -91,6 +98,22 @@ func (e *envelope) Sign(req *signature.SignRequest) ([]byte, error) {
 		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
 	}
 
+	if req.DetachedPayload {
+		// an empty payload indicates detached content
+		//
+		// Reference: https://www.rfc-editor.org/rfc/rfc7515#appendix-F
+		env.Payload = ""
+	}
+
+	// timestamp the signature of JWS envelope
+	if req.Timestamper != nil {
+		token, err := timestampSignature(req.Timestamper, method.Alg(), env.Signature)
+		if err != nil {
+			return nil, err
+		}
+		env.Header.TimestampSignature = token
+	}
+
 	encoded, err := json.Marshal(env)
 	if err != nil {
 		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
@@ -99,8 +122,28 @@ func (e *envelope) Sign(req *signature.SignRequest) ([]byte, error) {
 	return encoded, nil
 }
 
+// SetTimestampSignature implements signature.TimestampSetter interface.
+// Only the unprotected header of the JWS envelope is updated.
+func (e *envelope) SetTimestampSignature(token []byte) ([]byte, error) {
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+
+	e.base.Header.TimestampSignature = token
+	encoded, err := json.Marshal(e.base)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return encoded, nil
+}
+
 // Verify verifies the envelope and returns its enclosed payload and signer info.
 func (e *envelope) Verify() (*signature.EnvelopeContent, error) {
+	return e.VerifyWithOptions(signature.VerifyOptions{})
+}
+
+// VerifyWithOptions implements signature.OptionsVerifier interface.
+func (e *envelope) VerifyWithOptions(opts signature.VerifyOptions) (*signature.EnvelopeContent, error) {
 	if e.base == nil {
 		return nil, &signature.SignatureEnvelopeNotFoundError{}
 	}
@@ -114,13 +157,48 @@ func (e *envelope) Verify() (*signature.EnvelopeContent, error) {
 		return nil, &signature.InvalidSignatureError{Msg: "malformed leaf certificate"}
 	}
 
+	// legacy algorithms are only verified if allowed. Malformed protected
+	// headers are reported by verifyJWT.
+	if !opts.AllowLegacyAlgorithms {
+		if protected, err := parseProtectedHeaders(e.base.Protected); err == nil {
+			if _, ok := legacyJWSAlgSignatureAlgMap[protected.Algorithm]; ok {
+				return nil, &signature.UnsupportedSignatureAlgoError{Alg: protected.Algorithm}
+			}
+		}
+	}
+
 	// verify JWT
-	compact := compactJWS(e.base)
-	if err = verifyJWT(compact, cert.PublicKey); err != nil {
+	env := e.base
+	detached := e.base.Payload == ""
+	if detached {
+		if len(opts.DetachedPayload) == 0 {
+			return nil, &signature.InvalidArgumentError{
+				Param: "opts.DetachedPayload",
+				Err:   errors.New("payload is detached from JWS envelope but not provided"),
+			}
+		}
+		detachedEnv := *e.base
+		detachedEnv.Payload = base64.RawURLEncoding.EncodeToString(opts.DetachedPayload)
+		env = &detachedEnv
+	} else if opts.DetachedPayload != nil {
+		return nil, &signature.InvalidArgumentError{
+			Param: "opts.DetachedPayload",
+			Err:   errors.New("payload is embedded in JWS envelope"),
+		}
+	}
+	compact := compactJWS(env)
+	if err = verifyJWT(compact, cert.PublicKey, opts.AllowLegacyAlgorithms); err != nil {
 		return nil, err
 	}
 
-	return e.Content()
+	content, err := e.Content()
+	if err != nil {
+		return nil, err
+	}
+	if detached {
+		content.Payload.Content = opts.DetachedPayload
+	}
+	return content, nil
 }
 
 // Content returns the payload and signer information of the envelope.
@@ -164,11 +242,18 @@ func (e *envelope) payload(protected *jwsProtectedHeader) (*signature.Payload, e
 	return &signature.Payload{
 		Content:     payload,
 		ContentType: protected.ContentType,
+		Detached:    e.base.Payload == "",
 	}, nil
 }
 
 // signerInfo returns the SignerInfo of JWS envelope.
 func (e *envelope) signerInfo(protected *jwsProtectedHeader) (*signature.SignerInfo, error) {
+	return parseSignerInfo(protected, &e.base.Header, e.base.Signature)
+}
+
+// parseSignerInfo returns the SignerInfo of a JWS signature with the given
+// headers and Base64URL-encoded signature.
+func parseSignerInfo(protected *jwsProtectedHeader, header *jwsUnprotectedHeader, encodedSig string) (*signature.SignerInfo, error) {
 	var signerInfo signature.SignerInfo
 
 	// populate protected header to signerInfo
@@ -177,7 +262,7 @@ func (e *envelope) signerInfo(protected *jwsProtectedHeader) (*signature.SignerI
 	}
 
 	// parse signature
-	sig, err := base64.RawURLEncoding.DecodeString(e.base.Signature)
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
 	if err != nil {
 		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
 	}
@@ -188,7 +273,7 @@ func (e *envelope) signerInfo(protected *jwsProtectedHeader) (*signature.SignerI
 
 	// parse headers
 	var certs []*x509.Certificate
-	for _, certBytes := range e.base.Header.CertChain {
+	for _, certBytes := range header.CertChain {
 		cert, err := x509.ParseCertificate(certBytes)
 		if err != nil {
 			return nil, &signature.InvalidSignatureError{Msg: err.Error()}
@@ -196,8 +281,8 @@ func (e *envelope) signerInfo(protected *jwsProtectedHeader) (*signature.SignerI
 		certs = append(certs, cert)
 	}
 	signerInfo.CertificateChain = certs
-	signerInfo.UnsignedAttributes.SigningAgent = e.base.Header.SigningAgent
-	signerInfo.UnsignedAttributes.TimestampSignature = e.base.Header.TimestampSignature
+	signerInfo.UnsignedAttributes.SigningAgent = header.SigningAgent
+	signerInfo.UnsignedAttributes.TimestampSignature = header.TimestampSignature
 	return &signerInfo, nil
 }
 
@@ -220,3 +305,25 @@ func sign(payload jwt.MapClaims, headers map[string]interface{}, method signingM
 	}
 	return compact, certs, nil
 }
+
+// signContent signs the Base64URL-encoded content as is instead of the
+// re-encoded claims, so that the content can be verified without re-encoding
+// when it is detached or shared by multiple signatures.
+func signContent(encodedContent string, headers map[string]interface{}, method signingMethod) (string, []*x509.Certificate, error) {
+	rawHeader, err := json.Marshal(headers)
+	if err != nil {
+		return "", nil, err
+	}
+	signingString := jwt.EncodeSegment(rawHeader) + "." + encodedContent
+	sig, err := method.Sign(signingString, method.PrivateKey())
+	if err != nil {
+		return "", nil, err
+	}
+
+	// access certificate chain after sign
+	certs, err := method.CertificateChain()
+	if err != nil {
+		return "", nil, err
+	}
+	return signingString + "." + sig, certs, nil
+}
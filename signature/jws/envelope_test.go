@@ -251,7 +251,7 @@ func TestSignFailed(t *testing.T) {
 
 		e := envelope{}
 		_, err = e.Sign(signReq)
-		checkErrorEqual(t, `signature algorithm "#0" is not supported`, err.Error())
+		checkErrorEqual(t, `signature algorithm "Algorithm(0)" is not supported`, err.Error())
 	})
 }
 
@@ -302,6 +302,27 @@ func TestSignVerify(t *testing.T) {
 	}
 }
 
+func TestSignVerifyWithSigner(t *testing.T) {
+	for _, keyType := range signaturetest.KeyTypes {
+		for _, size := range signaturetest.GetKeySizes(keyType) {
+			t.Run(fmt.Sprintf("%v %d", keyType, size), func(t *testing.T) {
+				signer, err := signaturetest.GetTestSigner(keyType, size)
+				checkNoError(t, err)
+
+				signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+				checkNoError(t, err)
+
+				e := envelope{}
+				encoded, err := e.Sign(signReq)
+				checkNoError(t, err)
+
+				_, err = verifyCore(encoded)
+				checkNoError(t, err)
+			})
+		}
+	}
+}
+
 func TestVerify(t *testing.T) {
 	t.Run("break json format", func(t *testing.T) {
 		encoded, err := getEncodedMessage(signature.SigningSchemeX509, true, extSignedAttr)
@@ -601,3 +622,223 @@ func TestEmptyEnvelope(t *testing.T) {
 		}
 	})
 }
+
+func TestSignWithTimestamper(t *testing.T) {
+	signer, err := getSigner(true, nil, nil)
+	checkNoError(t, err)
+	signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+	checkNoError(t, err)
+
+	t.Run("timestamp signature", func(t *testing.T) {
+		timestamper := &mockTimestamper{token: []byte("timestamp token")}
+		signReq.Timestamper = timestamper
+		encoded, err := (&envelope{}).Sign(signReq)
+		checkNoError(t, err)
+
+		content, err := verifyCore(encoded)
+		checkNoError(t, err)
+		signerInfo := content.SignerInfo
+		if !reflect.DeepEqual(timestamper.signature, signerInfo.Signature) {
+			t.Fatalf("expected timestamped signature %x, but got %x", signerInfo.Signature, timestamper.signature)
+		}
+		if timestamper.hash != signerInfo.SignatureAlgorithm.Hash() {
+			t.Fatalf("expected hash %v, but got %v", signerInfo.SignatureAlgorithm.Hash(), timestamper.hash)
+		}
+		if !reflect.DeepEqual(signerInfo.UnsignedAttributes.TimestampSignature, timestamper.token) {
+			t.Fatalf("expected timestamp token %q, but got %q", timestamper.token, signerInfo.UnsignedAttributes.TimestampSignature)
+		}
+	})
+
+	t.Run("timestamper error", func(t *testing.T) {
+		signReq.Timestamper = &mockTimestamper{err: errors.New("tsa error")}
+		_, err := (&envelope{}).Sign(signReq)
+		var timestampErr *signature.TimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("expected TimestampError, but got %v", err)
+		}
+	})
+}
+
+func TestSetTimestampSignature(t *testing.T) {
+	encoded, err := getEncodedMessage(signature.SigningSchemeX509, true, nil)
+	checkNoError(t, err)
+	env, err := ParseEnvelope(encoded)
+	checkNoError(t, err)
+	content, err := env.Content()
+	checkNoError(t, err)
+
+	// add timestamp signature after signing
+	token := []byte("timestamp token")
+	setter, ok := env.(signature.TimestampSetter)
+	if !ok {
+		t.Fatal("expected JWS envelope to implement signature.TimestampSetter")
+	}
+	timestamped, err := setter.SetTimestampSignature(token)
+	checkNoError(t, err)
+
+	timestampedContent, err := verifyCore(timestamped)
+	checkNoError(t, err)
+	if !reflect.DeepEqual(timestampedContent.SignerInfo.UnsignedAttributes.TimestampSignature, token) {
+		t.Fatalf("expected timestamp signature %q, but got %q", token, timestampedContent.SignerInfo.UnsignedAttributes.TimestampSignature)
+	}
+	if !reflect.DeepEqual(timestampedContent.SignerInfo.Signature, content.SignerInfo.Signature) {
+		t.Fatal("expected signature to be unchanged")
+	}
+
+	var original, updated jwsEnvelope
+	checkNoError(t, json.Unmarshal(encoded, &original))
+	checkNoError(t, json.Unmarshal(timestamped, &updated))
+	if original.Protected != updated.Protected {
+		t.Fatal("expected protected header to be unchanged")
+	}
+}
+
+func TestSetTimestampSignatureEmptyEnvelope(t *testing.T) {
+	_, err := (&envelope{}).SetTimestampSignature([]byte("token"))
+	if _, ok := err.(*signature.SignatureEnvelopeNotFoundError); !ok {
+		t.Fatalf("expected SignatureEnvelopeNotFoundError, but got %v", err)
+	}
+}
+
+type mockTimestamper struct {
+	token     []byte
+	err       error
+	signature []byte
+	hash      crypto.Hash
+}
+
+func (m *mockTimestamper) Timestamp(signature []byte, hash crypto.Hash) ([]byte, error) {
+	m.signature = signature
+	m.hash = hash
+	return m.token, m.err
+}
+
+// getLegacySignedEnvelope returns a signed envelope with its protected header
+// algorithm replaced by the legacy alg and re-signed with RSASSA-PKCS1-v1_5.
+func getLegacySignedEnvelope(alg string, hash crypto.Hash) (*jwsEnvelope, error) {
+	env, err := getSignedEnvelope(signature.SigningSchemeX509, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	rawProtected, err := base64.RawURLEncoding.DecodeString(env.Protected)
+	if err != nil {
+		return nil, err
+	}
+	var protected map[string]interface{}
+	if err := json.Unmarshal(rawProtected, &protected); err != nil {
+		return nil, err
+	}
+	protected["alg"] = alg
+	if rawProtected, err = json.Marshal(protected); err != nil {
+		return nil, err
+	}
+	env.Protected = base64.RawURLEncoding.EncodeToString(rawProtected)
+
+	h := hash.New()
+	h.Write([]byte(env.Protected + "." + env.Payload))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, testhelper.GetRSALeafCertificate().PrivateKey, hash, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	env.Signature = base64.RawURLEncoding.EncodeToString(sig)
+	return env, nil
+}
+
+func TestVerifyLegacyAlgorithms(t *testing.T) {
+	tests := []struct {
+		alg    string
+		hash   crypto.Hash
+		expect signature.Algorithm
+	}{
+		{alg: "RS256", hash: crypto.SHA256, expect: signature.AlgorithmRS256},
+		{alg: "RS384", hash: crypto.SHA384, expect: signature.AlgorithmRS384},
+		{alg: "RS512", hash: crypto.SHA512, expect: signature.AlgorithmRS512},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			env, err := getLegacySignedEnvelope(tt.alg, tt.hash)
+			checkNoError(t, err)
+			encoded, err := json.Marshal(env)
+			checkNoError(t, err)
+			sigEnv, err := ParseEnvelope(encoded)
+			checkNoError(t, err)
+
+			// legacy algorithms are rejected by default
+			_, err = sigEnv.Verify()
+			var unsupportedErr *signature.UnsupportedSignatureAlgoError
+			if !errors.As(err, &unsupportedErr) {
+				t.Fatalf("Verify() expected UnsupportedSignatureAlgoError, got %v", err)
+			}
+
+			content, err := signature.Verify(sigEnv, signature.VerifyOptions{AllowLegacyAlgorithms: true})
+			checkNoError(t, err)
+			if alg := content.SignerInfo.SignatureAlgorithm; alg != tt.expect || !alg.IsLegacy() {
+				t.Fatalf("expected legacy signature algorithm %v, got %v", tt.expect, alg)
+			}
+		})
+	}
+
+	t.Run("tamper signature", func(t *testing.T) {
+		env, err := getLegacySignedEnvelope("RS256", crypto.SHA256)
+		checkNoError(t, err)
+		env.Signature = env.Signature[:len(env.Signature)-4] + "AAAA"
+		encoded, err := json.Marshal(env)
+		checkNoError(t, err)
+		sigEnv, err := ParseEnvelope(encoded)
+		checkNoError(t, err)
+
+		_, err = signature.Verify(sigEnv, signature.VerifyOptions{AllowLegacyAlgorithms: true})
+		checkErrorEqual(t, "crypto/rsa: verification error", err.Error())
+	})
+}
+
+func TestSignVerifyDetachedPayload(t *testing.T) {
+	signer, err := getSigner(true, nil, nil)
+	checkNoError(t, err)
+	signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+	checkNoError(t, err)
+	signReq.DetachedPayload = true
+
+	encoded, err := NewEnvelope().Sign(signReq)
+	checkNoError(t, err)
+	var raw jwsEnvelope
+	checkNoError(t, json.Unmarshal(encoded, &raw))
+	if raw.Payload != "" {
+		t.Fatalf("expect detached payload, got %q", raw.Payload)
+	}
+
+	env, err := ParseEnvelope(encoded)
+	checkNoError(t, err)
+	content, err := env.Content()
+	checkNoError(t, err)
+	if !content.Payload.Detached || len(content.Payload.Content) != 0 {
+		t.Fatalf("expect detached payload without content, got %+v", content.Payload)
+	}
+
+	t.Run("verify with detached payload", func(t *testing.T) {
+		content, err := signature.Verify(env, signature.VerifyOptions{DetachedPayload: signReq.Payload.Content})
+		checkNoError(t, err)
+		if !content.Payload.Detached || !reflect.DeepEqual(content.Payload.Content, signReq.Payload.Content) {
+			t.Fatalf("expect detached payload %q, got %+v", signReq.Payload.Content, content.Payload)
+		}
+	})
+
+	t.Run("verify without detached payload", func(t *testing.T) {
+		_, err := env.Verify()
+		checkErrorEqual(t, "payload is detached from JWS envelope but not provided", err.Error())
+	})
+
+	t.Run("verify with tampered detached payload", func(t *testing.T) {
+		_, err := signature.Verify(env, signature.VerifyOptions{DetachedPayload: []byte(`{"tampered":true}`)})
+		checkErrorEqual(t, "signature is invalid", err.Error())
+	})
+
+	t.Run("verify embedded payload with detached payload", func(t *testing.T) {
+		encoded, err := getEncodedMessage(signature.SigningSchemeX509, true, nil)
+		checkNoError(t, err)
+		env, err := ParseEnvelope(encoded)
+		checkNoError(t, err)
+		_, err = signature.Verify(env, signature.VerifyOptions{DetachedPayload: signReq.Payload.Content})
+		checkErrorEqual(t, "payload is embedded in JWS envelope", err.Error())
+	})
+}
@@ -143,6 +143,9 @@ func validateCriticalHeaders(protectedHeader *jwsProtectedHeader) error {
 
 func getSignatureAlgorithm(alg string) (signature.Algorithm, error) {
 	signatureAlg, ok := jwsAlgSignatureAlgMap[alg]
+	if !ok {
+		signatureAlg, ok = legacyJWSAlgSignatureAlgMap[alg]
+	}
 	if !ok {
 		return 0, &signature.UnsupportedSignatureAlgoError{Alg: alg}
 	}
@@ -195,6 +198,24 @@ func generateJWS(compact string, req *signature.SignRequest, certs []*x509.Certi
 	}, nil
 }
 
+// timestampSignature obtains a timestamp token over the decoded signature of
+// JWS envelope from the timestamper.
+func timestampSignature(timestamper signature.Timestamper, alg string, encodedSig string) ([]byte, error) {
+	sigAlg, ok := jwsAlgSignatureAlgMap[alg]
+	if !ok {
+		return nil, &signature.UnsupportedSignatureAlgoError{Alg: alg}
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	token, err := timestamper.Timestamp(sig, sigAlg.Hash())
+	if err != nil {
+		return nil, &signature.TimestampError{Err: err}
+	}
+	return token, nil
+}
+
 // getSignerAttributes merge extended signed attributes and protected header to be signed attributes.
 func getSignedAttributes(req *signature.SignRequest, algorithm string) (map[string]interface{}, error) {
 	extAttrs := make(map[string]interface{})
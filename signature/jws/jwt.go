@@ -18,7 +18,6 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"errors"
-	"fmt"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/notaryproject/notation-core-go/signature"
@@ -131,9 +130,14 @@ func getSigningMethod(signer signature.Signer) (signingMethod, error) {
 }
 
 // verifyJWT verifies the JWT token against the specified verification key.
-func verifyJWT(tokenString string, publicKey interface{}) error {
+// Legacy methods are accepted only if allowLegacy is set.
+func verifyJWT(tokenString string, publicKey interface{}, allowLegacy bool) error {
+	methods := validMethods
+	if allowLegacy {
+		methods = append(append([]string{}, validMethods...), legacyMethods...)
+	}
 	parser := jwt.NewParser(
-		jwt.WithValidMethods(validMethods),
+		jwt.WithValidMethods(methods),
 		jwt.WithJSONNumber(),
 		jwt.WithoutClaimsValidation(),
 	)
@@ -159,7 +163,7 @@ func extractJwtAlgorithm(signer signature.Signer) (string, error) {
 	jwsAlg, ok := signatureAlgJWSAlgMap[alg]
 	if !ok {
 		return "", &signature.UnsupportedSignatureAlgoError{
-			Alg: fmt.Sprintf("#%d", alg)}
+			Alg: alg.String()}
 	}
 	return jwsAlg, nil
 }
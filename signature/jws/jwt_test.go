@@ -63,12 +63,12 @@ func Test_remoteSigningMethod_Verify(t *testing.T) {
 func Test_newLocalSigningMethod(t *testing.T) {
 	signer := errorLocalSigner{}
 	_, err := newLocalSigningMethod(&signer)
-	checkErrorEqual(t, `signature algorithm "#0" is not supported`, err.Error())
+	checkErrorEqual(t, `signature algorithm "Algorithm(0)" is not supported`, err.Error())
 }
 
 func Test_newRemoteSigningMethod(t *testing.T) {
 	_, err := newRemoteSigningMethod(&errorLocalSigner{})
-	checkErrorEqual(t, `signature algorithm "#0" is not supported`, err.Error())
+	checkErrorEqual(t, `signature algorithm "Algorithm(0)" is not supported`, err.Error())
 }
 
 func Test_remoteSigningMethod_CertificateChain(t *testing.T) {
@@ -100,7 +100,7 @@ func Test_remoteSigningMethod_Sign(t *testing.T) {
 }
 func Test_extractJwtAlgorithm(t *testing.T) {
 	_, err := extractJwtAlgorithm(&errorLocalSigner{})
-	checkErrorEqual(t, `signature algorithm "#0" is not supported`, err.Error())
+	checkErrorEqual(t, `signature algorithm "Algorithm(0)" is not supported`, err.Error())
 
 	_, err = extractJwtAlgorithm(&errorLocalSigner{
 		keySpecError: errors.New("get key spec error"),
@@ -129,7 +129,7 @@ func Test_verifyJWT(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := verifyJWT(tt.args.tokenString, tt.args.publicKey); (err != nil) != tt.wantErr {
+			if err := verifyJWT(tt.args.tokenString, tt.args.publicKey, false); (err != nil) != tt.wantErr {
 				t.Errorf("verifyJWT() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -0,0 +1,273 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jws
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/internal/base"
+)
+
+// MediaTypeMultiSignatureEnvelope defines the media type name of JWS envelope
+// in JWS JSON General Serialization holding multiple signatures.
+const MediaTypeMultiSignatureEnvelope = "application/jose+json; serialization=general"
+
+func init() {
+	if err := signature.RegisterEnvelopeType(MediaTypeMultiSignatureEnvelope, NewMultiSignatureEnvelope, ParseMultiSignatureEnvelope); err != nil {
+		panic(err)
+	}
+}
+
+// multiEnvelope implements signature.MultiSignatureEnvelope with the
+// `signatures` array of JWS JSON General Serialization.
+type multiEnvelope struct {
+	base *jwsGeneralEnvelope
+}
+
+// NewMultiSignatureEnvelope generates a JWS envelope holding multiple
+// signatures.
+func NewMultiSignatureEnvelope() signature.Envelope {
+	return &base.MultiEnvelope{
+		Envelope: base.Envelope{
+			Envelope: &multiEnvelope{},
+		},
+	}
+}
+
+// ParseMultiSignatureEnvelope parses the envelope bytes and return a JWS
+// envelope holding multiple signatures.
+func ParseMultiSignatureEnvelope(envelopeBytes []byte) (signature.Envelope, error) {
+	var e jwsGeneralEnvelope
+	err := json.Unmarshal(envelopeBytes, &e)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return &base.MultiEnvelope{
+		Envelope: base.Envelope{
+			Envelope: &multiEnvelope{base: &e},
+			Raw:      envelopeBytes,
+		},
+	}, nil
+}
+
+// Sign generates and sign the envelope with a single signature according to
+// the sign request.
+func (e *multiEnvelope) Sign(req *signature.SignRequest) ([]byte, error) {
+	if req.DetachedPayload {
+		return nil, &signature.InvalidSignRequestError{Msg: "detached payload is not supported by JWS General JSON envelope"}
+	}
+
+	// payload is limited to be JSON format
+	var payload jwt.MapClaims
+	if err := json.Unmarshal(req.Payload.Content, &payload); err != nil {
+		return nil, &signature.InvalidSignRequestError{
+			Msg: fmt.Sprintf("payload format error: %v", err.Error())}
+	}
+
+	env := &jwsGeneralEnvelope{
+		Payload: jwt.EncodeSegment(req.Payload.Content),
+	}
+	if err := addSignature(env, req); err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(env)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	e.base = env
+	return encoded, nil
+}
+
+// AddSignature implements signature.MultiSignatureEnvelope interface.
+// The encoded payload and the existing signatures are kept as is.
+func (e *multiEnvelope) AddSignature(req *signature.SignRequest) ([]byte, error) {
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+	if req.DetachedPayload {
+		return nil, &signature.InvalidSignRequestError{Msg: "detached payload is not supported by JWS General JSON envelope"}
+	}
+
+	if err := addSignature(e.base, req); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(e.base)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return encoded, nil
+}
+
+// Clone returns a copy of the envelope. Signatures added to the copy are not
+// added to e.
+func (e *multiEnvelope) Clone() signature.MultiSignatureEnvelope {
+	if e.base == nil {
+		return &multiEnvelope{}
+	}
+	env := *e.base
+	env.Signatures = append([]jwsSignature(nil), e.base.Signatures...)
+	return &multiEnvelope{base: &env}
+}
+
+// Verify verifies all the signatures of the envelope and returns its enclosed
+// payload and the signer info of the first signature.
+func (e *multiEnvelope) Verify() (*signature.EnvelopeContent, error) {
+	content, err := e.VerifyAll()
+	if err != nil {
+		return nil, err
+	}
+	return &signature.EnvelopeContent{
+		SignerInfo: content.SignerInfos[0],
+		Payload:    content.Payload,
+	}, nil
+}
+
+// VerifyAll implements signature.MultiSignatureEnvelope interface.
+func (e *multiEnvelope) VerifyAll() (*signature.MultiSignatureContent, error) {
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+
+	// verify JWT of each signature
+	for _, sig := range e.base.Signatures {
+		if len(sig.Header.CertChain) == 0 {
+			return nil, &signature.InvalidSignatureError{Msg: "certificate chain is not present"}
+		}
+		cert, err := x509.ParseCertificate(sig.Header.CertChain[0])
+		if err != nil {
+			return nil, &signature.InvalidSignatureError{Msg: "malformed leaf certificate"}
+		}
+		compact := compactJWS(&jwsEnvelope{
+			Protected: sig.Protected,
+			Payload:   e.base.Payload,
+			Signature: sig.Signature,
+		})
+		if err := verifyJWT(compact, cert.PublicKey, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return e.contents()
+}
+
+// Content returns the payload and the signer information of the first
+// signature of the envelope.
+// Content is trusted only after the successful call to `Verify()`.
+func (e *multiEnvelope) Content() (*signature.EnvelopeContent, error) {
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+
+	content, err := e.contents()
+	if err != nil {
+		return nil, err
+	}
+	return &signature.EnvelopeContent{
+		SignerInfo: content.SignerInfos[0],
+		Payload:    content.Payload,
+	}, nil
+}
+
+// contents returns the payload and the signer info of each signature. All the
+// signatures must agree on the content type of the payload.
+func (e *multiEnvelope) contents() (*signature.MultiSignatureContent, error) {
+	if len(e.base.Signatures) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "signature missing in jws-json envelope"}
+	}
+
+	content, err := base64.RawURLEncoding.DecodeString(e.base.Payload)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{
+			Msg: fmt.Sprintf("payload error: %v", err)}
+	}
+
+	var contentType string
+	signerInfos := make([]signature.SignerInfo, 0, len(e.base.Signatures))
+	for i, sig := range e.base.Signatures {
+		protected, err := parseProtectedHeaders(sig.Protected)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			contentType = protected.ContentType
+		} else if protected.ContentType != contentType {
+			return nil, &signature.InvalidSignatureError{
+				Msg: fmt.Sprintf("content type %q of signature %d does not match %q", protected.ContentType, i, contentType)}
+		}
+		signerInfo, err := parseSignerInfo(protected, &sig.Header, sig.Signature)
+		if err != nil {
+			return nil, err
+		}
+		signerInfos = append(signerInfos, *signerInfo)
+	}
+
+	return &signature.MultiSignatureContent{
+		SignerInfos: signerInfos,
+		Payload: signature.Payload{
+			Content:     content,
+			ContentType: contentType,
+		},
+	}, nil
+}
+
+// addSignature signs the encoded payload of the envelope according to the
+// sign request and appends the signature to the envelope.
+func addSignature(env *jwsGeneralEnvelope, req *signature.SignRequest) error {
+	// get signingMethod for JWT package
+	method, err := getSigningMethod(req.Signer)
+	if err != nil {
+		return &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// get all attributes ready to be signed
+	signedAttrs, err := getSignedAttributes(req, method.Alg())
+	if err != nil {
+		return &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// JWT sign and get certificate chain
+	compact, certs, err := signContent(env.Payload, signedAttrs, method)
+	if err != nil {
+		return &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// generate signature
+	jws, err := generateJWS(compact, req, certs)
+	if err != nil {
+		return &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	sig := jwsSignature{
+		Protected: jws.Protected,
+		Header:    jws.Header,
+		Signature: jws.Signature,
+	}
+
+	// timestamp the signature
+	if req.Timestamper != nil {
+		token, err := timestampSignature(req.Timestamper, method.Alg(), sig.Signature)
+		if err != nil {
+			return err
+		}
+		sig.Header.TimestampSignature = token
+	}
+
+	env.Signatures = append(env.Signatures, sig)
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jws
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/internal/base"
+	"github.com/notaryproject/notation-core-go/signature/internal/signaturetest"
+)
+
+func signMultiSignatureEnvelope(t *testing.T) []byte {
+	t.Helper()
+	signer, err := getSigner(true, nil, nil)
+	checkNoError(t, err)
+	signReq, err := getSignReq(signature.SigningSchemeX509, signer, extSignedAttr)
+	checkNoError(t, err)
+	encoded, err := NewMultiSignatureEnvelope().Sign(signReq)
+	checkNoError(t, err)
+
+	coSigner, err := signaturetest.GetTestLocalSigner(signature.KeyTypeEC, 256)
+	checkNoError(t, err)
+	coSignReq, err := getSignReq(signature.SigningSchemeX509SigningAuthority, coSigner, nil)
+	checkNoError(t, err)
+	coSignReq.Payload = signature.Payload{}
+	encoded, err = signature.AddSignature(MediaTypeMultiSignatureEnvelope, encoded, coSignReq)
+	checkNoError(t, err)
+	return encoded
+}
+
+func TestMultiSignatureEnvelope(t *testing.T) {
+	encoded := signMultiSignatureEnvelope(t)
+	var general jwsGeneralEnvelope
+	checkNoError(t, json.Unmarshal(encoded, &general))
+	if len(general.Signatures) != 2 {
+		t.Fatalf("expected 2 signatures, but got %d", len(general.Signatures))
+	}
+
+	env, err := signature.ParseEnvelope(MediaTypeMultiSignatureEnvelope, encoded)
+	checkNoError(t, err)
+	multiEnv, ok := env.(signature.MultiSignatureEnvelope)
+	if !ok {
+		t.Fatal("expected signature.MultiSignatureEnvelope")
+	}
+	content, err := multiEnv.VerifyAll()
+	checkNoError(t, err)
+	if content.Payload.ContentType != "application/vnd.cncf.notary.payload.v1+json" {
+		t.Fatalf("unexpected content type %q", content.Payload.ContentType)
+	}
+	if len(content.SignerInfos) != 2 {
+		t.Fatalf("VerifyAll() expects 2 signer infos, but got %d", len(content.SignerInfos))
+	}
+	if alg := content.SignerInfos[0].SignatureAlgorithm; alg != signature.AlgorithmPS384 {
+		t.Errorf("expected algorithm %v for the first signature, but got %v", signature.AlgorithmPS384, alg)
+	}
+	if alg := content.SignerInfos[1].SignatureAlgorithm; alg != signature.AlgorithmES256 {
+		t.Errorf("expected algorithm %v for the second signature, but got %v", signature.AlgorithmES256, alg)
+	}
+	if n := len(content.SignerInfos[0].SignedAttributes.ExtendedAttributes); n != 2 {
+		t.Errorf("expected 2 extended attributes for the first signature, but got %d", n)
+	}
+
+	verified, err := env.Verify()
+	checkNoError(t, err)
+	if alg := verified.SignerInfo.SignatureAlgorithm; alg != signature.AlgorithmPS384 {
+		t.Errorf("Verify() expects the first signature, but got algorithm %v", alg)
+	}
+}
+
+func TestMultiSignatureEnvelopeTampered(t *testing.T) {
+	var general jwsGeneralEnvelope
+	checkNoError(t, json.Unmarshal(signMultiSignatureEnvelope(t), &general))
+
+	t.Run("tampered signature", func(t *testing.T) {
+		tampered := general
+		tampered.Signatures = append([]jwsSignature{}, general.Signatures...)
+		tampered.Signatures[1].Signature = general.Signatures[0].Signature
+		encoded, err := json.Marshal(tampered)
+		checkNoError(t, err)
+		env, err := ParseMultiSignatureEnvelope(encoded)
+		checkNoError(t, err)
+		_, err = env.Verify()
+		var integrityErr *signature.SignatureIntegrityError
+		if !errors.As(err, &integrityErr) {
+			t.Fatalf("Verify() expects SignatureIntegrityError, but got: %v", err)
+		}
+	})
+
+	t.Run("mismatched content type", func(t *testing.T) {
+		tampered := general
+		tampered.Signatures = append([]jwsSignature{}, general.Signatures...)
+		signer, err := getSigner(true, nil, nil)
+		checkNoError(t, err)
+		signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+		checkNoError(t, err)
+		signReq.Payload.ContentType = "application/json"
+		checkNoError(t, addSignature(&tampered, signReq))
+		encoded, err := json.Marshal(tampered)
+		checkNoError(t, err)
+		env, err := ParseMultiSignatureEnvelope(encoded)
+		checkNoError(t, err)
+		_, err = env.Verify()
+		checkErrorEqual(t, `content type "application/json" of signature 2 does not match "application/vnd.cncf.notary.payload.v1+json"`, err.Error())
+	})
+}
+
+func TestMultiSignatureEnvelopeErrors(t *testing.T) {
+	t.Run("parse malformed envelope", func(t *testing.T) {
+		_, err := ParseMultiSignatureEnvelope([]byte("{"))
+		var sigErr *signature.InvalidSignatureError
+		if !errors.As(err, &sigErr) {
+			t.Fatalf("ParseMultiSignatureEnvelope() expects InvalidSignatureError, but got: %v", err)
+		}
+	})
+
+	t.Run("sign with non-JSON payload", func(t *testing.T) {
+		signer, err := getSigner(true, nil, nil)
+		checkNoError(t, err)
+		signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+		checkNoError(t, err)
+		signReq.Payload.Content = []byte("payload")
+		_, err = NewMultiSignatureEnvelope().Sign(signReq)
+		var reqErr *signature.InvalidSignRequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("Sign() expects InvalidSignRequestError, but got: %v", err)
+		}
+	})
+
+	t.Run("sign with detached payload", func(t *testing.T) {
+		signer, err := getSigner(true, nil, nil)
+		checkNoError(t, err)
+		signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+		checkNoError(t, err)
+		signReq.DetachedPayload = true
+		_, err = NewMultiSignatureEnvelope().Sign(signReq)
+		var reqErr *signature.InvalidSignRequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("Sign() expects InvalidSignRequestError, but got: %v", err)
+		}
+	})
+
+	t.Run("add signature to empty envelope", func(t *testing.T) {
+		signer, err := getSigner(true, nil, nil)
+		checkNoError(t, err)
+		signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+		checkNoError(t, err)
+		_, err = (&multiEnvelope{}).AddSignature(signReq)
+		var notFoundErr *signature.SignatureEnvelopeNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			t.Fatalf("AddSignature() expects SignatureEnvelopeNotFoundError, but got: %v", err)
+		}
+	})
+
+	t.Run("add signature to clone", func(t *testing.T) {
+		encoded := signMultiSignatureEnvelope(t)
+		env, err := ParseMultiSignatureEnvelope(encoded)
+		checkNoError(t, err)
+		internal := env.(*base.MultiEnvelope).Envelope.Envelope.(*multiEnvelope)
+		signer, err := getSigner(true, nil, nil)
+		checkNoError(t, err)
+		signReq, err := getSignReq(signature.SigningSchemeX509, signer, nil)
+		checkNoError(t, err)
+		_, err = internal.Clone().AddSignature(signReq)
+		checkNoError(t, err)
+		if len(internal.base.Signatures) != 2 {
+			t.Fatalf("expected the envelope to keep 2 signatures, but got %d", len(internal.base.Signatures))
+		}
+	})
+
+	t.Run("content without signatures", func(t *testing.T) {
+		env := &multiEnvelope{base: &jwsGeneralEnvelope{}}
+		_, err := env.Content()
+		checkErrorEqual(t, "signature missing in jws-json envelope", err.Error())
+	})
+}
@@ -99,6 +99,30 @@ type jwsEnvelope struct {
 	Signature string `json:"signature"`
 }
 
+// jwsGeneralEnvelope is the Signature envelope in JWS JSON General
+// Serialization holding multiple signatures over the same payload.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc7515#section-7.2.1
+type jwsGeneralEnvelope struct {
+	// JWSPayload Base64URL-encoded. Raw data should be JSON format.
+	Payload string `json:"payload"`
+
+	// Signatures over the payload.
+	Signatures []jwsSignature `json:"signatures"`
+}
+
+// jwsSignature is a signature in JWS JSON General Serialization.
+type jwsSignature struct {
+	// jwsProtectedHeader Base64URL-encoded.
+	Protected string `json:"protected"`
+
+	// Signature metadata that is not integrity Protected
+	Header jwsUnprotectedHeader `json:"header"`
+
+	// Base64URL-encoded Signature.
+	Signature string `json:"signature"`
+}
+
 var (
 	ps256 = jwt.SigningMethodPS256.Name
 	ps384 = jwt.SigningMethodPS384.Name
@@ -106,9 +130,15 @@ var (
 	es256 = jwt.SigningMethodES256.Name
 	es384 = jwt.SigningMethodES384.Name
 	es512 = jwt.SigningMethodES512.Name
+	eddsa = jwt.SigningMethodEdDSA.Alg()
+
+	// legacy algorithms, which are only supported for verification
+	rs256 = jwt.SigningMethodRS256.Name
+	rs384 = jwt.SigningMethodRS384.Name
+	rs512 = jwt.SigningMethodRS512.Name
 )
 
-var validMethods = []string{ps256, ps384, ps512, es256, es384, es512}
+var validMethods = []string{ps256, ps384, ps512, es256, es384, es512, eddsa}
 
 var signatureAlgJWSAlgMap = map[signature.Algorithm]string{
 	signature.AlgorithmPS256: ps256,
@@ -117,10 +147,19 @@ var signatureAlgJWSAlgMap = map[signature.Algorithm]string{
 	signature.AlgorithmES256: es256,
 	signature.AlgorithmES384: es384,
 	signature.AlgorithmES512: es512,
+	signature.AlgorithmEdDSA: eddsa,
 }
 
 var jwsAlgSignatureAlgMap = reverseMap(signatureAlgJWSAlgMap)
 
+var legacyMethods = []string{rs256, rs384, rs512}
+
+var legacyJWSAlgSignatureAlgMap = map[string]signature.Algorithm{
+	rs256: signature.AlgorithmRS256,
+	rs384: signature.AlgorithmRS384,
+	rs512: signature.AlgorithmRS512,
+}
+
 func reverseMap(m map[signature.Algorithm]string) map[string]signature.Algorithm {
 	n := make(map[string]signature.Algorithm, len(m))
 	for k, v := range m {
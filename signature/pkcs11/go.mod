@@ -0,0 +1,17 @@
+module github.com/notaryproject/notation-core-go/signature/pkcs11
+
+go 1.19
+
+require (
+	github.com/miekg/pkcs11 v1.1.1
+	github.com/notaryproject/notation-core-go v0.0.0
+)
+
+require (
+	github.com/fxamacker/cbor/v2 v2.4.0 // indirect
+	github.com/veraison/go-cose v1.1.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/crypto v0.11.0 // indirect
+)
+
+replace github.com/notaryproject/notation-core-go => ../..
@@ -0,0 +1,462 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pkcs11 implements signature.Signer with keys stored in PKCS #11
+// tokens such as HSMs.
+//
+// The package is a separate Go module as it requires cgo to load PKCS #11
+// modules, so that users of the core module are not forced to enable cgo.
+package pkcs11
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// Options contains parameters for signing with a PKCS #11 token.
+type Options struct {
+	// ModulePath is the path of the PKCS #11 module, e.g.
+	// /usr/lib/softhsm/libsofthsm2.so
+	ModulePath string
+
+	// Slot is the ID of the slot holding the token.
+	Slot uint
+
+	// PIN is the user PIN of the token.
+	PIN string
+
+	// KeyLabel is the label (CKA_LABEL) of the private key. The signing
+	// certificate is the certificate object sharing the ID (CKA_ID) of the
+	// private key, or the label if the private key has no ID.
+	KeyLabel string
+}
+
+// module is the subset of the PKCS #11 API used by Signer, which is
+// implemented by *pkcs11.Ctx.
+type module interface {
+	Finalize() error
+	Destroy()
+	OpenSession(slotID uint, flags uint) (pkcs11.SessionHandle, error)
+	CloseSession(sh pkcs11.SessionHandle) error
+	Login(sh pkcs11.SessionHandle, userType uint, pin string) error
+	Logout(sh pkcs11.SessionHandle) error
+	FindObjectsInit(sh pkcs11.SessionHandle, temp []*pkcs11.Attribute) error
+	FindObjects(sh pkcs11.SessionHandle, max int) ([]pkcs11.ObjectHandle, bool, error)
+	FindObjectsFinal(sh pkcs11.SessionHandle) error
+	GetAttributeValue(sh pkcs11.SessionHandle, o pkcs11.ObjectHandle, a []*pkcs11.Attribute) ([]*pkcs11.Attribute, error)
+	SignInit(sh pkcs11.SessionHandle, m []*pkcs11.Mechanism, o pkcs11.ObjectHandle) error
+	Sign(sh pkcs11.SessionHandle, message []byte) ([]byte, error)
+}
+
+// Signer implements signature.Signer interface with a private key stored in
+// a PKCS #11 token.
+//
+// Signer holds a logged-in session to the token until Close is called.
+type Signer struct {
+	ctx     module
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	keySpec signature.KeySpec
+	certs   []*x509.Certificate
+
+	// mu serializes the signing operations of the session.
+	mu sync.Mutex
+}
+
+// NewSigner loads the PKCS #11 module, logs in the token and finds the
+// private key and the certificate chain specified by opts.
+//
+// The certificate chain is built from the certificates on the token, starting
+// from the signing certificate. The private key must match the public key of
+// the signing certificate. The caller must call Close to release the token
+// session.
+func NewSigner(opts Options) (*Signer, error) {
+	if opts.ModulePath == "" {
+		return nil, &signature.InvalidArgumentError{
+			Param: "ModulePath",
+			Err:   errors.New("empty module path"),
+		}
+	}
+	if opts.KeyLabel == "" {
+		return nil, &signature.InvalidArgumentError{
+			Param: "KeyLabel",
+			Err:   errors.New("empty key label"),
+		}
+	}
+
+	ctx := pkcs11.New(opts.ModulePath)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS #11 module %q", opts.ModulePath)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS #11 module: %w", err)
+	}
+	return newSigner(ctx, opts)
+}
+
+// newSigner opens the token with the initialized module ctx, which is
+// finalized on failure.
+func newSigner(ctx module, opts Options) (*Signer, error) {
+	s := &Signer{ctx: ctx}
+	if err := s.open(opts); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the session to the token and loads the key and the certificate
+// chain.
+func (s *Signer) open(opts Options) error {
+	session, err := s.ctx.OpenSession(opts.Slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return fmt.Errorf("failed to open session on slot %d: %w", opts.Slot, err)
+	}
+	s.session = session
+	if err := s.ctx.Login(session, pkcs11.CKU_USER, opts.PIN); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		return fmt.Errorf("failed to log in token: %w", err)
+	}
+
+	// find the private key
+	keys, err := s.findObjects([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, opts.KeyLabel),
+	})
+	if err != nil {
+		return err
+	}
+	switch len(keys) {
+	case 0:
+		return fmt.Errorf("private key with label %q is not found", opts.KeyLabel)
+	case 1:
+	default:
+		return fmt.Errorf("multiple private keys with label %q are found", opts.KeyLabel)
+	}
+	s.key = keys[0]
+
+	// find the signing certificate linked to the private key
+	certTemplate := append(s.linkedObjectTemplate(pkcs11.CKO_CERTIFICATE, opts.KeyLabel),
+		pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+	)
+	leafCerts, err := s.findCertificates(certTemplate)
+	if err != nil {
+		return err
+	}
+	if len(leafCerts) == 0 {
+		return fmt.Errorf("certificate of private key %q is not found", opts.KeyLabel)
+	}
+
+	// build the certificate chain with all certificates on the token
+	certs, err := s.findCertificates([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+		pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+	})
+	if err != nil {
+		return err
+	}
+	s.certs = buildCertificateChain(leafCerts[0], certs)
+
+	keySpec, err := signature.ExtractKeySpec(s.certs[0])
+	if err != nil {
+		return err
+	}
+	if keySpec.Type != signature.KeyTypeRSA && keySpec.Type != signature.KeyTypeEC {
+		return &signature.UnsupportedSigningKeyError{
+			Msg: "only RSA and EC keys are supported by PKCS #11 signer",
+		}
+	}
+	s.keySpec = keySpec
+	return s.checkKeyPair(opts.KeyLabel)
+}
+
+// checkKeyPair checks that the private key on the token matches the public
+// key of the signing certificate, so that a mislabeled key is detected
+// before producing signatures that never verify.
+//
+// The public components of RSA private keys are compared directly. As EC
+// private keys do not carry the public point, it is compared with the public
+// key object sharing the ID or the label of the private key if present.
+// Otherwise, a signature over a random payload is produced and verified.
+func (s *Signer) checkKeyPair(keyLabel string) error {
+	certKey := s.certs[0].PublicKey
+	switch s.keySpec.Type {
+	case signature.KeyTypeRSA:
+		attrs, err := s.ctx.GetAttributeValue(s.session, s.key, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read the public components of private key %q: %w", keyLabel, err)
+		}
+		if err := matchRSAPublicKey(attrs[0].Value, attrs[1].Value, certKey); err != nil {
+			return fmt.Errorf("private key %q does not match the signing certificate: %w", keyLabel, err)
+		}
+		return nil
+	case signature.KeyTypeEC:
+		publicKeys, err := s.findObjects(s.linkedObjectTemplate(pkcs11.CKO_PUBLIC_KEY, keyLabel))
+		if err != nil {
+			return err
+		}
+		if len(publicKeys) > 0 {
+			attrs, err := s.ctx.GetAttributeValue(s.session, publicKeys[0], []*pkcs11.Attribute{
+				pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to read the public key of private key %q: %w", keyLabel, err)
+			}
+			if err := matchECPoint(attrs[0].Value, certKey); err != nil {
+				return fmt.Errorf("private key %q does not match the signing certificate: %w", keyLabel, err)
+			}
+			return nil
+		}
+	}
+
+	alg := s.keySpec.SignatureAlgorithm()
+	payload := make([]byte, 32)
+	if _, err := rand.Read(payload); err != nil {
+		return err
+	}
+	h := alg.Hash().New()
+	h.Write(payload)
+	sig, err := s.sign(alg.Hash(), h.Sum(nil))
+	if err != nil {
+		return err
+	}
+	if err := signature.VerifyRawSignature(alg, certKey, payload, sig); err != nil {
+		return fmt.Errorf("private key %q does not match the signing certificate: %w", keyLabel, err)
+	}
+	return nil
+}
+
+// linkedObjectTemplate returns the template finding the objects of class
+// linked to the private key by its ID, or by keyLabel if the private key has
+// no ID.
+func (s *Signer) linkedObjectTemplate(class uint, keyLabel string) []*pkcs11.Attribute {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+	}
+	attrs, err := s.ctx.GetAttributeValue(s.session, s.key, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_ID, nil),
+	})
+	if err == nil && len(attrs[0].Value) > 0 {
+		return append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, attrs[0].Value))
+	}
+	return append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyLabel))
+}
+
+// matchRSAPublicKey checks that the big-endian modulus and public exponent
+// read from the token match the RSA public key.
+func matchRSAPublicKey(modulus, exponent []byte, key crypto.PublicKey) error {
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate does not have an RSA public key")
+	}
+	e := new(big.Int).SetBytes(exponent)
+	if new(big.Int).SetBytes(modulus).Cmp(rsaKey.N) != 0 || !e.IsInt64() || e.Int64() != int64(rsaKey.E) {
+		return errors.New("RSA public key mismatch")
+	}
+	return nil
+}
+
+// matchECPoint checks that the CKA_EC_POINT read from the token matches the
+// EC public key. The point is a DER-encoded OCTET STRING wrapping the
+// uncompressed point, while some tokens return the raw point instead.
+func matchECPoint(ecPoint []byte, key crypto.PublicKey) error {
+	ecKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("signing certificate does not have an EC public key")
+	}
+	expected := elliptic.Marshal(ecKey.Curve, ecKey.X, ecKey.Y)
+	if bytes.Equal(ecPoint, expected) {
+		return nil
+	}
+	var point []byte
+	if rest, err := asn1.Unmarshal(ecPoint, &point); err == nil && len(rest) == 0 && bytes.Equal(point, expected) {
+		return nil
+	}
+	return errors.New("EC public key mismatch")
+}
+
+// findObjects returns the handles of all objects matching the template.
+func (s *Signer) findObjects(template []*pkcs11.Attribute) ([]pkcs11.ObjectHandle, error) {
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return nil, fmt.Errorf("failed to find objects: %w", err)
+	}
+	defer s.ctx.FindObjectsFinal(s.session)
+
+	var handles []pkcs11.ObjectHandle
+	for {
+		found, _, err := s.ctx.FindObjects(s.session, 16)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find objects: %w", err)
+		}
+		if len(found) == 0 {
+			return handles, nil
+		}
+		handles = append(handles, found...)
+	}
+}
+
+// findCertificates returns the parsed certificates matching the template.
+func (s *Signer) findCertificates(template []*pkcs11.Attribute) ([]*x509.Certificate, error) {
+	handles, err := s.findObjects(template)
+	if err != nil {
+		return nil, err
+	}
+	certs := make([]*x509.Certificate, 0, len(handles))
+	for _, handle := range handles {
+		attrs, err := s.ctx.GetAttributeValue(s.session, handle, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate: %w", err)
+		}
+		cert, err := x509.ParseCertificate(attrs[0].Value)
+		if err != nil {
+			return nil, fmt.Errorf("malformed certificate on token: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// buildCertificateChain builds the certificate chain from leaf by appending
+// the issuer of the last certificate found in certs, until a self-signed
+// certificate is reached or no issuer is found.
+func buildCertificateChain(leaf *x509.Certificate, certs []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	for cert := leaf; ; {
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil {
+			return chain
+		}
+		var issuer *x509.Certificate
+		for _, candidate := range certs {
+			if bytes.Equal(cert.RawIssuer, candidate.RawSubject) && cert.CheckSignatureFrom(candidate) == nil && !containsCertificate(chain, candidate) {
+				issuer = candidate
+				break
+			}
+		}
+		if issuer == nil {
+			return chain
+		}
+		chain = append(chain, issuer)
+		cert = issuer
+	}
+}
+
+// containsCertificate reports whether cert is in certs.
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign signs the payload with the private key on the token and returns the
+// raw signature and certificates.
+//
+// RSA keys sign with CKM_RSA_PKCS_PSS and EC keys sign with CKM_ECDSA, whose
+// signature is the concatenation of r and s.
+func (s *Signer) Sign(payload []byte) ([]byte, []*x509.Certificate, error) {
+	hash := s.keySpec.SignatureAlgorithm().Hash()
+	h := hash.New()
+	h.Write(payload)
+	sig, err := s.sign(hash, h.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+	return sig, s.certs, nil
+}
+
+// sign signs the digest computed by hash with the private key on the token.
+func (s *Signer) sign(hash crypto.Hash, digest []byte) ([]byte, error) {
+	mechanism, err := signingMechanism(s.keySpec.Type, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return nil, errors.New("pkcs11 signer is closed")
+	}
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{mechanism}, s.key); err != nil {
+		return nil, fmt.Errorf("failed to initialize signing operation: %w", err)
+	}
+	sig, err := s.ctx.Sign(s.session, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign: %w", err)
+	}
+	return sig, nil
+}
+
+// signingMechanism returns the PKCS #11 mechanism signing the digest
+// computed by hash with the key type.
+func signingMechanism(keyType signature.KeyType, hash crypto.Hash) (*pkcs11.Mechanism, error) {
+	switch keyType {
+	case signature.KeyTypeRSA:
+		var hashMech, mgf uint
+		switch hash {
+		case crypto.SHA256:
+			hashMech, mgf = pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256
+		case crypto.SHA384:
+			hashMech, mgf = pkcs11.CKM_SHA384, pkcs11.CKG_MGF1_SHA384
+		case crypto.SHA512:
+			hashMech, mgf = pkcs11.CKM_SHA512, pkcs11.CKG_MGF1_SHA512
+		default:
+			return nil, &signature.UnsupportedSigningKeyError{Msg: fmt.Sprintf("hash %v is not supported", hash)}
+		}
+		return pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_PSS, pkcs11.NewPSSParams(hashMech, mgf, uint(hash.Size()))), nil
+	case signature.KeyTypeEC:
+		return pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil), nil
+	}
+	return nil, &signature.UnsupportedSigningKeyError{}
+}
+
+// KeySpec returns the key specification of the signing certificate.
+func (s *Signer) KeySpec() (signature.KeySpec, error) {
+	return s.keySpec, nil
+}
+
+// Close logs out the token and releases the PKCS #11 module.
+func (s *Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return nil
+	}
+	if s.session != 0 {
+		s.ctx.Logout(s.session)
+		s.ctx.CloseSession(s.session)
+	}
+	err := s.ctx.Finalize()
+	s.ctx.Destroy()
+	s.ctx = nil
+	return err
+}
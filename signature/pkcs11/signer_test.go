@@ -0,0 +1,700 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/pkcs11"
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/testhelper"
+)
+
+const (
+	testTokenLabel = "notation-test"
+	testSOPIN      = "5678"
+	testUserPIN    = "1234"
+)
+
+// softHSMModulePaths are the common install locations of SoftHSM v2.
+var softHSMModulePaths = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+}
+
+// findSoftHSM returns the path of the SoftHSM module specified by the
+// SOFTHSM2_MODULE environment variable or found in the common locations.
+// The test is skipped if SoftHSM is not installed.
+func findSoftHSM(t *testing.T) string {
+	if path := os.Getenv("SOFTHSM2_MODULE"); path != "" {
+		return path
+	}
+	for _, path := range softHSMModulePaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	t.Skip("SoftHSM is not installed, set SOFTHSM2_MODULE to run the test")
+	return ""
+}
+
+// setupSoftHSM initializes a new SoftHSM token with the private key and the
+// certificate chain, and returns the module path and the slot of the token.
+func setupSoftHSM(t *testing.T, keyLabel string, key crypto.PrivateKey, certs []*x509.Certificate) (string, uint) {
+	modulePath := findSoftHSM(t)
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokenDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	confPath := filepath.Join(dir, "softhsm2.conf")
+	conf := fmt.Sprintf("directories.tokendir = %s\nobjectstore.backend = file\nlog.level = ERROR\n", tokenDir)
+	if err := os.WriteFile(confPath, []byte(conf), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", confPath)
+
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		t.Fatalf("failed to load %s", modulePath)
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Finalize()
+
+	slots, err := ctx.GetSlotList(false)
+	if err != nil || len(slots) == 0 {
+		t.Fatalf("failed to get slots: %v", err)
+	}
+	if err := ctx.InitToken(slots[0], testSOPIN, testTokenLabel); err != nil {
+		t.Fatal(err)
+	}
+
+	// SoftHSM reassigns the slot of the initialized token
+	slot, err := findTokenSlot(ctx, testTokenLabel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.CloseSession(session)
+	if err := ctx.Login(session, pkcs11.CKU_SO, testSOPIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.InitPIN(session, testUserPIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Logout(session); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Login(session, pkcs11.CKU_USER, testUserPIN); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Logout(session)
+
+	id := []byte{0x01}
+	keyTemplate, err := privateKeyTemplate(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyTemplate = append(keyTemplate,
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyLabel),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	)
+	if _, err := ctx.CreateObject(session, keyTemplate); err != nil {
+		t.Fatalf("failed to import private key: %v", err)
+	}
+	for i, cert := range certs {
+		certTemplate := []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+			pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SUBJECT, cert.RawSubject),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, cert.Raw),
+		}
+		if i == 0 {
+			certTemplate = append(certTemplate, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+		} else {
+			certTemplate = append(certTemplate, pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{0xca, byte(i)}))
+		}
+		if _, err := ctx.CreateObject(session, certTemplate); err != nil {
+			t.Fatalf("failed to import certificate: %v", err)
+		}
+	}
+	return modulePath, slot
+}
+
+// findTokenSlot returns the slot of the token with the label.
+func findTokenSlot(ctx *pkcs11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, err
+		}
+		if info.Label == label {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("token %q is not found", label)
+}
+
+// privateKeyTemplate returns the key type specific attributes to import key.
+func privateKeyTemplate(key crypto.PrivateKey) ([]*pkcs11.Attribute, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		key.Precompute()
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_RSA),
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, key.N.Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, big.NewInt(int64(key.E)).Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE_EXPONENT, key.D.Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIME_1, key.Primes[0].Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIME_2, key.Primes[1].Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_EXPONENT_1, key.Precomputed.Dp.Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_EXPONENT_2, key.Precomputed.Dq.Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_COEFFICIENT, key.Precomputed.Qinv.Bytes()),
+		}, nil
+	case *ecdsa.PrivateKey:
+		oid, ok := map[elliptic.Curve]asn1.ObjectIdentifier{
+			elliptic.P256(): {1, 2, 840, 10045, 3, 1, 7},
+			elliptic.P384(): {1, 3, 132, 0, 34},
+			elliptic.P521(): {1, 3, 132, 0, 35},
+		}[key.Curve]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %s", key.Curve.Params().Name)
+		}
+		params, err := asn1.Marshal(oid)
+		if err != nil {
+			return nil, err
+		}
+		return []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, key.D.FillBytes(make([]byte, (key.Curve.Params().BitSize+7)/8))),
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   crypto.PrivateKey
+		certs []*x509.Certificate
+	}{
+		{
+			name: "RSA",
+			key:  testhelper.GetRSALeafCertificate().PrivateKey,
+			certs: []*x509.Certificate{
+				testhelper.GetRSALeafCertificate().Cert,
+				testhelper.GetRSARootCertificate().Cert,
+			},
+		},
+		{
+			name: "EC",
+			key:  testhelper.GetECLeafCertificate().PrivateKey,
+			certs: []*x509.Certificate{
+				testhelper.GetECLeafCertificate().Cert,
+				testhelper.GetECRootCertificate().Cert,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modulePath, slot := setupSoftHSM(t, "release-key", tt.key, tt.certs)
+			signer, err := NewSigner(Options{
+				ModulePath: modulePath,
+				Slot:       slot,
+				PIN:        testUserPIN,
+				KeyLabel:   "release-key",
+			})
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+			defer signer.Close()
+
+			keySpec, err := signer.KeySpec()
+			if err != nil {
+				t.Fatalf("KeySpec() error = %v", err)
+			}
+			expectedKeySpec, err := signature.ExtractKeySpec(tt.certs[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keySpec != expectedKeySpec {
+				t.Fatalf("KeySpec() = %+v, want %+v", keySpec, expectedKeySpec)
+			}
+
+			env := cose.NewEnvelope()
+			encoded, err := env.Sign(&signature.SignRequest{
+				Payload: signature.Payload{
+					ContentType: "application/vnd.cncf.notary.payload.v1+json",
+					Content:     []byte("{}"),
+				},
+				Signer:        signer,
+				SigningTime:   time.Now(),
+				SigningScheme: signature.SigningSchemeX509,
+			})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			env, err = cose.ParseEnvelope(encoded)
+			if err != nil {
+				t.Fatalf("ParseEnvelope() error = %v", err)
+			}
+			content, err := env.Verify()
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if chain := content.SignerInfo.CertificateChain; len(chain) != len(tt.certs) || !chain[0].Equal(tt.certs[0]) || !chain[1].Equal(tt.certs[1]) {
+				t.Errorf("unexpected certificate chain %v", chain)
+			}
+		})
+	}
+}
+
+func TestNewSignerErrors(t *testing.T) {
+	t.Run("empty module path", func(t *testing.T) {
+		if _, err := NewSigner(Options{KeyLabel: "key"}); err == nil {
+			t.Error("NewSigner() expects error, got nil")
+		}
+	})
+
+	t.Run("empty key label", func(t *testing.T) {
+		if _, err := NewSigner(Options{ModulePath: "module.so"}); err == nil {
+			t.Error("NewSigner() expects error, got nil")
+		}
+	})
+
+	t.Run("module not found", func(t *testing.T) {
+		if _, err := NewSigner(Options{ModulePath: "/non-existent/module.so", KeyLabel: "key"}); err == nil {
+			t.Error("NewSigner() expects error, got nil")
+		}
+	})
+
+	tuple := testhelper.GetRSALeafCertificate()
+	certs := []*x509.Certificate{tuple.Cert, testhelper.GetRSARootCertificate().Cert}
+
+	t.Run("wrong PIN", func(t *testing.T) {
+		modulePath, slot := setupSoftHSM(t, "release-key", tuple.PrivateKey, certs)
+		if _, err := NewSigner(Options{
+			ModulePath: modulePath,
+			Slot:       slot,
+			PIN:        "0000",
+			KeyLabel:   "release-key",
+		}); err == nil {
+			t.Error("NewSigner() expects error, got nil")
+		}
+	})
+
+	t.Run("key not found", func(t *testing.T) {
+		modulePath, slot := setupSoftHSM(t, "release-key", tuple.PrivateKey, certs)
+		if _, err := NewSigner(Options{
+			ModulePath: modulePath,
+			Slot:       slot,
+			PIN:        testUserPIN,
+			KeyLabel:   "other-key",
+		}); err == nil {
+			t.Error("NewSigner() expects error, got nil")
+		}
+	})
+}
+
+// fakeObject is an object on fakeModule.
+type fakeObject struct {
+	attrs []*pkcs11.Attribute
+
+	// key signs for private key objects.
+	key crypto.Signer
+}
+
+// fakeModule is an in-memory PKCS #11 token, so that Signer is tested
+// without SoftHSM.
+type fakeModule struct {
+	pin       string
+	objects   []fakeObject
+	found     []pkcs11.ObjectHandle
+	signKey   crypto.Signer
+	finalized bool
+}
+
+// newFakeModule returns a token holding the private key labeled keyLabel
+// and the certificate chain. If publicKey is set, the public key object of
+// the EC key is also stored.
+func newFakeModule(keyLabel string, key crypto.Signer, certs []*x509.Certificate, publicKey bool) *fakeModule {
+	id := []byte{0x01}
+	keyAttrs := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, keyLabel),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+	}
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+		keyAttrs = append(keyAttrs,
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, rsaKey.N.Bytes()),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, big.NewInt(int64(rsaKey.E)).Bytes()),
+		)
+	}
+	m := &fakeModule{
+		pin:     testUserPIN,
+		objects: []fakeObject{{attrs: keyAttrs, key: key}},
+	}
+	if ecKey, ok := key.(*ecdsa.PrivateKey); ok && publicKey {
+		point, _ := asn1.Marshal(elliptic.Marshal(ecKey.Curve, ecKey.X, ecKey.Y))
+		m.objects = append(m.objects, fakeObject{attrs: []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, id),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, point),
+		}})
+	}
+	for i, cert := range certs {
+		certID := id
+		if i > 0 {
+			certID = []byte{0xca, byte(i)}
+		}
+		m.objects = append(m.objects, fakeObject{attrs: []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+			pkcs11.NewAttribute(pkcs11.CKA_CERTIFICATE_TYPE, pkcs11.CKC_X_509),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, certID),
+			pkcs11.NewAttribute(pkcs11.CKA_VALUE, cert.Raw),
+		}})
+	}
+	return m
+}
+
+func (m *fakeModule) Finalize() error { m.finalized = true; return nil }
+
+func (m *fakeModule) Destroy() {}
+
+func (m *fakeModule) OpenSession(slotID uint, flags uint) (pkcs11.SessionHandle, error) {
+	return 1, nil
+}
+
+func (m *fakeModule) CloseSession(sh pkcs11.SessionHandle) error { return nil }
+
+func (m *fakeModule) Login(sh pkcs11.SessionHandle, userType uint, pin string) error {
+	if pin != m.pin {
+		return pkcs11.Error(pkcs11.CKR_PIN_INCORRECT)
+	}
+	return nil
+}
+
+func (m *fakeModule) Logout(sh pkcs11.SessionHandle) error { return nil }
+
+func (m *fakeModule) FindObjectsInit(sh pkcs11.SessionHandle, temp []*pkcs11.Attribute) error {
+	m.found = nil
+	for i, obj := range m.objects {
+		matched := true
+		for _, want := range temp {
+			attr := obj.attr(want.Type)
+			matched = matched && attr != nil && bytes.Equal(attr.Value, want.Value)
+		}
+		if matched {
+			m.found = append(m.found, pkcs11.ObjectHandle(i+1))
+		}
+	}
+	return nil
+}
+
+func (m *fakeModule) FindObjects(sh pkcs11.SessionHandle, max int) ([]pkcs11.ObjectHandle, bool, error) {
+	if max > len(m.found) {
+		max = len(m.found)
+	}
+	found := m.found[:max]
+	m.found = m.found[max:]
+	return found, false, nil
+}
+
+func (m *fakeModule) FindObjectsFinal(sh pkcs11.SessionHandle) error { return nil }
+
+func (m *fakeModule) GetAttributeValue(sh pkcs11.SessionHandle, o pkcs11.ObjectHandle, a []*pkcs11.Attribute) ([]*pkcs11.Attribute, error) {
+	obj := m.objects[o-1]
+	attrs := make([]*pkcs11.Attribute, 0, len(a))
+	for _, want := range a {
+		attr := obj.attr(want.Type)
+		if attr == nil {
+			return nil, pkcs11.Error(pkcs11.CKR_ATTRIBUTE_TYPE_INVALID)
+		}
+		attrs = append(attrs, attr)
+	}
+	return attrs, nil
+}
+
+func (m *fakeModule) SignInit(sh pkcs11.SessionHandle, mech []*pkcs11.Mechanism, o pkcs11.ObjectHandle) error {
+	m.signKey = m.objects[o-1].key
+	return nil
+}
+
+// Sign signs the digest as CKM_RSA_PKCS_PSS or CKM_ECDSA.
+func (m *fakeModule) Sign(sh pkcs11.SessionHandle, digest []byte) ([]byte, error) {
+	hash := map[int]crypto.Hash{32: crypto.SHA256, 48: crypto.SHA384, 64: crypto.SHA512}[len(digest)]
+	switch key := m.signKey.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPSS(rand.Reader, key, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, err
+		}
+		keyBytes := (key.Curve.Params().BitSize + 7) / 8
+		return append(r.FillBytes(make([]byte, keyBytes)), s.FillBytes(make([]byte, keyBytes))...), nil
+	}
+	return nil, pkcs11.Error(pkcs11.CKR_KEY_TYPE_INCONSISTENT)
+}
+
+// attr returns the attribute of the type or nil if not found.
+func (o fakeObject) attr(typ uint) *pkcs11.Attribute {
+	for _, attr := range o.attrs {
+		if attr.Type == typ {
+			return attr
+		}
+	}
+	return nil
+}
+
+func TestSignWithFakeModule(t *testing.T) {
+	rsaLeaf := testhelper.GetRSALeafCertificate()
+	ecLeaf := testhelper.GetECLeafCertificate()
+	tests := []struct {
+		name      string
+		key       crypto.Signer
+		certs     []*x509.Certificate
+		publicKey bool
+	}{
+		{
+			name:  "RSA",
+			key:   rsaLeaf.PrivateKey,
+			certs: []*x509.Certificate{rsaLeaf.Cert, testhelper.GetRSARootCertificate().Cert},
+		},
+		{
+			name:      "EC with public key object",
+			key:       ecLeaf.PrivateKey,
+			certs:     []*x509.Certificate{ecLeaf.Cert, testhelper.GetECRootCertificate().Cert},
+			publicKey: true,
+		},
+		{
+			name:  "EC without public key object",
+			key:   ecLeaf.PrivateKey,
+			certs: []*x509.Certificate{ecLeaf.Cert, testhelper.GetECRootCertificate().Cert},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newFakeModule("release-key", tt.key, tt.certs, tt.publicKey)
+			signer, err := newSigner(m, Options{PIN: testUserPIN, KeyLabel: "release-key"})
+			if err != nil {
+				t.Fatalf("newSigner() error = %v", err)
+			}
+			payload := []byte("payload")
+			sig, certs, err := signer.Sign(payload)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if len(certs) != 2 || !certs[0].Equal(tt.certs[0]) || !certs[1].Equal(tt.certs[1]) {
+				t.Fatalf("unexpected certificate chain %v", certs)
+			}
+			if err := signature.VerifyRawSignature(signer.keySpec.SignatureAlgorithm(), tt.certs[0].PublicKey, payload, sig); err != nil {
+				t.Fatalf("invalid signature: %v", err)
+			}
+			if err := signer.Close(); err != nil || !m.finalized {
+				t.Fatalf("Close() error = %v, finalized = %v", err, m.finalized)
+			}
+			if _, _, err := signer.Sign(payload); err == nil {
+				t.Fatal("Sign() expects error after Close(), got nil")
+			}
+		})
+	}
+}
+
+func TestNewSignerWithFakeModuleErrors(t *testing.T) {
+	rsaLeaf := testhelper.GetRSALeafCertificate()
+	ecLeaf := testhelper.GetECLeafCertificate()
+	otherRSAKey := testhelper.GetRSACertTuple(3072).PrivateKey
+	otherECKey := testhelper.GetECCertTuple(elliptic.P256()).PrivateKey
+	tests := []struct {
+		name      string
+		module    *fakeModule
+		opts      Options
+		expectErr string
+	}{
+		{
+			name:      "wrong PIN",
+			module:    newFakeModule("release-key", rsaLeaf.PrivateKey, []*x509.Certificate{rsaLeaf.Cert}, false),
+			opts:      Options{PIN: "0000", KeyLabel: "release-key"},
+			expectErr: "failed to log in token",
+		},
+		{
+			name:      "key not found",
+			module:    newFakeModule("release-key", rsaLeaf.PrivateKey, []*x509.Certificate{rsaLeaf.Cert}, false),
+			opts:      Options{PIN: testUserPIN, KeyLabel: "other-key"},
+			expectErr: `private key with label "other-key" is not found`,
+		},
+		{
+			name:      "certificate not found",
+			module:    newFakeModule("release-key", rsaLeaf.PrivateKey, nil, false),
+			opts:      Options{PIN: testUserPIN, KeyLabel: "release-key"},
+			expectErr: `certificate of private key "release-key" is not found`,
+		},
+		{
+			name:      "mismatched RSA key",
+			module:    newFakeModule("release-key", otherRSAKey, []*x509.Certificate{rsaLeaf.Cert}, false),
+			opts:      Options{PIN: testUserPIN, KeyLabel: "release-key"},
+			expectErr: `private key "release-key" does not match the signing certificate`,
+		},
+		{
+			name:      "mismatched EC public key object",
+			module:    newFakeModule("release-key", otherECKey, []*x509.Certificate{ecLeaf.Cert}, true),
+			opts:      Options{PIN: testUserPIN, KeyLabel: "release-key"},
+			expectErr: `private key "release-key" does not match the signing certificate`,
+		},
+		{
+			name:      "mismatched EC key",
+			module:    newFakeModule("release-key", otherECKey, []*x509.Certificate{ecLeaf.Cert}, false),
+			opts:      Options{PIN: testUserPIN, KeyLabel: "release-key"},
+			expectErr: `private key "release-key" does not match the signing certificate`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newSigner(tt.module, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Fatalf("newSigner() expects error containing %q, got %v", tt.expectErr, err)
+			}
+			if !tt.module.finalized {
+				t.Error("expect the module to be finalized on failure")
+			}
+		})
+	}
+}
+
+func TestMatchECPoint(t *testing.T) {
+	key := testhelper.GetECLeafCertificate().PrivateKey
+	point := elliptic.Marshal(key.Curve, key.X, key.Y)
+	encoded, err := asn1.Marshal(point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ecPoint := range [][]byte{point, encoded} {
+		if err := matchECPoint(ecPoint, &key.PublicKey); err != nil {
+			t.Errorf("matchECPoint() error = %v", err)
+		}
+	}
+	if err := matchECPoint(encoded[:len(encoded)-1], &key.PublicKey); err == nil {
+		t.Error("matchECPoint() expects error for truncated point, got nil")
+	}
+	if err := matchECPoint(encoded, &testhelper.GetRSALeafCertificate().PrivateKey.PublicKey); err == nil {
+		t.Error("matchECPoint() expects error for RSA key, got nil")
+	}
+}
+
+func TestBuildCertificateChain(t *testing.T) {
+	leaf := testhelper.GetRSALeafCertificate().Cert
+	root := testhelper.GetRSARootCertificate().Cert
+	other := testhelper.GetECRootCertificate().Cert
+
+	tests := []struct {
+		name   string
+		leaf   *x509.Certificate
+		certs  []*x509.Certificate
+		expect []*x509.Certificate
+	}{
+		{
+			name:   "complete chain",
+			leaf:   leaf,
+			certs:  []*x509.Certificate{other, leaf, root},
+			expect: []*x509.Certificate{leaf, root},
+		},
+		{
+			name:   "issuer not found",
+			leaf:   leaf,
+			certs:  []*x509.Certificate{other, leaf},
+			expect: []*x509.Certificate{leaf},
+		},
+		{
+			name:   "self-signed leaf",
+			leaf:   root,
+			certs:  []*x509.Certificate{root, leaf},
+			expect: []*x509.Certificate{root},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := buildCertificateChain(tt.leaf, tt.certs)
+			if len(chain) != len(tt.expect) {
+				t.Fatalf("expect chain of %d certificates, got %d", len(tt.expect), len(chain))
+			}
+			for i := range chain {
+				if !chain[i].Equal(tt.expect[i]) {
+					t.Errorf("expect certificate %d to be %v, got %v", i, tt.expect[i].Subject, chain[i].Subject)
+				}
+			}
+		})
+	}
+}
+
+func TestSigningMechanism(t *testing.T) {
+	tests := []struct {
+		keyType   signature.KeyType
+		hash      crypto.Hash
+		expect    uint
+		expectErr bool
+	}{
+		{keyType: signature.KeyTypeRSA, hash: crypto.SHA256, expect: pkcs11.CKM_RSA_PKCS_PSS},
+		{keyType: signature.KeyTypeRSA, hash: crypto.SHA384, expect: pkcs11.CKM_RSA_PKCS_PSS},
+		{keyType: signature.KeyTypeRSA, hash: crypto.SHA512, expect: pkcs11.CKM_RSA_PKCS_PSS},
+		{keyType: signature.KeyTypeRSA, hash: crypto.SHA1, expectErr: true},
+		{keyType: signature.KeyTypeEC, hash: crypto.SHA256, expect: pkcs11.CKM_ECDSA},
+		{keyType: signature.KeyTypeEd25519, hash: crypto.SHA512, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d %v", tt.keyType, tt.hash), func(t *testing.T) {
+			mechanism, err := signingMechanism(tt.keyType, tt.hash)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("signingMechanism() error = %v, expectErr = %v", err, tt.expectErr)
+			}
+			if err == nil && mechanism.Mechanism != tt.expect {
+				t.Errorf("signingMechanism() = %#x, want %#x", mechanism.Mechanism, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pkcs7 implements the signature.Envelope interface with the CMS
+// (PKCS #7) SignedData, which is understood by S/MIME-style verifiers.
+//
+// The notary signed attributes are mapped onto CMS signed attributes: the
+// signing time onto the signing-time attribute, the content type of the
+// payload onto the content description of the content-hints attribute. The
+// certificate chain is carried in the certificates of the SignedData, and the
+// timestamp signature in the time-stamp token unsigned attribute.
+//
+// CMS has no standard signed attributes for the signing scheme and the
+// expiry. Signing requests with the notary.x509.signingAuthority signing
+// scheme or a non-zero expiry are rejected with InvalidSignRequestError, and
+// parsed envelopes always have the notary.x509 signing scheme and no expiry.
+// Extended signed attributes and the signing agent are not supported, and
+// Ed25519 keys are not supported for signing.
+//
+// References:
+//   - https://www.rfc-editor.org/rfc/rfc5652#section-5
+//   - https://www.rfc-editor.org/rfc/rfc5751#section-3.5.3
+package pkcs7
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/cms"
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/internal/base"
+)
+
+// MediaTypeEnvelope defines the media type name of CMS signature envelope.
+const MediaTypeEnvelope = "application/pkcs7-signature"
+
+func init() {
+	if err := signature.RegisterEnvelopeType(MediaTypeEnvelope, NewEnvelope, ParseEnvelope); err != nil {
+		panic(err)
+	}
+}
+
+type envelope struct {
+	base *cms.ParsedSignedData
+}
+
+// NewEnvelope generates a CMS signature envelope.
+func NewEnvelope() signature.Envelope {
+	return &base.Envelope{
+		Envelope: &envelope{},
+	}
+}
+
+// ParseEnvelope parses the envelope bytes and return a CMS signature
+// envelope. BER-encoded envelopes are normalized to DER before parsing.
+func ParseEnvelope(envelopeBytes []byte) (signature.Envelope, error) {
+	signedData, err := cms.ParseSignedData(envelopeBytes)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	return &base.Envelope{
+		Envelope: &envelope{base: signedData},
+		Raw:      envelopeBytes,
+	}, nil
+}
+
+// Sign generates and sign the envelope according to the sign request.
+// The payload is omitted from the encapsulated content if
+// req.DetachedPayload is set.
+func (e *envelope) Sign(req *signature.SignRequest) ([]byte, error) {
+	if len(req.ExtendedSignedAttributes) > 0 {
+		return nil, &signature.InvalidSignRequestError{Msg: "extended signed attributes are not supported by CMS envelope"}
+	}
+
+	// local signers do not sign by themselves, and are backed by a signer
+	// signing the signed attributes as is
+	signer := req.Signer
+	if localSigner, ok := signer.(signature.LocalSigner); ok {
+		var err error
+		if signer, err = signature.NewSignerFromLocal(localSigner); err != nil {
+			return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+		}
+	}
+	keySpec, err := signer.KeySpec()
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	digestAlg, sigAlg, err := algorithmIdentifiers(keySpec)
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+
+	// sign the DER encoding of the signed attributes
+	signedAttrs, err := generateSignedAttributes(req, keySpec.SignatureAlgorithm().Hash())
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	encodedAttrs, err := asn1.MarshalWithParams(signedAttrs, "set")
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	sig, certs, err := signer.Sign(encodedAttrs)
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	if len(certs) == 0 {
+		return nil, &signature.InvalidSignRequestError{Msg: "certificate chain is not set"}
+	}
+	if keySpec.Type == signature.KeyTypeEC {
+		// CMS carries ECDSA signatures as DER-encoded ECDSA-Sig-Value
+		if sig, err = ecdsaDERSignature(sig); err != nil {
+			return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+		}
+	}
+
+	// timestamp the signature of CMS envelope
+	var unsignedAttrs cms.Attributes
+	if req.Timestamper != nil {
+		token, err := req.Timestamper.Timestamp(sig, keySpec.SignatureAlgorithm().Hash())
+		if err != nil {
+			return nil, &signature.TimestampError{Err: err}
+		}
+		unsignedAttrs = cms.Attributes{newAttribute(cms.OIDAttributeTimeStampToken, token)}
+	}
+
+	// generate envelope
+	sid, err := issuerAndSerialNumber(certs[0])
+	if err != nil {
+		return nil, &signature.InvalidSignRequestError{Msg: err.Error()}
+	}
+	var content []byte
+	if !req.DetachedPayload {
+		content = req.Payload.Content
+	}
+	encoded, err := generateSignedData(content, certs, nil, cms.SignerInfo{
+		Version:            1,
+		SignerIdentifier:   sid,
+		DigestAlgorithm:    digestAlg,
+		SignedAttributes:   signedAttrs,
+		SignatureAlgorithm: sigAlg,
+		Signature:          sig,
+		UnsignedAttributes: unsignedAttrs,
+	})
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	signedData, err := cms.ParseSignedData(encoded)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	e.base = signedData
+	return encoded, nil
+}
+
+// SetTimestampSignature implements signature.TimestampSetter interface.
+// Only the unsigned attributes of the SignerInfo are updated.
+func (e *envelope) SetTimestampSignature(token []byte) ([]byte, error) {
+	signerInfo, err := e.signerInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	unsignedAttrs := cms.Attributes{newAttribute(cms.OIDAttributeTimeStampToken, token)}
+	for _, attr := range signerInfo.UnsignedAttributes {
+		if !attr.Type.Equal(cms.OIDAttributeTimeStampToken) {
+			unsignedAttrs = append(unsignedAttrs, attr)
+		}
+	}
+	updated := *signerInfo
+	updated.UnsignedAttributes = unsignedAttrs
+	encoded, err := generateSignedData(e.base.Content, e.base.Certificates, e.base.CRLs, updated)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	signedData, err := cms.ParseSignedData(encoded)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	e.base = signedData
+	return encoded, nil
+}
+
+// Verify verifies the envelope and returns its enclosed payload and signer info.
+func (e *envelope) Verify() (*signature.EnvelopeContent, error) {
+	return e.VerifyWithOptions(signature.VerifyOptions{})
+}
+
+// VerifyWithOptions implements signature.OptionsVerifier interface.
+func (e *envelope) VerifyWithOptions(opts signature.VerifyOptions) (*signature.EnvelopeContent, error) {
+	content, err := e.Content()
+	if err != nil {
+		return nil, err
+	}
+	if alg := content.SignerInfo.SignatureAlgorithm; alg.IsLegacy() && !opts.AllowLegacyAlgorithms {
+		return nil, &signature.UnsupportedSignatureAlgoError{Alg: alg.String()}
+	}
+
+	payload := e.base.Content
+	detached := payload == nil
+	if detached {
+		if len(opts.DetachedPayload) == 0 {
+			return nil, &signature.InvalidArgumentError{
+				Param: "opts.DetachedPayload",
+				Err:   errors.New("payload is detached from CMS envelope but not provided"),
+			}
+		}
+		payload = opts.DetachedPayload
+	} else if opts.DetachedPayload != nil {
+		return nil, &signature.InvalidArgumentError{
+			Param: "opts.DetachedPayload",
+			Err:   errors.New("payload is embedded in CMS envelope"),
+		}
+	}
+
+	// core verify process, verify the signature over the signed attributes
+	// and the message digest of the payload
+	if _, err := e.base.VerifySigner(&e.base.Signers[0], payload); err != nil {
+		return nil, &signature.SignatureIntegrityError{Err: err}
+	}
+
+	if detached {
+		content.Payload.Content = opts.DetachedPayload
+	}
+	return content, nil
+}
+
+// Content returns the payload and signer information of the envelope.
+// Content is trusted only after the successful call to `Verify()`.
+func (e *envelope) Content() (*signature.EnvelopeContent, error) {
+	signerInfo, err := e.signerInfo()
+	if err != nil {
+		return nil, err
+	}
+	if !e.base.ContentType.Equal(cms.OIDData) {
+		return nil, &signature.InvalidSignatureError{Msg: "encapsulated content type is not id-data"}
+	}
+
+	// parse signed attributes
+	signedAttrs, contentType, err := parseSignedAttributes(signerInfo.SignedAttributes)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+
+	// parse certificate chain starting with the signing certificate
+	cert, err := e.base.SigningCertificate(signerInfo)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+	if len(e.base.Certificates) == 0 || !e.base.Certificates[0].Equal(cert) {
+		return nil, &signature.InvalidSignatureError{Msg: "certificate chain does not start with the signing certificate"}
+	}
+	sigAlg, err := signatureAlgorithm(signerInfo, cert)
+	if err != nil {
+		return nil, &signature.InvalidSignatureError{Msg: err.Error()}
+	}
+
+	// parse unsigned attributes
+	var token asn1.RawValue
+	switch err := signerInfo.UnsignedAttributes.Get(cms.OIDAttributeTimeStampToken, &token); {
+	case errors.Is(err, cms.ErrAttributeNotFound):
+	case err != nil:
+		return nil, &signature.InvalidSignatureError{Msg: "malformed timestamp signature"}
+	}
+
+	if len(signerInfo.Signature) == 0 {
+		return nil, &signature.InvalidSignatureError{Msg: "signature missing in CMS envelope"}
+	}
+
+	return &signature.EnvelopeContent{
+		SignerInfo: signature.SignerInfo{
+			SignedAttributes: *signedAttrs,
+			UnsignedAttributes: signature.UnsignedAttributes{
+				TimestampSignature: token.FullBytes,
+			},
+			SignatureAlgorithm: sigAlg,
+			CertificateChain:   e.base.Certificates,
+			Signature:          signerInfo.Signature,
+		},
+		Payload: signature.Payload{
+			ContentType: contentType,
+			Content:     e.base.Content,
+			Detached:    e.base.Content == nil,
+		},
+	}, nil
+}
+
+// signerInfo returns the only SignerInfo of the CMS envelope. The notary
+// signed attributes are signed by a single signer.
+func (e *envelope) signerInfo() (*cms.SignerInfo, error) {
+	if e.base == nil {
+		return nil, &signature.SignatureEnvelopeNotFoundError{}
+	}
+	if len(e.base.Signers) != 1 {
+		return nil, &signature.InvalidSignatureError{
+			Msg: fmt.Sprintf("CMS envelope must have exactly one signer, got %d", len(e.base.Signers))}
+	}
+	return &e.base.Signers[0], nil
+}
@@ -0,0 +1,538 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs7
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/cms"
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/internal/signaturetest"
+	"github.com/notaryproject/notation-core-go/testhelper"
+)
+
+const payloadString = `{"targetArtifact":{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:73c803930ea3ba1e54bc25c2bdc53edd0284c62ed651fe7b00369da519a3c333","size":16724}}`
+
+func newSignRequest(keyType signature.KeyType, size int) (*signature.SignRequest, error) {
+	signer, err := signaturetest.GetTestLocalSigner(keyType, size)
+	if err != nil {
+		return nil, err
+	}
+	return &signature.SignRequest{
+		Payload: signature.Payload{
+			ContentType: "application/vnd.cncf.notary.payload.v1+json",
+			Content:     []byte(payloadString),
+		},
+		Signer:        signer,
+		SigningTime:   time.Now().Truncate(time.Second),
+		SigningScheme: signature.SigningSchemeX509,
+	}, nil
+}
+
+func signEnvelope(t *testing.T) (*signature.SignRequest, []byte) {
+	t.Helper()
+	req, err := newSignRequest(signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+	encoded, err := NewEnvelope().Sign(req)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	return req, encoded
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, keyType := range []signature.KeyType{signature.KeyTypeRSA, signature.KeyTypeEC} {
+		for _, size := range signaturetest.GetKeySizes(keyType) {
+			t.Run(fmt.Sprintf("with %v keyType, %v keySize", keyType, size), func(t *testing.T) {
+				req, err := newSignRequest(keyType, size)
+				if err != nil {
+					t.Fatalf("newSignRequest() failed. Error = %s", err)
+				}
+				encoded, err := NewEnvelope().Sign(req)
+				if err != nil {
+					t.Fatalf("Sign() failed. Error = %s", err)
+				}
+
+				env, err := signature.ParseEnvelope(MediaTypeEnvelope, encoded)
+				if err != nil {
+					t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+				}
+				content, err := env.Verify()
+				if err != nil {
+					t.Fatalf("Verify() failed. Error = %s", err)
+				}
+
+				if content.Payload.ContentType != req.Payload.ContentType {
+					t.Errorf("expected content type %q, but got %q", req.Payload.ContentType, content.Payload.ContentType)
+				}
+				if !bytes.Equal(content.Payload.Content, req.Payload.Content) {
+					t.Errorf("expected payload %q, but got %q", req.Payload.Content, content.Payload.Content)
+				}
+				signedAttrs := content.SignerInfo.SignedAttributes
+				if signedAttrs.SigningScheme != signature.SigningSchemeX509 {
+					t.Errorf("expected signing scheme %v, but got %v", signature.SigningSchemeX509, signedAttrs.SigningScheme)
+				}
+				if !signedAttrs.SigningTime.Equal(req.SigningTime) {
+					t.Errorf("expected signing time %v, but got %v", req.SigningTime, signedAttrs.SigningTime)
+				}
+				if !signedAttrs.Expiry.IsZero() {
+					t.Errorf("expected no expiry, but got %v", signedAttrs.Expiry)
+				}
+				keySpec, _ := req.Signer.KeySpec()
+				if alg := content.SignerInfo.SignatureAlgorithm; alg != keySpec.SignatureAlgorithm() {
+					t.Errorf("expected signature algorithm %v, but got %v", keySpec.SignatureAlgorithm(), alg)
+				}
+				certs, _ := req.Signer.(signature.LocalSigner).CertificateChain()
+				if len(content.SignerInfo.CertificateChain) != len(certs) {
+					t.Fatalf("expected %d certificates, but got %d", len(certs), len(content.SignerInfo.CertificateChain))
+				}
+				for i, cert := range certs {
+					if !cert.Equal(content.SignerInfo.CertificateChain[i]) {
+						t.Errorf("certificate %d does not match", i)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSignAndVerifyWithSigner(t *testing.T) {
+	signer, err := signaturetest.GetTestSigner(signature.KeyTypeEC, 384)
+	if err != nil {
+		t.Fatalf("GetTestSigner() failed. Error = %s", err)
+	}
+	req, err := newSignRequest(signature.KeyTypeEC, 384)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+	req.Signer = signer
+	encoded, err := NewEnvelope().Sign(req)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	if _, err := env.Verify(); err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+}
+
+func TestSignedAttributes(t *testing.T) {
+	_, encoded := signEnvelope(t)
+	signedData, err := cms.ParseSignedData(encoded)
+	if err != nil {
+		t.Fatalf("ParseSignedData() failed. Error = %s", err)
+	}
+	if _, err := signedData.Verify(); err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	attrs := signedData.Signers[0].SignedAttributes
+	expectedOIDs := []asn1.ObjectIdentifier{
+		cms.OIDAttributeContentType,
+		cms.OIDAttributeMessageDigest,
+		cms.OIDAttributeSigningTime,
+		cms.OIDAttributeContentHints,
+	}
+	if len(attrs) != len(expectedOIDs) {
+		t.Errorf("expected %d signed attributes, but got %d", len(expectedOIDs), len(attrs))
+	}
+	for _, oid := range expectedOIDs {
+		if !attrs.Has(oid) {
+			t.Errorf("expected signed attribute %s", oid)
+		}
+	}
+	var hints contentHints
+	if err := attrs.Get(cms.OIDAttributeContentHints, &hints); err != nil {
+		t.Fatalf("Get() failed. Error = %s", err)
+	}
+	if hints.ContentDescription != "application/vnd.cncf.notary.payload.v1+json" {
+		t.Errorf("unexpected content description %q", hints.ContentDescription)
+	}
+}
+
+func TestSignErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *signature.SignRequest) error
+	}{
+		{
+			name: "extended signed attributes",
+			modify: func(req *signature.SignRequest) error {
+				req.ExtendedSignedAttributes = []signature.Attribute{{Key: "key", Value: "value"}}
+				return nil
+			},
+		},
+		{
+			name: "Ed25519 key",
+			modify: func(req *signature.SignRequest) (err error) {
+				req.Signer, err = signaturetest.GetTestLocalSigner(signature.KeyTypeEd25519, 256)
+				return err
+			},
+		},
+		{
+			name: "missing content type",
+			modify: func(req *signature.SignRequest) error {
+				req.Payload.ContentType = ""
+				return nil
+			},
+		},
+		{
+			name: "signing authority scheme",
+			modify: func(req *signature.SignRequest) error {
+				req.SigningScheme = signature.SigningSchemeX509SigningAuthority
+				return nil
+			},
+		},
+		{
+			name: "expiry",
+			modify: func(req *signature.SignRequest) error {
+				req.Expiry = req.SigningTime.Add(time.Hour)
+				return nil
+			},
+		},
+		{
+			name: "unknown signing scheme",
+			modify: func(req *signature.SignRequest) error {
+				req.SigningScheme = "notary.unknown"
+				return nil
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := newSignRequest(signature.KeyTypeEC, 256)
+			if err != nil {
+				t.Fatalf("newSignRequest() failed. Error = %s", err)
+			}
+			if err := tt.modify(req); err != nil {
+				t.Fatalf("modify() failed. Error = %s", err)
+			}
+			_, err = (&envelope{}).Sign(req)
+			var signReqErr *signature.InvalidSignRequestError
+			if !errors.As(err, &signReqErr) {
+				t.Fatalf("expected InvalidSignRequestError, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestDetachedPayload(t *testing.T) {
+	req, err := newSignRequest(signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+	req.DetachedPayload = true
+	encoded, err := NewEnvelope().Sign(req)
+	if err != nil {
+		t.Fatalf("Sign() failed. Error = %s", err)
+	}
+	if bytes.Contains(encoded, req.Payload.Content) {
+		t.Fatal("expected payload to be detached from the envelope")
+	}
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+
+	content, err := env.Content()
+	if err != nil {
+		t.Fatalf("Content() failed. Error = %s", err)
+	}
+	if !content.Payload.Detached || content.Payload.Content != nil {
+		t.Fatalf("expected detached payload, but got %+v", content.Payload)
+	}
+
+	var argErr *signature.InvalidArgumentError
+	if _, err := env.Verify(); !errors.As(err, &argErr) {
+		t.Fatalf("expected InvalidArgumentError, but got %v", err)
+	}
+	var integrityErr *signature.SignatureIntegrityError
+	if _, err := signature.Verify(env, signature.VerifyOptions{DetachedPayload: []byte("tampered")}); !errors.As(err, &integrityErr) {
+		t.Fatalf("expected SignatureIntegrityError, but got %v", err)
+	}
+	content, err = signature.Verify(env, signature.VerifyOptions{DetachedPayload: req.Payload.Content})
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	if !bytes.Equal(content.Payload.Content, req.Payload.Content) {
+		t.Fatalf("expected payload %q, but got %q", req.Payload.Content, content.Payload.Content)
+	}
+
+	// embedded payload must not be provided again
+	_, encoded = signEnvelope(t)
+	if env, err = ParseEnvelope(encoded); err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	if _, err := signature.Verify(env, signature.VerifyOptions{DetachedPayload: req.Payload.Content}); !errors.As(err, &argErr) {
+		t.Fatalf("expected InvalidArgumentError, but got %v", err)
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	t.Run("tampered payload", func(t *testing.T) {
+		req, _ := signEnvelope(t)
+		env := &envelope{}
+		if _, err := env.Sign(req); err != nil {
+			t.Fatalf("Sign() failed. Error = %s", err)
+		}
+		env.base.Content = []byte("tampered")
+		var integrityErr *signature.SignatureIntegrityError
+		if _, err := env.Verify(); !errors.As(err, &integrityErr) {
+			t.Fatalf("expected SignatureIntegrityError, but got %v", err)
+		}
+	})
+
+	t.Run("tampered signature", func(t *testing.T) {
+		req, _ := signEnvelope(t)
+		env := &envelope{}
+		if _, err := env.Sign(req); err != nil {
+			t.Fatalf("Sign() failed. Error = %s", err)
+		}
+		env.base.Signers[0].Signature[0] ^= 0xff
+		var integrityErr *signature.SignatureIntegrityError
+		if _, err := env.Verify(); !errors.As(err, &integrityErr) {
+			t.Fatalf("expected SignatureIntegrityError, but got %v", err)
+		}
+	})
+
+	t.Run("missing signer", func(t *testing.T) {
+		req, _ := signEnvelope(t)
+		env := &envelope{}
+		if _, err := env.Sign(req); err != nil {
+			t.Fatalf("Sign() failed. Error = %s", err)
+		}
+		env.base.Signers = nil
+		var sigErr *signature.InvalidSignatureError
+		if _, err := env.Verify(); !errors.As(err, &sigErr) {
+			t.Fatalf("expected InvalidSignatureError, but got %v", err)
+		}
+	})
+
+	t.Run("missing content-hints attribute", func(t *testing.T) {
+		req, _ := signEnvelope(t)
+		env := &envelope{}
+		if _, err := env.Sign(req); err != nil {
+			t.Fatalf("Sign() failed. Error = %s", err)
+		}
+		var attrs cms.Attributes
+		for _, attr := range env.base.Signers[0].SignedAttributes {
+			if !attr.Type.Equal(cms.OIDAttributeContentHints) {
+				attrs = append(attrs, attr)
+			}
+		}
+		env.base.Signers[0].SignedAttributes = attrs
+		var sigErr *signature.InvalidSignatureError
+		if _, err := env.Verify(); !errors.As(err, &sigErr) {
+			t.Fatalf("expected InvalidSignatureError, but got %v", err)
+		}
+	})
+
+	t.Run("envelope not found", func(t *testing.T) {
+		var notFoundErr *signature.SignatureEnvelopeNotFoundError
+		if _, err := (&envelope{}).Verify(); !errors.As(err, &notFoundErr) {
+			t.Fatalf("expected SignatureEnvelopeNotFoundError, but got %v", err)
+		}
+	})
+
+	t.Run("malformed envelope", func(t *testing.T) {
+		var sigErr *signature.InvalidSignatureError
+		if _, err := ParseEnvelope([]byte("malformed")); !errors.As(err, &sigErr) {
+			t.Fatalf("expected InvalidSignatureError, but got %v", err)
+		}
+	})
+}
+
+func TestParseEnvelopeBER(t *testing.T) {
+	req, encoded := signEnvelope(t)
+
+	// re-encode the length of the outer ContentInfo in the non-minimal long
+	// form, which is valid BER but not DER
+	var ci asn1.RawValue
+	if _, err := asn1.Unmarshal(encoded, &ci); err != nil {
+		t.Fatal(err)
+	}
+	length := len(ci.Bytes)
+	ber := []byte{0x30, 0x84, byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length)}
+	ber = append(ber, ci.Bytes...)
+
+	env, err := ParseEnvelope(ber)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	content, err := env.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	if !bytes.Equal(content.Payload.Content, req.Payload.Content) {
+		t.Fatalf("expected payload %q, but got %q", req.Payload.Content, content.Payload.Content)
+	}
+}
+
+func TestVerifyLegacyAlgorithm(t *testing.T) {
+	leaf := testhelper.GetRSALeafCertificate()
+	req, err := newSignRequest(signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+
+	// sign the signed attributes with RSASSA-PKCS1-v1_5
+	signedAttrs, err := generateSignedAttributes(req, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("generateSignedAttributes() failed. Error = %s", err)
+	}
+	encodedAttrs, err := asn1.MarshalWithParams(signedAttrs, "set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := crypto.SHA256.New()
+	digest.Write(encodedAttrs)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, leaf.PrivateKey, crypto.SHA256, digest.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid, err := issuerAndSerialNumber(leaf.Cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := generateSignedData(req.Payload.Content, []*x509.Certificate{leaf.Cert, testhelper.GetRSARootCertificate().Cert}, nil, cms.SignerInfo{
+		Version:            1,
+		SignerIdentifier:   sid,
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: cms.OIDDigestAlgorithmSHA256},
+		SignedAttributes:   signedAttrs,
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: cms.OIDSignatureAlgorithmSHA256WithRSA},
+		Signature:          sig,
+	})
+	if err != nil {
+		t.Fatalf("generateSignedData() failed. Error = %s", err)
+	}
+
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	var algErr *signature.UnsupportedSignatureAlgoError
+	if _, err := env.Verify(); !errors.As(err, &algErr) {
+		t.Fatalf("expected UnsupportedSignatureAlgoError, but got %v", err)
+	}
+	content, err := signature.Verify(env, signature.VerifyOptions{AllowLegacyAlgorithms: true})
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	if alg := content.SignerInfo.SignatureAlgorithm; alg != signature.AlgorithmRS256 {
+		t.Fatalf("expected signature algorithm %v, but got %v", signature.AlgorithmRS256, alg)
+	}
+}
+
+type mockTimestamper struct {
+	token     []byte
+	err       error
+	signature []byte
+	hash      crypto.Hash
+}
+
+func (m *mockTimestamper) Timestamp(signature []byte, hash crypto.Hash) ([]byte, error) {
+	m.signature = signature
+	m.hash = hash
+	return m.token, m.err
+}
+
+// mockToken is a DER-encoded value standing in for a timestamp token.
+var mockToken = []byte{0x04, 0x0f, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p', ' ', 't', 'o', 'k', 'e', 'n'}
+
+func TestSignWithTimestamper(t *testing.T) {
+	req, err := newSignRequest(signature.KeyTypeRSA, 3072)
+	if err != nil {
+		t.Fatalf("newSignRequest() failed. Error = %s", err)
+	}
+
+	t.Run("timestamp signature", func(t *testing.T) {
+		timestamper := &mockTimestamper{token: mockToken}
+		req.Timestamper = timestamper
+		env := &envelope{}
+		if _, err := env.Sign(req); err != nil {
+			t.Fatalf("Sign() failed. Error = %s", err)
+		}
+		content, err := env.Content()
+		if err != nil {
+			t.Fatalf("Content() failed. Error = %s", err)
+		}
+		if !bytes.Equal(timestamper.signature, content.SignerInfo.Signature) {
+			t.Fatalf("expected timestamped signature %x, but got %x", content.SignerInfo.Signature, timestamper.signature)
+		}
+		if timestamper.hash != crypto.SHA384 {
+			t.Fatalf("expected hash %v, but got %v", crypto.SHA384, timestamper.hash)
+		}
+		if token := content.SignerInfo.UnsignedAttributes.TimestampSignature; !bytes.Equal(token, timestamper.token) {
+			t.Fatalf("expected timestamp token %x, but got %x", timestamper.token, token)
+		}
+	})
+
+	t.Run("timestamper error", func(t *testing.T) {
+		req.Timestamper = &mockTimestamper{err: errors.New("tsa error")}
+		_, err := (&envelope{}).Sign(req)
+		var timestampErr *signature.TimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("expected TimestampError, but got %v", err)
+		}
+	})
+}
+
+func TestSetTimestampSignature(t *testing.T) {
+	_, encoded := signEnvelope(t)
+	env, err := ParseEnvelope(encoded)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	setter, ok := env.(signature.TimestampSetter)
+	if !ok {
+		t.Fatal("expected signature.TimestampSetter")
+	}
+	content, err := env.Content()
+	if err != nil {
+		t.Fatalf("Content() failed. Error = %s", err)
+	}
+	timestamped, err := setter.SetTimestampSignature(mockToken)
+	if err != nil {
+		t.Fatalf("SetTimestampSignature() failed. Error = %s", err)
+	}
+
+	env, err = ParseEnvelope(timestamped)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() failed. Error = %s", err)
+	}
+	timestampedContent, err := env.Verify()
+	if err != nil {
+		t.Fatalf("Verify() failed. Error = %s", err)
+	}
+	if got := timestampedContent.SignerInfo.UnsignedAttributes.TimestampSignature; !bytes.Equal(got, mockToken) {
+		t.Fatalf("expected timestamp token %x, but got %x", mockToken, got)
+	}
+	if !bytes.Equal(timestampedContent.SignerInfo.Signature, content.SignerInfo.Signature) {
+		t.Fatal("expected signature to be unchanged")
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs7
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/notaryproject/notation-core-go/cms"
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// contentHints is the value of the content-hints attribute.
+//
+//	ContentHints ::= SEQUENCE {
+//	 contentDescription  UTF8String (SIZE (1..MAX)) OPTIONAL,
+//	 contentType         ContentType }
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc2634#section-2.9
+type contentHints struct {
+	ContentDescription string `asn1:"utf8,optional"`
+	ContentType        asn1.ObjectIdentifier
+}
+
+// pssParameters is the parameters of the RSASSA-PSS signature algorithm.
+//
+//	RSASSA-PSS-params ::= SEQUENCE {
+//	 hashAlgorithm     [0] HashAlgorithm DEFAULT sha1,
+//	 maskGenAlgorithm  [1] MaskGenAlgorithm DEFAULT mgf1SHA1,
+//	 saltLength        [2] INTEGER DEFAULT 20,
+//	 trailerField      [3] TrailerField DEFAULT trailerFieldBC }
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc4055#section-3.1
+type pssParameters struct {
+	Hash         pkix.AlgorithmIdentifier `asn1:"explicit,tag:0"`
+	MGF          pkix.AlgorithmIdentifier `asn1:"explicit,tag:1"`
+	SaltLength   int                      `asn1:"explicit,tag:2"`
+	TrailerField int                      `asn1:"optional,explicit,tag:3,default:1"`
+}
+
+// ecdsaSignature is the DER encoding of an ECDSA signature.
+//
+//	ECDSA-Sig-Value ::= SEQUENCE {
+//	 r  INTEGER,
+//	 s  INTEGER }
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// digestAlgorithmOIDs maps the hash algorithms of the notary signature
+// algorithms to the digest algorithm OIDs.
+var digestAlgorithmOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA256: cms.OIDDigestAlgorithmSHA256,
+	crypto.SHA384: cms.OIDDigestAlgorithmSHA384,
+	crypto.SHA512: cms.OIDDigestAlgorithmSHA512,
+}
+
+// ecdsaAlgorithmOIDs maps the hash algorithms of the notary ECDSA signature
+// algorithms to the signature algorithm OIDs.
+var ecdsaAlgorithmOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA256: cms.OIDSignatureAlgorithmECDSAWithSHA256,
+	crypto.SHA384: cms.OIDSignatureAlgorithmECDSAWithSHA384,
+	crypto.SHA512: cms.OIDSignatureAlgorithmECDSAWithSHA512,
+}
+
+// legacyAlgorithms maps the hash algorithms to the verify-only RSASSA-PKCS1-v1_5
+// signature algorithms.
+var legacyAlgorithms = map[crypto.Hash]signature.Algorithm{
+	crypto.SHA256: signature.AlgorithmRS256,
+	crypto.SHA384: signature.AlgorithmRS384,
+	crypto.SHA512: signature.AlgorithmRS512,
+}
+
+// newAttribute returns an attribute with the single DER-encoded value.
+func newAttribute(oid asn1.ObjectIdentifier, value []byte) cms.Attribute {
+	return cms.Attribute{
+		Type: oid,
+		Values: asn1.RawValue{
+			Class:      asn1.ClassUniversal,
+			Tag:        asn1.TagSet,
+			IsCompound: true,
+			Bytes:      value,
+		},
+	}
+}
+
+// generateSignedAttributes maps the notary signed attributes of the sign
+// request onto the CMS signed attributes, sorted in the DER order of SET OF.
+func generateSignedAttributes(req *signature.SignRequest, hash crypto.Hash) (cms.Attributes, error) {
+	if req.SigningScheme != signature.SigningSchemeX509 {
+		return nil, fmt.Errorf("signing scheme %s is not supported by CMS envelope", req.SigningScheme)
+	}
+	if !req.Expiry.IsZero() {
+		return nil, errors.New("expiry is not supported by CMS envelope")
+	}
+	if req.Payload.ContentType == "" {
+		return nil, errors.New("payload content type is missing")
+	}
+	h := hash.New()
+	h.Write(req.Payload.Content)
+
+	values := []struct {
+		oid   asn1.ObjectIdentifier
+		value any
+	}{
+		{oid: cms.OIDAttributeContentType, value: cms.OIDData},
+		{oid: cms.OIDAttributeMessageDigest, value: h.Sum(nil)},
+		{oid: cms.OIDAttributeSigningTime, value: req.SigningTime.UTC()},
+		{oid: cms.OIDAttributeContentHints, value: contentHints{
+			ContentDescription: req.Payload.ContentType,
+			ContentType:        cms.OIDData,
+		}},
+	}
+	var attrs cms.Attributes
+	for _, v := range values {
+		value, err := asn1.Marshal(v.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode attribute %s: %w", v.oid, err)
+		}
+		attrs = append(attrs, newAttribute(v.oid, value))
+	}
+
+	// the signature is verified over the DER encoding of the attributes, so
+	// that they are kept in the sorted order of the encoding.
+	encoded, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return nil, err
+	}
+	var sorted cms.Attributes
+	if _, err := asn1.UnmarshalWithParams(encoded, &sorted, "set"); err != nil {
+		return nil, err
+	}
+	return sorted, nil
+}
+
+// parseSignedAttributes parses the CMS signed attributes into the notary
+// signed attributes and the content type of the payload.
+func parseSignedAttributes(attrs cms.Attributes) (*signature.SignedAttributes, string, error) {
+	signedAttrs := signature.SignedAttributes{
+		SigningScheme: signature.SigningSchemeX509,
+	}
+	if err := attrs.Get(cms.OIDAttributeSigningTime, &signedAttrs.SigningTime); err != nil {
+		return nil, "", fmt.Errorf("invalid signing-time attribute: %w", err)
+	}
+	var hints contentHints
+	if err := attrs.Get(cms.OIDAttributeContentHints, &hints); err != nil {
+		return nil, "", fmt.Errorf("invalid content-hints attribute: %w", err)
+	}
+	if !hints.ContentType.Equal(cms.OIDData) {
+		return nil, "", errors.New("content type of content-hints attribute is not id-data")
+	}
+	if hints.ContentDescription == "" {
+		return nil, "", errors.New("content description of content-hints attribute is missing")
+	}
+
+	return &signedAttrs, hints.ContentDescription, nil
+}
+
+// algorithmIdentifiers returns the digest algorithm and the signature
+// algorithm identifiers of the key spec. RSA keys sign with RSASSA-PSS.
+func algorithmIdentifiers(keySpec signature.KeySpec) (pkix.AlgorithmIdentifier, pkix.AlgorithmIdentifier, error) {
+	if keySpec.Type == signature.KeyTypeEd25519 {
+		return pkix.AlgorithmIdentifier{}, pkix.AlgorithmIdentifier{}, errors.New("Ed25519 keys are not supported by CMS envelope")
+	}
+	hash := keySpec.SignatureAlgorithm().Hash()
+	digestOID, ok := digestAlgorithmOIDs[hash]
+	if !ok {
+		return pkix.AlgorithmIdentifier{}, pkix.AlgorithmIdentifier{}, fmt.Errorf("signature algorithm %s is not supported by CMS envelope", keySpec.SignatureAlgorithm())
+	}
+	digestAlg := pkix.AlgorithmIdentifier{Algorithm: digestOID}
+
+	switch keySpec.Type {
+	case signature.KeyTypeRSA:
+		hashAlg := pkix.AlgorithmIdentifier{
+			Algorithm:  digestOID,
+			Parameters: asn1.NullRawValue,
+		}
+		mgfParams, err := asn1.Marshal(hashAlg)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, pkix.AlgorithmIdentifier{}, err
+		}
+		params, err := asn1.Marshal(pssParameters{
+			Hash: hashAlg,
+			MGF: pkix.AlgorithmIdentifier{
+				Algorithm:  cms.OIDMaskGenerationFunctionMGF1,
+				Parameters: asn1.RawValue{FullBytes: mgfParams},
+			},
+			SaltLength:   hash.Size(),
+			TrailerField: 1,
+		})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, pkix.AlgorithmIdentifier{}, err
+		}
+		return digestAlg, pkix.AlgorithmIdentifier{
+			Algorithm:  cms.OIDSignatureAlgorithmRSAPSS,
+			Parameters: asn1.RawValue{FullBytes: params},
+		}, nil
+	case signature.KeyTypeEC:
+		return digestAlg, pkix.AlgorithmIdentifier{Algorithm: ecdsaAlgorithmOIDs[hash]}, nil
+	}
+	return pkix.AlgorithmIdentifier{}, pkix.AlgorithmIdentifier{}, fmt.Errorf("signature algorithm %s is not supported by CMS envelope", keySpec.SignatureAlgorithm())
+}
+
+// signatureAlgorithm returns the notary signature algorithm of the SignerInfo
+// signed by the certificate.
+func signatureAlgorithm(signerInfo *cms.SignerInfo, cert *x509.Certificate) (signature.Algorithm, error) {
+	var hash crypto.Hash
+	for h, oid := range digestAlgorithmOIDs {
+		if oid.Equal(signerInfo.DigestAlgorithm.Algorithm) {
+			hash = h
+		}
+	}
+	if hash == 0 {
+		return 0, fmt.Errorf("unsupported digest algorithm %s", signerInfo.DigestAlgorithm.Algorithm)
+	}
+	keySpec, err := signature.ExtractKeySpec(cert)
+	if err != nil {
+		return 0, err
+	}
+
+	sigOID := signerInfo.SignatureAlgorithm.Algorithm
+	switch keySpec.Type {
+	case signature.KeyTypeRSA:
+		switch {
+		case sigOID.Equal(cms.OIDSignatureAlgorithmRSAPSS):
+			if err := validatePSSParameters(signerInfo.SignatureAlgorithm.Parameters, hash); err != nil {
+				return 0, err
+			}
+			if alg := keySpec.SignatureAlgorithm(); alg.Hash() == hash {
+				return alg, nil
+			}
+		case sigOID.Equal(cms.OIDSignatureAlgorithmRSA),
+			sigOID.Equal(cms.OIDSignatureAlgorithmSHA256WithRSA) && hash == crypto.SHA256,
+			sigOID.Equal(cms.OIDSignatureAlgorithmSHA384WithRSA) && hash == crypto.SHA384,
+			sigOID.Equal(cms.OIDSignatureAlgorithmSHA512WithRSA) && hash == crypto.SHA512:
+			return legacyAlgorithms[hash], nil
+		}
+	case signature.KeyTypeEC:
+		if alg := keySpec.SignatureAlgorithm(); alg.Hash() == hash && sigOID.Equal(ecdsaAlgorithmOIDs[hash]) {
+			return alg, nil
+		}
+	}
+	return 0, fmt.Errorf("signature algorithm %s with digest algorithm %s is not supported for the signing certificate", sigOID, signerInfo.DigestAlgorithm.Algorithm)
+}
+
+// validatePSSParameters checks that the RSASSA-PSS parameters match the
+// notary RSASSA-PSS algorithms, which use the same hash algorithm for the
+// message digest and MGF1, and a salt as long as the hash.
+func validatePSSParameters(raw asn1.RawValue, hash crypto.Hash) error {
+	var params pssParameters
+	if rest, err := asn1.Unmarshal(raw.FullBytes, &params); err != nil || len(rest) != 0 {
+		return errors.New("invalid RSASSA-PSS parameters")
+	}
+	var mgfHash pkix.AlgorithmIdentifier
+	if rest, err := asn1.Unmarshal(params.MGF.Parameters.FullBytes, &mgfHash); err != nil || len(rest) != 0 {
+		return errors.New("invalid RSASSA-PSS mask generation function")
+	}
+	digestOID := digestAlgorithmOIDs[hash]
+	if !params.Hash.Algorithm.Equal(digestOID) ||
+		!params.MGF.Algorithm.Equal(cms.OIDMaskGenerationFunctionMGF1) ||
+		!mgfHash.Algorithm.Equal(digestOID) ||
+		params.SaltLength != hash.Size() ||
+		params.TrailerField != 1 {
+		return errors.New("unsupported RSASSA-PSS parameters")
+	}
+	return nil
+}
+
+// ecdsaDERSignature converts the ECDSA signature in the concatenation of r
+// and s to the DER-encoded ECDSA-Sig-Value.
+func ecdsaDERSignature(sig []byte) ([]byte, error) {
+	if len(sig) == 0 || len(sig)%2 != 0 {
+		return nil, errors.New("invalid ECDSA signature")
+	}
+	n := len(sig) / 2
+	return asn1.Marshal(ecdsaSignature{
+		R: new(big.Int).SetBytes(sig[:n]),
+		S: new(big.Int).SetBytes(sig[n:]),
+	})
+}
+
+// generateSignedData returns the DER-encoded ContentInfo of the SignedData
+// with a single signer. The certificate chain is carried in the certificates
+// of the SignedData and the content is detached if it is nil.
+func generateSignedData(content []byte, certs []*x509.Certificate, crls []*x509.RevocationList, signerInfo cms.SignerInfo) ([]byte, error) {
+	// SignedData is version 3 only if the signer is identified by the
+	// subject key identifier.
+	//
+	// Reference: https://www.rfc-editor.org/rfc/rfc5652#section-5.1
+	version := 1
+	if signerInfo.Version == 3 {
+		version = 3
+	}
+	var rawCerts []byte
+	for _, cert := range certs {
+		rawCerts = append(rawCerts, cert.Raw...)
+	}
+	var rawCRLs []byte
+	for _, crl := range crls {
+		rawCRLs = append(rawCRLs, crl.Raw...)
+	}
+	signedData := cms.SignedData{
+		Version:                    version,
+		DigestAlgorithmIdentifiers: []pkix.AlgorithmIdentifier{signerInfo.DigestAlgorithm},
+		EncapsulatedContentInfo: cms.EncapsulatedContentInfo{
+			ContentType: cms.OIDData,
+			Content:     content,
+		},
+		Certificates: contextSpecificValue(0, rawCerts),
+		CRLs:         contextSpecificValue(1, rawCRLs),
+		SignerInfos:  []cms.SignerInfo{signerInfo},
+	}
+	encoded, err := asn1.Marshal(signedData)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(cms.ContentInfo{
+		ContentType: cms.OIDSignedData,
+		Content:     contextSpecificValue(0, encoded),
+	})
+}
+
+// contextSpecificValue returns the constructed value with the context
+// specific tag, or the zero value omitted as optional if bytes is empty.
+func contextSpecificValue(tag int, bytes []byte) asn1.RawValue {
+	if len(bytes) == 0 {
+		return asn1.RawValue{}
+	}
+	return asn1.RawValue{
+		Class:      asn1.ClassContextSpecific,
+		Tag:        tag,
+		IsCompound: true,
+		Bytes:      bytes,
+	}
+}
+
+// issuerAndSerialNumber returns the signer identifier of the certificate.
+func issuerAndSerialNumber(cert *x509.Certificate) (asn1.RawValue, error) {
+	encoded, err := asn1.Marshal(cms.IssuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+		SerialNumber: cert.SerialNumber,
+	})
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	return asn1.RawValue{FullBytes: encoded}, nil
+}
@@ -0,0 +1,96 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import "fmt"
+
+// ErrorCode is the error code returned by plugins.
+type ErrorCode string
+
+const (
+	// ErrorCodeValidation is used when the request is invalid.
+	ErrorCodeValidation ErrorCode = "VALIDATION_ERROR"
+
+	// ErrorCodeUnsupportedContractVersion is used when the contract version
+	// of the request is not supported by the plugin.
+	ErrorCodeUnsupportedContractVersion ErrorCode = "UNSUPPORTED_CONTRACT_VERSION"
+
+	// ErrorCodeAccessDenied is used when the plugin is denied to access the
+	// signing key.
+	ErrorCodeAccessDenied ErrorCode = "ACCESS_DENIED"
+
+	// ErrorCodeTimeout is used when the back end of the plugin times out.
+	ErrorCodeTimeout ErrorCode = "TIMEOUT"
+
+	// ErrorCodeThrottled is used when the back end of the plugin throttles
+	// the request.
+	ErrorCodeThrottled ErrorCode = "THROTTLED"
+
+	// ErrorCodeGeneric is used for any other error.
+	ErrorCodeGeneric ErrorCode = "ERROR"
+)
+
+// Error is used when a plugin command fails.
+type Error struct {
+	// Command is the failed plugin command.
+	Command Command
+
+	// Code is the error code returned by the plugin.
+	Code ErrorCode
+
+	// Message is the error message returned by the plugin.
+	Message string
+
+	// Details contains optional details of the error.
+	Details map[string]string
+}
+
+// Error returns the formatted error message.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("plugin command %s failed with error code %s", e.Command, e.Code)
+	}
+	return fmt.Sprintf("plugin command %s failed with error code %s: %s", e.Command, e.Code, e.Message)
+}
+
+// Is reports whether target is an *Error with the same error code, so that
+// callers can match errors by code, e.g.
+//
+//	errors.Is(err, &plugin.Error{Code: plugin.ErrorCodeThrottled})
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// InvalidResponseError is used when a plugin returns a malformed or
+// unexpected response.
+type InvalidResponseError struct {
+	// Command is the plugin command returning the response.
+	Command Command
+
+	// Msg describes the problem of the response.
+	Msg string
+}
+
+// Error returns the formatted error message.
+func (e *InvalidResponseError) Error() string {
+	return fmt.Sprintf("plugin command %s returned an invalid response: %s", e.Command, e.Msg)
+}
+
+// errorResponse is the error response written by plugins to stderr.
+type errorResponse struct {
+	ErrorCode    ErrorCode         `json:"errorCode"`
+	ErrorMessage string            `json:"errorMessage,omitempty"`
+	ErrorDetails map[string]string `json:"errorDetails,omitempty"`
+}
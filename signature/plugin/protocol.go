@@ -0,0 +1,160 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugin implements signature.Signer by running external signing
+// plugin executables, so that keys stored in KMS or other remote back ends
+// can be used without dedicated Go code.
+//
+// A plugin is invoked as "<executable> <command>" with a JSON request written
+// to its stdin. On success, the plugin writes a JSON response to its stdout
+// and exits with code 0. On failure, the plugin writes a JSON error response
+// to its stderr and exits with a non-zero code.
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// ContractVersion is the version of the plugin protocol implemented by this
+// package.
+const ContractVersion = "1.0"
+
+// Command is a command supported by signing plugins.
+type Command string
+
+const (
+	// CommandDescribeKey is the command to get the key specification of a
+	// signing key.
+	CommandDescribeKey Command = "describe-key"
+
+	// CommandGenerateSignature is the command to sign a payload.
+	CommandGenerateSignature Command = "generate-signature"
+)
+
+// DescribeKeyRequest is the request of the describe-key command.
+type DescribeKeyRequest struct {
+	ContractVersion string            `json:"contractVersion"`
+	KeyID           string            `json:"keyId"`
+	PluginConfig    map[string]string `json:"pluginConfig,omitempty"`
+}
+
+// DescribeKeyResponse is the response of the describe-key command.
+type DescribeKeyResponse struct {
+	// KeyID is the ID of the signing key, which must be the same as the
+	// request.
+	KeyID string `json:"keyId"`
+
+	// KeySpec is the key specification of the signing key, e.g. "RSA-3072".
+	KeySpec string `json:"keySpec"`
+}
+
+// GenerateSignatureRequest is the request of the generate-signature command.
+type GenerateSignatureRequest struct {
+	ContractVersion string            `json:"contractVersion"`
+	KeyID           string            `json:"keyId"`
+	KeySpec         string            `json:"keySpec"`
+	Hash            string            `json:"hashAlgorithm"`
+	PluginConfig    map[string]string `json:"pluginConfig,omitempty"`
+
+	// Payload is the raw payload to be signed, which is base64 encoded in
+	// JSON.
+	Payload []byte `json:"payload"`
+}
+
+// GenerateSignatureResponse is the response of the generate-signature
+// command.
+type GenerateSignatureResponse struct {
+	// KeyID is the ID of the signing key, which must be the same as the
+	// request.
+	KeyID string `json:"keyId"`
+
+	// Signature is the raw signature, which is base64 encoded in JSON.
+	// ECDSA signatures are the concatenation of r and s.
+	Signature []byte `json:"signature"`
+
+	// SigningAlgorithm is the algorithm used to generate the signature, e.g.
+	// "RSASSA-PSS-SHA-384".
+	SigningAlgorithm string `json:"signingAlgorithm"`
+
+	// CertificateChain is the DER encoded certificate chain of the signing
+	// key, starting from the signing certificate.
+	CertificateChain [][]byte `json:"certificateChain"`
+}
+
+// key specifications of the plugin protocol.
+const (
+	keySpecRSA2048 = "RSA-2048"
+	keySpecRSA3072 = "RSA-3072"
+	keySpecRSA4096 = "RSA-4096"
+	keySpecEC256   = "EC-256"
+	keySpecEC384   = "EC-384"
+	keySpecEC521   = "EC-521"
+	keySpecEd25519 = "Ed25519"
+)
+
+var keySpecMap = map[string]signature.KeySpec{
+	keySpecRSA2048: {Type: signature.KeyTypeRSA, Size: 2048},
+	keySpecRSA3072: {Type: signature.KeyTypeRSA, Size: 3072},
+	keySpecRSA4096: {Type: signature.KeyTypeRSA, Size: 4096},
+	keySpecEC256:   {Type: signature.KeyTypeEC, Size: 256},
+	keySpecEC384:   {Type: signature.KeyTypeEC, Size: 384},
+	keySpecEC521:   {Type: signature.KeyTypeEC, Size: 521},
+	keySpecEd25519: {Type: signature.KeyTypeEd25519, Size: 256},
+}
+
+// hash and signing algorithms of the plugin protocol.
+var (
+	hashAlgorithmMap = map[signature.Algorithm]string{
+		signature.AlgorithmPS256: "SHA-256",
+		signature.AlgorithmPS384: "SHA-384",
+		signature.AlgorithmPS512: "SHA-512",
+		signature.AlgorithmES256: "SHA-256",
+		signature.AlgorithmES384: "SHA-384",
+		signature.AlgorithmES512: "SHA-512",
+		signature.AlgorithmEdDSA: "SHA-512",
+	}
+	signingAlgorithmMap = map[signature.Algorithm]string{
+		signature.AlgorithmPS256: "RSASSA-PSS-SHA-256",
+		signature.AlgorithmPS384: "RSASSA-PSS-SHA-384",
+		signature.AlgorithmPS512: "RSASSA-PSS-SHA-512",
+		signature.AlgorithmES256: "ECDSA-SHA-256",
+		signature.AlgorithmES384: "ECDSA-SHA-384",
+		signature.AlgorithmES512: "ECDSA-SHA-512",
+		signature.AlgorithmEdDSA: "Ed25519",
+	}
+)
+
+// parseKeySpec parses the key specification of the plugin protocol.
+func parseKeySpec(keySpec string) (signature.KeySpec, error) {
+	spec, ok := keySpecMap[keySpec]
+	if !ok {
+		return signature.KeySpec{}, &signature.UnsupportedSigningKeyError{
+			Msg: fmt.Sprintf("plugin key spec %q is not supported", keySpec),
+		}
+	}
+	return spec, nil
+}
+
+// formatKeySpec formats the key specification for the plugin protocol.
+func formatKeySpec(keySpec signature.KeySpec) (string, error) {
+	for name, spec := range keySpecMap {
+		if spec == keySpec {
+			return name, nil
+		}
+	}
+	return "", &signature.UnsupportedSigningKeyError{
+		Msg: fmt.Sprintf("key type %d with size %d is not supported", keySpec.Type, keySpec.Size),
+	}
+}
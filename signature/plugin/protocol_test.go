@@ -0,0 +1,52 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+func TestKeySpec(t *testing.T) {
+	for name, keySpec := range keySpecMap {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := parseKeySpec(name)
+			if err != nil {
+				t.Fatalf("parseKeySpec() error = %v", err)
+			}
+			if parsed != keySpec {
+				t.Errorf("parseKeySpec() = %+v, want %+v", parsed, keySpec)
+			}
+			formatted, err := formatKeySpec(keySpec)
+			if err != nil {
+				t.Fatalf("formatKeySpec() error = %v", err)
+			}
+			if formatted != name {
+				t.Errorf("formatKeySpec() = %s, want %s", formatted, name)
+			}
+			alg := keySpec.SignatureAlgorithm()
+			if hashAlgorithmMap[alg] == "" || signingAlgorithmMap[alg] == "" {
+				t.Errorf("missing hash or signing algorithm of %s", name)
+			}
+		})
+	}
+
+	if _, err := parseKeySpec("RSA-1024"); err == nil {
+		t.Error("parseKeySpec() expects error for unsupported key spec")
+	}
+	if _, err := formatKeySpec(signature.KeySpec{Type: signature.KeyTypeRSA, Size: 1024}); err == nil {
+		t.Error("formatKeySpec() expects error for unsupported key spec")
+	}
+}
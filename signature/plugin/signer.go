@@ -0,0 +1,272 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// Options contains parameters for signing with a plugin.
+type Options struct {
+	// PluginConfig is passed to the plugin in every request, e.g. the region
+	// of a KMS back end.
+	PluginConfig map[string]string
+
+	// Timeout limits the duration of each plugin invocation. If it is zero,
+	// there is no limit.
+	Timeout time.Duration
+
+	// Context is passed to every plugin invocation. When it is cancelled, the
+	// running plugin is killed. If it is nil, context.Background() is used.
+	Context context.Context
+}
+
+func (opts Options) ctx() context.Context {
+	if opts.Context == nil {
+		return context.Background()
+	}
+	return opts.Context
+}
+
+// runner runs a plugin command.
+type runner interface {
+	// Run runs the command with the JSON request and returns the JSON
+	// response.
+	Run(ctx context.Context, command Command, req []byte) ([]byte, error)
+}
+
+// execRunner runs plugin commands by executing the plugin executable.
+type execRunner struct {
+	path string
+}
+
+// Run executes the plugin with the command as the argument and the request
+// as stdin. The error response in stderr is converted to *Error if the
+// plugin exits with a non-zero code.
+func (r *execRunner) Run(ctx context.Context, command Command, req []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.path, string(command))
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, fmt.Errorf("plugin command %s: %w", command, ctxErr)
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("failed to run plugin command %s: %w", command, err)
+		}
+		return nil, parseErrorResponse(command, stderr.Bytes())
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseErrorResponse converts the error response of the plugin to *Error.
+// Malformed error responses are reported as ErrorCodeGeneric with the raw
+// output as the message.
+func parseErrorResponse(command Command, data []byte) error {
+	var resp errorResponse
+	if err := json.Unmarshal(data, &resp); err != nil || resp.ErrorCode == "" {
+		return &Error{
+			Command: command,
+			Code:    ErrorCodeGeneric,
+			Message: strings.TrimSpace(string(data)),
+		}
+	}
+	return &Error{
+		Command: command,
+		Code:    resp.ErrorCode,
+		Message: resp.ErrorMessage,
+		Details: resp.ErrorDetails,
+	}
+}
+
+// signer implements signature.Signer interface with a plugin.
+type signer struct {
+	keyID  string
+	opts   Options
+	runner runner
+
+	// keySpec is the key specification of the signing key, which is
+	// described by the plugin on first use.
+	keySpec   signature.KeySpec
+	described bool
+	mu        sync.Mutex
+}
+
+// NewSigner returns a new signer, which signs with the key identified by
+// keyID using the plugin executable at path.
+func NewSigner(path, keyID string, opts Options) (signature.Signer, error) {
+	if path == "" {
+		return nil, &signature.InvalidArgumentError{
+			Param: "path",
+			Err:   errors.New("empty plugin path"),
+		}
+	}
+	return newSigner(&execRunner{path: path}, keyID, opts)
+}
+
+func newSigner(runner runner, keyID string, opts Options) (*signer, error) {
+	if keyID == "" {
+		return nil, &signature.InvalidArgumentError{
+			Param: "keyID",
+			Err:   errors.New("empty key ID"),
+		}
+	}
+	return &signer{
+		keyID:  keyID,
+		opts:   opts,
+		runner: runner,
+	}, nil
+}
+
+// KeySpec returns the key specification described by the plugin.
+func (s *signer) KeySpec() (signature.KeySpec, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.described {
+		return s.keySpec, nil
+	}
+
+	req := &DescribeKeyRequest{
+		ContractVersion: ContractVersion,
+		KeyID:           s.keyID,
+		PluginConfig:    s.opts.PluginConfig,
+	}
+	var resp DescribeKeyResponse
+	if err := s.run(CommandDescribeKey, req, &resp); err != nil {
+		return signature.KeySpec{}, err
+	}
+	if resp.KeyID != s.keyID {
+		return signature.KeySpec{}, &InvalidResponseError{
+			Command: CommandDescribeKey,
+			Msg:     fmt.Sprintf("key ID %q does not match the requested key ID %q", resp.KeyID, s.keyID),
+		}
+	}
+	keySpec, err := parseKeySpec(resp.KeySpec)
+	if err != nil {
+		return signature.KeySpec{}, err
+	}
+
+	s.keySpec = keySpec
+	s.described = true
+	return keySpec, nil
+}
+
+// Sign signs the payload with the plugin and returns the raw signature and
+// certificates.
+func (s *signer) Sign(payload []byte) ([]byte, []*x509.Certificate, error) {
+	keySpec, err := s.KeySpec()
+	if err != nil {
+		return nil, nil, err
+	}
+	keySpecName, err := formatKeySpec(keySpec)
+	if err != nil {
+		return nil, nil, err
+	}
+	alg := keySpec.SignatureAlgorithm()
+
+	req := &GenerateSignatureRequest{
+		ContractVersion: ContractVersion,
+		KeyID:           s.keyID,
+		KeySpec:         keySpecName,
+		Hash:            hashAlgorithmMap[alg],
+		PluginConfig:    s.opts.PluginConfig,
+		Payload:         payload,
+	}
+	var resp GenerateSignatureResponse
+	if err := s.run(CommandGenerateSignature, req, &resp); err != nil {
+		return nil, nil, err
+	}
+
+	// validate response
+	invalidResponse := func(format string, a ...any) error {
+		return &InvalidResponseError{
+			Command: CommandGenerateSignature,
+			Msg:     fmt.Sprintf(format, a...),
+		}
+	}
+	if resp.KeyID != s.keyID {
+		return nil, nil, invalidResponse("key ID %q does not match the requested key ID %q", resp.KeyID, s.keyID)
+	}
+	if expected := signingAlgorithmMap[alg]; resp.SigningAlgorithm != expected {
+		return nil, nil, invalidResponse("signing algorithm %q does not match the expected algorithm %q", resp.SigningAlgorithm, expected)
+	}
+	if len(resp.Signature) == 0 {
+		return nil, nil, invalidResponse("signature is missing")
+	}
+	if len(resp.CertificateChain) == 0 {
+		return nil, nil, invalidResponse("certificate chain is missing")
+	}
+	certs := make([]*x509.Certificate, 0, len(resp.CertificateChain))
+	for _, raw := range resp.CertificateChain {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, nil, invalidResponse("malformed certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+	leafKeySpec, err := signature.ExtractKeySpec(certs[0])
+	if err != nil {
+		return nil, nil, invalidResponse("signing certificate: %v", err)
+	}
+	if leafKeySpec != keySpec {
+		return nil, nil, invalidResponse("key spec of the signing certificate does not match the described key spec %q", keySpecName)
+	}
+	// detect a misbehaving plugin before the signature is put into an
+	// envelope
+	if err := signature.VerifyRawSignature(alg, certs[0].PublicKey, payload, resp.Signature); err != nil {
+		return nil, nil, invalidResponse("signature cannot be verified with the signing certificate: %v", err)
+	}
+	return resp.Signature, certs, nil
+}
+
+// run runs the plugin command with req and unmarshals the response into
+// resp.
+func (s *signer) run(command Command, req, resp any) error {
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ctx := s.opts.ctx()
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+	respBytes, err := s.runner.Run(ctx, command, reqBytes)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(respBytes, resp); err != nil {
+		return &InvalidResponseError{
+			Command: command,
+			Msg:     err.Error(),
+		}
+	}
+	return nil
+}
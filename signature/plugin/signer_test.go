@@ -0,0 +1,523 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-core-go/testhelper"
+)
+
+// environment variables configuring the test binary to act as a plugin.
+const (
+	envTestPluginMode  = "NOTATION_TEST_PLUGIN_MODE"
+	envTestPluginKey   = "NOTATION_TEST_PLUGIN_KEY"
+	envTestPluginCerts = "NOTATION_TEST_PLUGIN_CERTS"
+)
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(envTestPluginMode); mode != "" {
+		os.Exit(runTestPlugin(mode))
+	}
+	os.Exit(m.Run())
+}
+
+// runTestPlugin implements a plugin signing with the key and certificates
+// passed by environment variables.
+func runTestPlugin(mode string) int {
+	fail := func(code ErrorCode, msg string) int {
+		json.NewEncoder(os.Stderr).Encode(errorResponse{
+			ErrorCode:    code,
+			ErrorMessage: msg,
+		})
+		return 1
+	}
+	switch mode {
+	case "error":
+		return fail(ErrorCodeAccessDenied, "access denied")
+	case "garbage":
+		fmt.Fprint(os.Stderr, "panic: something went wrong")
+		return 2
+	case "sleep":
+		time.Sleep(time.Minute)
+		return 0
+	}
+
+	rawKey, err := base64.StdEncoding.DecodeString(os.Getenv(envTestPluginKey))
+	if err != nil {
+		return fail(ErrorCodeGeneric, err.Error())
+	}
+	key, err := x509.ParsePKCS8PrivateKey(rawKey)
+	if err != nil {
+		return fail(ErrorCodeGeneric, err.Error())
+	}
+	var rawCerts [][]byte
+	var certs []*x509.Certificate
+	for _, encoded := range strings.Split(os.Getenv(envTestPluginCerts), ",") {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fail(ErrorCodeGeneric, err.Error())
+		}
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fail(ErrorCodeGeneric, err.Error())
+		}
+		rawCerts = append(rawCerts, raw)
+		certs = append(certs, cert)
+	}
+	keySpec, err := signature.ExtractKeySpec(certs[0])
+	if err != nil {
+		return fail(ErrorCodeGeneric, err.Error())
+	}
+	keySpecName, err := formatKeySpec(keySpec)
+	if err != nil {
+		return fail(ErrorCodeGeneric, err.Error())
+	}
+
+	if len(os.Args) != 2 {
+		return fail(ErrorCodeValidation, "missing command")
+	}
+	reqBytes, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fail(ErrorCodeGeneric, err.Error())
+	}
+	var resp any
+	switch Command(os.Args[1]) {
+	case CommandDescribeKey:
+		var req DescribeKeyRequest
+		if err := json.Unmarshal(reqBytes, &req); err != nil {
+			return fail(ErrorCodeValidation, err.Error())
+		}
+		resp = &DescribeKeyResponse{
+			KeyID:   req.KeyID,
+			KeySpec: keySpecName,
+		}
+	case CommandGenerateSignature:
+		var req GenerateSignatureRequest
+		if err := json.Unmarshal(reqBytes, &req); err != nil {
+			return fail(ErrorCodeValidation, err.Error())
+		}
+		if req.ContractVersion != ContractVersion {
+			return fail(ErrorCodeUnsupportedContractVersion, req.ContractVersion)
+		}
+		if req.KeySpec != keySpecName {
+			return fail(ErrorCodeValidation, "unexpected key spec "+req.KeySpec)
+		}
+		s, err := signature.NewSigner(certs, key.(crypto.Signer))
+		if err != nil {
+			return fail(ErrorCodeGeneric, err.Error())
+		}
+		sig, _, err := s.Sign(req.Payload)
+		if err != nil {
+			return fail(ErrorCodeGeneric, err.Error())
+		}
+		resp = &GenerateSignatureResponse{
+			KeyID:            req.KeyID,
+			Signature:        sig,
+			SigningAlgorithm: signingAlgorithmMap[keySpec.SignatureAlgorithm()],
+			CertificateChain: rawCerts,
+		}
+	default:
+		return fail(ErrorCodeValidation, "unsupported command "+os.Args[1])
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		return fail(ErrorCodeGeneric, err.Error())
+	}
+	return 0
+}
+
+// setupTestPlugin configures the test binary to act as a plugin in mode
+// signing with key and certs, and returns the path of the plugin.
+func setupTestPlugin(t *testing.T, mode string, key crypto.PrivateKey, certs []*x509.Certificate) string {
+	t.Setenv(envTestPluginMode, mode)
+	if key != nil {
+		rawKey, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv(envTestPluginKey, base64.StdEncoding.EncodeToString(rawKey))
+	}
+	encodedCerts := make([]string, len(certs))
+	for i, cert := range certs {
+		encodedCerts[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+	t.Setenv(envTestPluginCerts, strings.Join(encodedCerts, ","))
+	return os.Args[0]
+}
+
+func TestNewSigner(t *testing.T) {
+	if _, err := NewSigner("", "key", Options{}); err == nil {
+		t.Error("NewSigner() expects error for empty path")
+	}
+	if _, err := NewSigner("plugin", "", Options{}); err == nil {
+		t.Error("NewSigner() expects error for empty key ID")
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   crypto.PrivateKey
+		certs []*x509.Certificate
+	}{
+		{
+			name: "RSA",
+			key:  testhelper.GetRSALeafCertificate().PrivateKey,
+			certs: []*x509.Certificate{
+				testhelper.GetRSALeafCertificate().Cert,
+				testhelper.GetRSARootCertificate().Cert,
+			},
+		},
+		{
+			name: "EC",
+			key:  testhelper.GetECLeafCertificate().PrivateKey,
+			certs: []*x509.Certificate{
+				testhelper.GetECLeafCertificate().Cert,
+				testhelper.GetECRootCertificate().Cert,
+			},
+		},
+		{
+			name: "Ed25519",
+			key:  testhelper.GetEd25519LeafCertificate().PrivateKey,
+			certs: []*x509.Certificate{
+				testhelper.GetEd25519LeafCertificate().Cert,
+				testhelper.GetRSARootCertificate().Cert,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := setupTestPlugin(t, "sign", tt.key, tt.certs)
+			signer, err := NewSigner(path, "test-key", Options{
+				PluginConfig: map[string]string{"region": "test"},
+				Timeout:      time.Minute,
+			})
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+
+			env := jws.NewEnvelope()
+			encoded, err := env.Sign(&signature.SignRequest{
+				Payload: signature.Payload{
+					ContentType: "application/vnd.cncf.notary.payload.v1+json",
+					Content:     []byte("{}"),
+				},
+				Signer:        signer,
+				SigningTime:   time.Now(),
+				SigningScheme: signature.SigningSchemeX509,
+			})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			env, err = jws.ParseEnvelope(encoded)
+			if err != nil {
+				t.Fatalf("ParseEnvelope() error = %v", err)
+			}
+			content, err := env.Verify()
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !content.SignerInfo.CertificateChain[0].Equal(tt.certs[0]) {
+				t.Errorf("expect signing certificate %v, got %v", tt.certs[0].Subject, content.SignerInfo.CertificateChain[0].Subject)
+			}
+		})
+	}
+}
+
+func TestExecRunnerErrors(t *testing.T) {
+	t.Run("error response", func(t *testing.T) {
+		path := setupTestPlugin(t, "error", nil, nil)
+		signer, err := NewSigner(path, "test-key", Options{})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		_, err = signer.KeySpec()
+		var pluginErr *Error
+		if !errors.As(err, &pluginErr) {
+			t.Fatalf("KeySpec() expects *Error, got %v", err)
+		}
+		if pluginErr.Code != ErrorCodeAccessDenied || pluginErr.Command != CommandDescribeKey || pluginErr.Message != "access denied" {
+			t.Errorf("unexpected error %+v", pluginErr)
+		}
+		if !errors.Is(err, &Error{Code: ErrorCodeAccessDenied}) {
+			t.Errorf("expect error matching code %s", ErrorCodeAccessDenied)
+		}
+	})
+
+	t.Run("malformed error response", func(t *testing.T) {
+		path := setupTestPlugin(t, "garbage", nil, nil)
+		signer, err := NewSigner(path, "test-key", Options{})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		_, err = signer.KeySpec()
+		expected := &Error{
+			Command: CommandDescribeKey,
+			Code:    ErrorCodeGeneric,
+			Message: "panic: something went wrong",
+		}
+		if err == nil || err.Error() != expected.Error() {
+			t.Errorf("KeySpec() expects error %v, got %v", expected, err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		path := setupTestPlugin(t, "sleep", nil, nil)
+		signer, err := NewSigner(path, "test-key", Options{Timeout: 100 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		if _, err = signer.KeySpec(); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("KeySpec() expects context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		path := setupTestPlugin(t, "sleep", nil, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		signer, err := NewSigner(path, "test-key", Options{Context: ctx})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		time.AfterFunc(100*time.Millisecond, cancel)
+		if _, err = signer.KeySpec(); !errors.Is(err, context.Canceled) {
+			t.Errorf("KeySpec() expects context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("executable not found", func(t *testing.T) {
+		signer, err := NewSigner("/non-existent/plugin", "test-key", Options{})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		if _, err = signer.KeySpec(); err == nil {
+			t.Error("KeySpec() expects error, got nil")
+		}
+	})
+}
+
+// mockRunner returns the predefined responses of commands.
+type mockRunner struct {
+	responses map[Command]any
+	calls     map[Command]int
+}
+
+func (r *mockRunner) Run(ctx context.Context, command Command, req []byte) ([]byte, error) {
+	if r.calls == nil {
+		r.calls = make(map[Command]int)
+	}
+	r.calls[command]++
+	switch resp := r.responses[command].(type) {
+	case error:
+		return nil, resp
+	case []byte:
+		return resp, nil
+	default:
+		return json.Marshal(resp)
+	}
+}
+
+func TestSignerResponseErrors(t *testing.T) {
+	leafTuple := testhelper.GetRSALeafCertificate()
+	leaf := leafTuple.Cert
+	localSigner, err := signature.NewSigner([]*x509.Certificate{leaf}, leafTuple.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _, err := localSigner.Sign([]byte("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	describeKey := &DescribeKeyResponse{KeyID: "test-key", KeySpec: keySpecRSA3072}
+	validSignature := &GenerateSignatureResponse{
+		KeyID:            "test-key",
+		Signature:        sig,
+		SigningAlgorithm: "RSASSA-PSS-SHA-384",
+		CertificateChain: [][]byte{leaf.Raw},
+	}
+	withSignature := func(update func(resp *GenerateSignatureResponse)) *GenerateSignatureResponse {
+		resp := *validSignature
+		update(&resp)
+		return &resp
+	}
+
+	tests := []struct {
+		name      string
+		responses map[Command]any
+		expectErr string
+	}{
+		{
+			name: "malformed describe-key response",
+			responses: map[Command]any{
+				CommandDescribeKey: []byte("{"),
+			},
+			expectErr: "plugin command describe-key returned an invalid response: unexpected end of JSON input",
+		},
+		{
+			name: "mismatched describe-key key ID",
+			responses: map[Command]any{
+				CommandDescribeKey: &DescribeKeyResponse{KeyID: "other", KeySpec: keySpecRSA3072},
+			},
+			expectErr: `plugin command describe-key returned an invalid response: key ID "other" does not match the requested key ID "test-key"`,
+		},
+		{
+			name: "unsupported key spec",
+			responses: map[Command]any{
+				CommandDescribeKey: &DescribeKeyResponse{KeyID: "test-key", KeySpec: "RSA-1024"},
+			},
+			expectErr: `plugin key spec "RSA-1024" is not supported`,
+		},
+		{
+			name: "generate-signature error",
+			responses: map[Command]any{
+				CommandDescribeKey:       describeKey,
+				CommandGenerateSignature: &Error{Command: CommandGenerateSignature, Code: ErrorCodeThrottled},
+			},
+			expectErr: "plugin command generate-signature failed with error code THROTTLED",
+		},
+		{
+			name: "mismatched generate-signature key ID",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					resp.KeyID = "other"
+				}),
+			},
+			expectErr: `plugin command generate-signature returned an invalid response: key ID "other" does not match the requested key ID "test-key"`,
+		},
+		{
+			name: "mismatched signing algorithm",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					resp.SigningAlgorithm = "RSASSA-PSS-SHA-256"
+				}),
+			},
+			expectErr: `plugin command generate-signature returned an invalid response: signing algorithm "RSASSA-PSS-SHA-256" does not match the expected algorithm "RSASSA-PSS-SHA-384"`,
+		},
+		{
+			name: "missing signature",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					resp.Signature = nil
+				}),
+			},
+			expectErr: "plugin command generate-signature returned an invalid response: signature is missing",
+		},
+		{
+			name: "missing certificate chain",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					resp.CertificateChain = nil
+				}),
+			},
+			expectErr: "plugin command generate-signature returned an invalid response: certificate chain is missing",
+		},
+		{
+			name: "malformed certificate",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					resp.CertificateChain = [][]byte{[]byte("malformed")}
+				}),
+			},
+			expectErr: "plugin command generate-signature returned an invalid response: malformed certificate",
+		},
+		{
+			name: "mismatched signing certificate",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					resp.CertificateChain = [][]byte{testhelper.GetECLeafCertificate().Cert.Raw}
+				}),
+			},
+			expectErr: `plugin command generate-signature returned an invalid response: key spec of the signing certificate does not match the described key spec "RSA-3072"`,
+		},
+		{
+			name: "wrong signature",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					resp.Signature = []byte("signature")
+				}),
+			},
+			expectErr: "plugin command generate-signature returned an invalid response: signature cannot be verified with the signing certificate",
+		},
+		{
+			name: "signature of other payload",
+			responses: map[Command]any{
+				CommandDescribeKey: describeKey,
+				CommandGenerateSignature: withSignature(func(resp *GenerateSignatureResponse) {
+					otherSig, _, err := localSigner.Sign([]byte("other payload"))
+					if err != nil {
+						t.Fatal(err)
+					}
+					resp.Signature = otherSig
+				}),
+			},
+			expectErr: "plugin command generate-signature returned an invalid response: signature cannot be verified with the signing certificate",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newSigner(&mockRunner{responses: tt.responses}, "test-key", Options{})
+			if err != nil {
+				t.Fatalf("newSigner() error = %v", err)
+			}
+			_, _, err = s.Sign([]byte("payload"))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.expectErr) {
+				t.Errorf("Sign() expects error %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+
+	t.Run("key spec is described once", func(t *testing.T) {
+		runner := &mockRunner{responses: map[Command]any{
+			CommandDescribeKey:       describeKey,
+			CommandGenerateSignature: validSignature,
+		}}
+		s, err := newSigner(runner, "test-key", Options{})
+		if err != nil {
+			t.Fatalf("newSigner() error = %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			gotSig, certs, err := s.Sign([]byte("payload"))
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if !bytes.Equal(gotSig, sig) || len(certs) != 1 || !certs[0].Equal(leaf) {
+				t.Fatalf("unexpected signature %x and certificates %v", gotSig, certs)
+			}
+		}
+		if runner.calls[CommandDescribeKey] != 1 || runner.calls[CommandGenerateSignature] != 2 {
+			t.Errorf("unexpected plugin calls %v", runner.calls)
+		}
+	})
+}
@@ -16,10 +16,14 @@ package signature
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"encoding/asn1"
 	"errors"
 	"fmt"
+	"math/big"
 )
 
 // Signer is used to sign bytes generated after signature envelope created.
@@ -31,6 +35,14 @@ type Signer interface {
 	KeySpec() (KeySpec, error)
 }
 
+// Timestamper obtains timestamp tokens for signatures from a Time Stamping
+// Authority (TSA).
+type Timestamper interface {
+	// Timestamp requests a timestamp token for the signature, whose message
+	// imprint is computed using hash, and returns the raw timestamp token.
+	Timestamp(signature []byte, hash crypto.Hash) ([]byte, error)
+}
+
 // LocalSigner is only used by built-in signers to sign.
 type LocalSigner interface {
 	Signer
@@ -96,6 +108,12 @@ func isKeyPair(priv crypto.PrivateKey, pub crypto.PublicKey, keySpec KeySpec) bo
 			return false
 		}
 		return privateKey.PublicKey.Equal(pub)
+	case KeyTypeEd25519:
+		privateKey, ok := priv.(ed25519.PrivateKey)
+		if !ok {
+			return false
+		}
+		return privateKey.Public().(ed25519.PublicKey).Equal(pub)
 	default:
 		return false
 	}
@@ -122,6 +140,196 @@ func (s *localSigner) PrivateKey() crypto.PrivateKey {
 	return s.key
 }
 
+// signer implements Signer interface with a crypto.Signer.
+//
+// Unlike localSigner, signer performs the signing itself so that the key
+// material is never required to be extracted, e.g. keys stored in HSMs or
+// KMS.
+type signer struct {
+	keySpec KeySpec
+	key     crypto.Signer
+	certs   []*x509.Certificate
+}
+
+// NewSigner returns a new signer with given certificates and crypto.Signer.
+// The public key of key must match the public key of the leaf certificate.
+func NewSigner(certs []*x509.Certificate, key crypto.Signer) (Signer, error) {
+	if len(certs) == 0 {
+		return nil, &InvalidArgumentError{
+			Param: "certs",
+			Err:   errors.New("empty certs"),
+		}
+	}
+	if key == nil {
+		return nil, &InvalidArgumentError{
+			Param: "key",
+			Err:   errors.New("nil key"),
+		}
+	}
+
+	keySpec, err := ExtractKeySpec(certs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := key.Public().(interface {
+		Equal(crypto.PublicKey) bool
+	})
+	if !ok || !publicKey.Equal(certs[0].PublicKey) {
+		return nil, &InvalidArgumentError{
+			Param: "key and certs",
+			Err:   errors.New("key not matches certificate"),
+		}
+	}
+
+	return &signer{
+		keySpec: keySpec,
+		key:     key,
+		certs:   certs,
+	}, nil
+}
+
+// NewSignerFromLocal returns a new signer signing with the private key and
+// the certificate chain of the local signer. It is used by envelopes whose
+// signing is not provided by an underlying crypto library.
+func NewSignerFromLocal(localSigner LocalSigner) (Signer, error) {
+	key, ok := localSigner.PrivateKey().(crypto.Signer)
+	if !ok {
+		return nil, &UnsupportedSigningKeyError{}
+	}
+	certs, err := localSigner.CertificateChain()
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(certs, key)
+}
+
+// Sign signs the payload and returns the raw signature and certificates.
+//
+// RSA keys sign with RSASSA-PSS, ECDSA keys produce the signature as the
+// concatenation of r and s, and Ed25519 keys sign the payload directly.
+func (s *signer) Sign(payload []byte) ([]byte, []*x509.Certificate, error) {
+	hash := s.keySpec.SignatureAlgorithm().Hash()
+	if s.keySpec.Type == KeyTypeEd25519 {
+		sig, err := s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+		if err != nil {
+			return nil, nil, err
+		}
+		return sig, s.certs, nil
+	}
+
+	h := hash.New()
+	h.Write(payload)
+	digest := h.Sum(nil)
+
+	switch s.keySpec.Type {
+	case KeyTypeRSA:
+		sig, err := s.key.Sign(rand.Reader, digest, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       hash,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return sig, s.certs, nil
+	case KeyTypeEC:
+		der, err := s.key.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		sig, err := ecdsaRawSignature(der, s.keySpec.Size)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sig, s.certs, nil
+	}
+	return nil, nil, &UnsupportedSigningKeyError{}
+}
+
+// KeySpec returns the key specification.
+func (s *signer) KeySpec() (KeySpec, error) {
+	return s.keySpec, nil
+}
+
+// ecdsaRawSignature converts the ASN.1 DER encoded ECDSA signature to the
+// concatenation of r and s, each of which is padded to the byte length of
+// the curve.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc7518#section-3.4
+func ecdsaRawSignature(der []byte, curveBits int) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, fmt.Errorf("malformed ecdsa signature: %w", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("malformed ecdsa signature: trailing data")
+	}
+
+	keyBytes := (curveBits + 7) / 8
+	if sig.R.Sign() <= 0 || sig.S.Sign() <= 0 || sig.R.BitLen() > keyBytes*8 || sig.S.BitLen() > keyBytes*8 {
+		return nil, errors.New("malformed ecdsa signature: invalid r or s")
+	}
+	out := make([]byte, 2*keyBytes)
+	sig.R.FillBytes(out[:keyBytes])
+	sig.S.FillBytes(out[keyBytes:])
+	return out, nil
+}
+
+// VerifyRawSignature verifies the raw signature over the payload generated
+// with the signature algorithm, as returned by Signer.Sign.
+//
+// RSA signatures are RSASSA-PSS signatures, ECDSA signatures are the
+// concatenation of r and s, and Ed25519 signatures are over the payload
+// directly.
+func VerifyRawSignature(alg Algorithm, key crypto.PublicKey, payload, sig []byte) error {
+	if alg == AlgorithmEdDSA {
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("public key is not an Ed25519 key")
+		}
+		if !ed25519.Verify(edKey, payload, sig) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+
+	hash := alg.Hash()
+	if hash == 0 {
+		return fmt.Errorf("signature algorithm %s is not supported", alg)
+	}
+	h := hash.New()
+	h.Write(payload)
+	digest := h.Sum(nil)
+
+	switch alg {
+	case AlgorithmPS256, AlgorithmPS384, AlgorithmPS512:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("public key is not an RSA key")
+		}
+		return rsa.VerifyPSS(rsaKey, hash, digest, sig, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		})
+	case AlgorithmES256, AlgorithmES384, AlgorithmES512:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("public key is not an ECDSA key")
+		}
+		keyBytes := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*keyBytes {
+			return errors.New("invalid signature")
+		}
+		r := new(big.Int).SetBytes(sig[:keyBytes])
+		s := new(big.Int).SetBytes(sig[keyBytes:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("signature algorithm %s is not supported", alg)
+}
+
 // VerifyAuthenticity verifies the certificate chain in the given SignerInfo
 // with one of the trusted certificates and returns a certificate that matches
 // with one of the certificates in the SignerInfo.
@@ -15,8 +15,14 @@ package signature
 
 import (
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"crypto/x509"
+	"errors"
+	"io"
+	"math/big"
 	"reflect"
 	"testing"
 
@@ -41,7 +47,7 @@ func TestNewLocalSigner(t *testing.T) {
 		{
 			name: "unsupported leaf cert",
 			certs: []*x509.Certificate{
-				{PublicKey: ed25519.PublicKey{}},
+				{PublicKey: &struct{}{}},
 			},
 			key:       nil,
 			expect:    nil,
@@ -101,6 +107,33 @@ func TestNewLocalSigner(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "Ed25519 keys not match",
+			certs: []*x509.Certificate{
+				testhelper.GetEd25519LeafCertificate().Cert,
+			},
+			key:       testhelper.GetEd25519CertTuple().PrivateKey,
+			expect:    nil,
+			expectErr: true,
+		},
+		{
+			name: "Ed25519 keys match",
+			certs: []*x509.Certificate{
+				testhelper.GetEd25519LeafCertificate().Cert,
+			},
+			key: testhelper.GetEd25519LeafCertificate().PrivateKey,
+			expect: &localSigner{
+				keySpec: KeySpec{
+					Type: KeyTypeEd25519,
+					Size: 256,
+				},
+				key: testhelper.GetEd25519LeafCertificate().PrivateKey,
+				certs: []*x509.Certificate{
+					testhelper.GetEd25519LeafCertificate().Cert,
+				},
+			},
+			expectErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -176,6 +209,265 @@ func TestPrivateKey(t *testing.T) {
 	}
 }
 
+// opaqueSigner hides the concrete type of the wrapped crypto.Signer, e.g. a
+// key stored in an HSM.
+type opaqueSigner struct {
+	crypto.Signer
+}
+
+// errorSigner returns err on Sign.
+type errorSigner struct {
+	crypto.Signer
+	sig []byte
+	err error
+}
+
+func (s *errorSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.sig, s.err
+}
+
+func TestNewSigner(t *testing.T) {
+	tests := []struct {
+		name  string
+		certs []*x509.Certificate
+		key   crypto.Signer
+	}{
+		{
+			name:  "empty certs",
+			certs: []*x509.Certificate{},
+			key:   testhelper.GetRSALeafCertificate().PrivateKey,
+		},
+		{
+			name: "nil key",
+			certs: []*x509.Certificate{
+				testhelper.GetRSALeafCertificate().Cert,
+			},
+			key: nil,
+		},
+		{
+			name: "unsupported leaf cert",
+			certs: []*x509.Certificate{
+				{PublicKey: &struct{}{}},
+			},
+			key: testhelper.GetRSALeafCertificate().PrivateKey,
+		},
+		{
+			name: "keys not match",
+			certs: []*x509.Certificate{
+				testhelper.GetRSALeafCertificate().Cert,
+			},
+			key: &opaqueSigner{testhelper.GetECLeafCertificate().PrivateKey},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSigner(tt.certs, tt.key); err == nil {
+				t.Errorf("expect error but got nil")
+			}
+		})
+	}
+}
+
+func TestSignerSign(t *testing.T) {
+	payload := []byte("test payload")
+
+	t.Run("RSA", func(t *testing.T) {
+		tuple := testhelper.GetRSALeafCertificate()
+		s, err := NewSigner([]*x509.Certificate{tuple.Cert}, &opaqueSigner{tuple.PrivateKey})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		if _, ok := s.(LocalSigner); ok {
+			t.Fatal("expect signer not to be a LocalSigner")
+		}
+		sig, certs, err := s.Sign(payload)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		if !reflect.DeepEqual(certs, []*x509.Certificate{tuple.Cert}) {
+			t.Errorf("expect certs %+v, got %+v", tuple.Cert, certs)
+		}
+		digest := crypto.SHA384.New()
+		digest.Write(payload)
+		if err := rsa.VerifyPSS(&tuple.PrivateKey.PublicKey, crypto.SHA384, digest.Sum(nil), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+			t.Errorf("VerifyPSS() error = %v", err)
+		}
+	})
+
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Run("EC "+curve.Params().Name, func(t *testing.T) {
+			tuple := testhelper.GetECCertTuple(curve)
+			s, err := NewSigner([]*x509.Certificate{tuple.Cert}, &opaqueSigner{tuple.PrivateKey})
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+			sig, _, err := s.Sign(payload)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			keySpec, _ := s.KeySpec()
+			keyBytes := (keySpec.Size + 7) / 8
+			if len(sig) != 2*keyBytes {
+				t.Fatalf("expect signature of %d bytes, got %d", 2*keyBytes, len(sig))
+			}
+			digest := keySpec.SignatureAlgorithm().Hash().New()
+			digest.Write(payload)
+			sigR := new(big.Int).SetBytes(sig[:keyBytes])
+			sigS := new(big.Int).SetBytes(sig[keyBytes:])
+			if !ecdsa.Verify(&tuple.PrivateKey.PublicKey, digest.Sum(nil), sigR, sigS) {
+				t.Error("expect valid ecdsa signature")
+			}
+		})
+	}
+
+	t.Run("Ed25519", func(t *testing.T) {
+		tuple := testhelper.GetEd25519LeafCertificate()
+		s, err := NewSigner([]*x509.Certificate{tuple.Cert}, &opaqueSigner{tuple.PrivateKey})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		sig, _, err := s.Sign(payload)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		if !ed25519.Verify(tuple.PrivateKey.Public().(ed25519.PublicKey), payload, sig) {
+			t.Error("expect valid ed25519 signature")
+		}
+	})
+
+	t.Run("crypto.Signer error", func(t *testing.T) {
+		tuple := testhelper.GetECLeafCertificate()
+		s, err := NewSigner([]*x509.Certificate{tuple.Cert}, &errorSigner{Signer: tuple.PrivateKey, err: errors.New("sign error")})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		if _, _, err := s.Sign(payload); err == nil {
+			t.Error("expect error but got nil")
+		}
+	})
+
+	t.Run("malformed ecdsa signature", func(t *testing.T) {
+		tuple := testhelper.GetECLeafCertificate()
+		s, err := NewSigner([]*x509.Certificate{tuple.Cert}, &errorSigner{Signer: tuple.PrivateKey, sig: []byte("malformed")})
+		if err != nil {
+			t.Fatalf("NewSigner() error = %v", err)
+		}
+		if _, _, err := s.Sign(payload); err == nil {
+			t.Error("expect error but got nil")
+		}
+	})
+}
+
+// fakeLocalSigner overrides the private key and the certificate chain of a
+// local signer.
+type fakeLocalSigner struct {
+	LocalSigner
+	key      crypto.PrivateKey
+	certsErr error
+}
+
+func (s *fakeLocalSigner) CertificateChain() ([]*x509.Certificate, error) {
+	if s.certsErr != nil {
+		return nil, s.certsErr
+	}
+	return s.LocalSigner.CertificateChain()
+}
+
+func (s *fakeLocalSigner) PrivateKey() crypto.PrivateKey {
+	return s.key
+}
+
+func TestNewSignerFromLocal(t *testing.T) {
+	tuple := testhelper.GetECLeafCertificate()
+	localSigner, err := NewLocalSigner([]*x509.Certificate{tuple.Cert}, tuple.PrivateKey)
+	if err != nil {
+		t.Fatalf("NewLocalSigner() error = %v", err)
+	}
+
+	t.Run("sign", func(t *testing.T) {
+		s, err := NewSignerFromLocal(localSigner)
+		if err != nil {
+			t.Fatalf("NewSignerFromLocal() error = %v", err)
+		}
+		if _, ok := s.(LocalSigner); ok {
+			t.Fatal("expect signer not to be a LocalSigner")
+		}
+		payload := []byte("test payload")
+		sig, certs, err := s.Sign(payload)
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		if !reflect.DeepEqual(certs, []*x509.Certificate{tuple.Cert}) {
+			t.Errorf("expect certs %+v, got %+v", tuple.Cert, certs)
+		}
+		if err := VerifyRawSignature(AlgorithmES384, tuple.Cert.PublicKey, payload, sig); err != nil {
+			t.Errorf("VerifyRawSignature() error = %v", err)
+		}
+	})
+
+	t.Run("unsupported private key", func(t *testing.T) {
+		_, err := NewSignerFromLocal(&fakeLocalSigner{LocalSigner: localSigner, key: struct{}{}})
+		if _, ok := err.(*UnsupportedSigningKeyError); !ok {
+			t.Errorf("expect UnsupportedSigningKeyError, got %v", err)
+		}
+	})
+
+	t.Run("certificate chain error", func(t *testing.T) {
+		_, err := NewSignerFromLocal(&fakeLocalSigner{LocalSigner: localSigner, key: tuple.PrivateKey, certsErr: errors.New("no certs")})
+		if err == nil {
+			t.Error("expect error but got nil")
+		}
+	})
+}
+
+func TestVerifyRawSignature(t *testing.T) {
+	payload := []byte("test payload")
+	rsaTuple := testhelper.GetRSALeafCertificate()
+	ecTuple := testhelper.GetECLeafCertificate()
+	edTuple := testhelper.GetEd25519LeafCertificate()
+	for _, tt := range []struct {
+		name string
+		cert *x509.Certificate
+		key  crypto.Signer
+	}{
+		{name: "RSA", cert: rsaTuple.Cert, key: rsaTuple.PrivateKey},
+		{name: "EC", cert: ecTuple.Cert, key: ecTuple.PrivateKey},
+		{name: "Ed25519", cert: edTuple.Cert, key: edTuple.PrivateKey},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSigner([]*x509.Certificate{tt.cert}, tt.key)
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+			sig, _, err := s.Sign(payload)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			keySpec, _ := s.KeySpec()
+			alg := keySpec.SignatureAlgorithm()
+			if err := VerifyRawSignature(alg, tt.cert.PublicKey, payload, sig); err != nil {
+				t.Errorf("VerifyRawSignature() error = %v", err)
+			}
+			if err := VerifyRawSignature(alg, tt.cert.PublicKey, []byte("tampered"), sig); err == nil {
+				t.Error("VerifyRawSignature() expects error for tampered payload")
+			}
+		})
+	}
+
+	t.Run("mismatched key type", func(t *testing.T) {
+		if err := VerifyRawSignature(AlgorithmPS384, ecTuple.Cert.PublicKey, payload, []byte("sig")); err == nil {
+			t.Error("VerifyRawSignature() expects error")
+		}
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		if err := VerifyRawSignature(AlgorithmRS256, rsaTuple.Cert.PublicKey, payload, []byte("sig")); err == nil {
+			t.Error("VerifyRawSignature() expects error")
+		}
+	})
+}
+
 func TestVerifyAuthenticity(t *testing.T) {
 	tests := []struct {
 		name       string
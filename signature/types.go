@@ -17,6 +17,8 @@ import (
 	"crypto/x509"
 	"errors"
 	"time"
+
+	"github.com/notaryproject/notation-core-go/timestamp"
 )
 
 // SignatureMediaType list the supported media-type for signatures.
@@ -101,6 +103,30 @@ type SignRequest struct {
 
 	// SigningScheme defines the Notary Project Signing Scheme used by the signature.
 	SigningScheme SigningScheme
+
+	// Timestamper is optional. If provided, it is used to obtain a timestamp
+	// token over the signature, which is embedded in the unsigned attributes
+	// of the signature envelope.
+	Timestamper Timestamper
+
+	// DetachedPayload specifies that Payload.Content is signed but not
+	// embedded in the signature envelope, so that it can be stored
+	// separately. The content must be provided by
+	// VerifyOptions.DetachedPayload to verify the envelope.
+	DetachedPayload bool
+}
+
+// VerifyOptions contains parameters for verifying a signature envelope.
+type VerifyOptions struct {
+	// AllowLegacyAlgorithms enables the verification of signatures generated
+	// by the legacy RSASSA-PKCS1-v1_5 algorithms RS256, RS384 and RS512.
+	// Legacy algorithms are never supported for signing.
+	AllowLegacyAlgorithms bool
+
+	// DetachedPayload is the payload content of an envelope signed with
+	// SignRequest.DetachedPayload. It is required to verify such envelopes
+	// and must be nil for envelopes embedding the payload.
+	DetachedPayload []byte
 }
 
 // EnvelopeContent represents a combination of payload to be signed and a parsed
@@ -113,6 +139,16 @@ type EnvelopeContent struct {
 	Payload Payload
 }
 
+// MultiSignatureContent represents a combination of the payload and the
+// signer info of each signature in an envelope holding multiple signatures.
+type MultiSignatureContent struct {
+	// SignerInfos are the parsed signatures in the order of the envelope.
+	SignerInfos []SignerInfo
+
+	// Payload is the payload signed by all the signatures.
+	Payload Payload
+}
+
 // SignerInfo represents a parsed signature envelope that is agnostic to
 // signature envelope format.
 type SignerInfo struct {
@@ -125,6 +161,8 @@ type SignerInfo struct {
 	UnsignedAttributes UnsignedAttributes
 
 	// SignatureAlgorithm defines the signature algorithm.
+	// SignatureAlgorithm.IsLegacy() reports whether the signature is
+	// generated by a verify-only legacy algorithm.
 	SignatureAlgorithm Algorithm
 
 	// CertificateChain is an ordered list of X.509 public certificates
@@ -146,6 +184,11 @@ type Payload struct {
 	//
 	// For JWS envelope, Content is limited to be JSON format.
 	Content []byte
+
+	// Detached reports whether the payload content is stored outside of the
+	// signature envelope. Content of a detached payload is only filled in
+	// after a successful verification with VerifyOptions.DetachedPayload.
+	Detached bool
 }
 
 // ExtendedAttribute fetches the specified Attribute with provided key from
@@ -159,17 +202,73 @@ func (signerInfo *SignerInfo) ExtendedAttribute(key string) (Attribute, error) {
 	return Attribute{}, errors.New("key not in ExtendedAttributes")
 }
 
-// AuthenticSigningTime returns the authentic signing time
+// AuthenticSigningTime returns the authentic signing time.
+//
+// For the notary.x509 signing scheme, it is the genTime of the verified
+// timestamp signature, whose TSA certificate chain is verified against the
+// system roots. Use AuthenticTimestamp to specify the trusted TSA root
+// certificates and to get the accuracy as well.
 func (signerInfo *SignerInfo) AuthenticSigningTime() (time.Time, error) {
+	ts, err := signerInfo.AuthenticTimestamp(x509.VerifyOptions{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return ts.Value, nil
+}
+
+// AuthenticTimestamp returns the authentic signing time together with its
+// accuracy.
+//
+// For the notary.x509 signing scheme, the timestamp signature is verified
+// against the signature, and the certificate chain of the TSA is verified
+// against opts.Roots, the trusted TSA root certificates, at the genTime of
+// the timestamp. If opts.Roots is nil, the system roots are used.
+// See timestamp.SignedToken.Verify for the other options.
+func (signerInfo *SignerInfo) AuthenticTimestamp(opts x509.VerifyOptions) (*timestamp.Timestamp, error) {
 	switch signerInfo.SignedAttributes.SigningScheme {
 	case SigningSchemeX509SigningAuthority:
-		return signerInfo.SignedAttributes.SigningTime, nil
+		return &timestamp.Timestamp{
+			Value: signerInfo.SignedAttributes.SigningTime,
+		}, nil
 	case SigningSchemeX509:
 		if len(signerInfo.UnsignedAttributes.TimestampSignature) > 0 {
-			// TODO: Add TSA support for AutheticSigningTime
-			// https://github.com/notaryproject/notation-core-go/issues/38
-			return time.Time{}, errors.New("TSA checking has not been implemented")
+			ts, err := verifyTimestamp(signerInfo.UnsignedAttributes.TimestampSignature, signerInfo.Signature, opts)
+			if err != nil {
+				return nil, &InvalidTimestampError{Err: err}
+			}
+			return ts, nil
 		}
 	}
-	return time.Time{}, errors.New("authenticSigningTime not found")
+	return nil, errors.New("authenticSigningTime not found")
+}
+
+// verifyTimestampImprint checks that the message imprint of the timestamp
+// token matches the signature.
+func verifyTimestampImprint(token, signature []byte) error {
+	signedToken, err := timestamp.ParseSignedToken(token)
+	if err != nil {
+		return err
+	}
+	info, err := signedToken.Info()
+	if err != nil {
+		return err
+	}
+	return info.VerifyContent(signature)
+}
+
+// verifyTimestamp verifies the timestamp token against the signature and the
+// trusted TSA root certificates in opts, and returns the timestamp.
+func verifyTimestamp(token, signature []byte, opts x509.VerifyOptions) (*timestamp.Timestamp, error) {
+	signedToken, err := timestamp.ParseSignedToken(token)
+	if err != nil {
+		return nil, err
+	}
+	info, _, err := signedToken.Verify(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := info.VerifyContent(signature); err != nil {
+		return nil, err
+	}
+	return info.Timestamp(), nil
 }
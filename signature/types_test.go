@@ -0,0 +1,164 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signature
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/testhelper"
+)
+
+func TestAuthenticSigningTime(t *testing.T) {
+	root := testhelper.GetRSARootCertificate()
+	tsa := testhelper.GetRSATimeStampingCertTuple(root)
+	sig := []byte("signature")
+	genTime := time.Now().Truncate(time.Second)
+	h := crypto.SHA256.New()
+	h.Write(sig)
+	token, err := testhelper.CreateTimestampToken(testhelper.TimestampTokenOptions{
+		TSA:           tsa,
+		Certificates:  []*x509.Certificate{tsa.Cert, root.Cert},
+		Hash:          crypto.SHA256,
+		HashedMessage: h.Sum(nil),
+		GenTime:       genTime,
+		Accuracy:      time.Second,
+	})
+	if err != nil {
+		t.Fatalf("CreateTimestampToken() error = %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Cert)
+	opts := x509.VerifyOptions{Roots: roots}
+
+	t.Run("signing authority", func(t *testing.T) {
+		signerInfo := &SignerInfo{
+			SignedAttributes: SignedAttributes{
+				SigningScheme: SigningSchemeX509SigningAuthority,
+				SigningTime:   genTime,
+			},
+		}
+		signingTime, err := signerInfo.AuthenticSigningTime()
+		if err != nil {
+			t.Fatalf("AuthenticSigningTime() error = %v", err)
+		}
+		if !signingTime.Equal(genTime) {
+			t.Fatalf("Expected signing time %v but got %v", genTime, signingTime)
+		}
+	})
+
+	t.Run("timestamp signature", func(t *testing.T) {
+		signerInfo := &SignerInfo{
+			SignedAttributes: SignedAttributes{
+				SigningScheme: SigningSchemeX509,
+			},
+			UnsignedAttributes: UnsignedAttributes{
+				TimestampSignature: token,
+			},
+			Signature: sig,
+		}
+		ts, err := signerInfo.AuthenticTimestamp(opts)
+		if err != nil {
+			t.Fatalf("AuthenticTimestamp() error = %v", err)
+		}
+		if !ts.Value.Equal(genTime) || ts.Accuracy != time.Second {
+			t.Fatalf("Expected timestamp %v ± %v but got %v ± %v", genTime, time.Second, ts.Value, ts.Accuracy)
+		}
+
+		// the test root is not one of the system roots
+		_, err = signerInfo.AuthenticSigningTime()
+		var timestampErr *InvalidTimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("Expected InvalidTimestampError but got %v", err)
+		}
+	})
+
+	t.Run("timestamp signature with forged TSA root", func(t *testing.T) {
+		forgedRoot := testhelper.GetRSACertTupleWithPK(testhelper.GetRSACertTuple(3072).PrivateKey, root.Cert.Subject.CommonName, nil)
+		forgedTSA := testhelper.GetRSATimeStampingCertTuple(forgedRoot)
+		forgedToken, err := testhelper.CreateTimestampToken(testhelper.TimestampTokenOptions{
+			TSA:           forgedTSA,
+			Certificates:  []*x509.Certificate{forgedTSA.Cert, forgedRoot.Cert},
+			Hash:          crypto.SHA256,
+			HashedMessage: h.Sum(nil),
+			GenTime:       genTime,
+		})
+		if err != nil {
+			t.Fatalf("CreateTimestampToken() error = %v", err)
+		}
+		signerInfo := &SignerInfo{
+			SignedAttributes: SignedAttributes{
+				SigningScheme: SigningSchemeX509,
+			},
+			UnsignedAttributes: UnsignedAttributes{
+				TimestampSignature: forgedToken,
+			},
+			Signature: sig,
+		}
+		_, err = signerInfo.AuthenticTimestamp(opts)
+		var timestampErr *InvalidTimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("Expected InvalidTimestampError but got %v", err)
+		}
+	})
+
+	t.Run("timestamp signature of other signature", func(t *testing.T) {
+		signerInfo := &SignerInfo{
+			SignedAttributes: SignedAttributes{
+				SigningScheme: SigningSchemeX509,
+			},
+			UnsignedAttributes: UnsignedAttributes{
+				TimestampSignature: token,
+			},
+			Signature: []byte("other signature"),
+		}
+		_, err := signerInfo.AuthenticTimestamp(opts)
+		var timestampErr *InvalidTimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("Expected InvalidTimestampError but got %v", err)
+		}
+	})
+
+	t.Run("malformed timestamp signature", func(t *testing.T) {
+		signerInfo := &SignerInfo{
+			SignedAttributes: SignedAttributes{
+				SigningScheme: SigningSchemeX509,
+			},
+			UnsignedAttributes: UnsignedAttributes{
+				TimestampSignature: []byte("malformed"),
+			},
+			Signature: sig,
+		}
+		_, err := signerInfo.AuthenticSigningTime()
+		var timestampErr *InvalidTimestampError
+		if !errors.As(err, &timestampErr) {
+			t.Fatalf("Expected InvalidTimestampError but got %v", err)
+		}
+	})
+
+	t.Run("missing timestamp signature", func(t *testing.T) {
+		signerInfo := &SignerInfo{
+			SignedAttributes: SignedAttributes{
+				SigningScheme: SigningSchemeX509,
+			},
+			Signature: sig,
+		}
+		if _, err := signerInfo.AuthenticSigningTime(); err == nil || err.Error() != "authenticSigningTime not found" {
+			t.Fatalf("Expected error authenticSigningTime not found but got %v", err)
+		}
+	})
+}
@@ -17,11 +17,13 @@ package testhelper
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"fmt"
 	"math/big"
 	mrand "math/rand"
@@ -37,6 +39,7 @@ var (
 	rsaLeafWithoutEKU        RSACertTuple
 	ecdsaRoot                ECCertTuple
 	ecdsaLeaf                ECCertTuple
+	ed25519Leaf              Ed25519CertTuple
 	unsupportedECDSARoot     ECCertTuple
 	unsupportedRSARoot       RSACertTuple
 	rsaSelfSignedSigningCert RSACertTuple
@@ -54,6 +57,11 @@ type ECCertTuple struct {
 	PrivateKey *ecdsa.PrivateKey
 }
 
+type Ed25519CertTuple struct {
+	Cert       *x509.Certificate
+	PrivateKey ed25519.PrivateKey
+}
+
 // GetRSARootCertificate returns root certificate signed using RSA algorithm
 func GetRSARootCertificate() RSACertTuple {
 	setupCertificates()
@@ -74,14 +82,23 @@ func GetRevokableRSALeafCertificate() RSACertTuple {
 
 // GetRevokableRSAChain returns a chain of certificates that specify a local OCSP server signed using RSA algorithm
 func GetRevokableRSAChain(size int) []RSACertTuple {
+	return getRevokableRSAChain(size, false)
+}
+
+// GetRevokableRSAChainWithCRL returns a chain of certificates that specify a local OCSP server and a local CRL distribution point signed using RSA algorithm
+func GetRevokableRSAChainWithCRL(size int) []RSACertTuple {
+	return getRevokableRSAChain(size, true)
+}
+
+func getRevokableRSAChain(size int, withCRL bool) []RSACertTuple {
 	setupCertificates()
 	chain := make([]RSACertTuple, size)
-	chain[size-1] = getRevokableRSARootChainCertTuple("Notation Test Revokable RSA Chain Cert Root", size-1)
+	chain[size-1] = getRevokableRSARootChainCertTuple("Notation Test Revokable RSA Chain Cert Root", size-1, withCRL)
 	for i := size - 2; i > 0; i-- {
-		chain[i] = getRevokableRSAChainCertTuple(fmt.Sprintf("Notation Test Revokable RSA Chain Cert %d", size-i), &chain[i+1], i)
+		chain[i] = getRevokableRSAChainCertTuple(fmt.Sprintf("Notation Test Revokable RSA Chain Cert %d", size-i), &chain[i+1], i, withCRL)
 	}
 	if size > 1 {
-		chain[0] = getRevokableRSALeafChainCertTuple(fmt.Sprintf("Notation Test Revokable RSA Chain Cert %d", size), &chain[1], 0)
+		chain[0] = getRevokableRSALeafChainCertTuple(fmt.Sprintf("Notation Test Revokable RSA Chain Cert %d", size), &chain[1], 0, withCRL)
 	}
 	return chain
 }
@@ -104,6 +121,13 @@ func GetECLeafCertificate() ECCertTuple {
 	return ecdsaLeaf
 }
 
+// GetEd25519LeafCertificate returns leaf certificate with an Ed25519 key
+// issued by the RSA root certificate
+func GetEd25519LeafCertificate() Ed25519CertTuple {
+	setupCertificates()
+	return ed25519Leaf
+}
+
 // GetUnsupportedRSACert returns certificate signed using RSA algorithm with key
 // size of 1024 bits which is not supported by notary.
 func GetUnsupportedRSACert() RSACertTuple {
@@ -132,6 +156,7 @@ func setupCertificates() {
 		rsaLeafWithoutEKU = getRSACertWithoutEKUTuple("Notation Test RSA Leaf without EKU Cert", &rsaRoot)
 		ecdsaRoot = getECCertTuple("Notation Test EC Root", nil)
 		ecdsaLeaf = getECCertTuple("Notation Test EC Leaf Cert", &ecdsaRoot)
+		ed25519Leaf = getEd25519CertTuple("Notation Test Ed25519 Leaf Cert", &rsaRoot)
 		unsupportedECDSARoot = getECCertTupleWithCurve("Notation Test Invalid ECDSA Cert", nil, elliptic.P224())
 
 		// This will be flagged by the static code analyzer as 'Use of a weak cryptographic key' but its intentional
@@ -153,34 +178,79 @@ func getRevokableRSACertTuple(cn string, issuer *RSACertTuple) RSACertTuple {
 	return getRSACertTupleWithTemplate(template, issuer.PrivateKey, issuer)
 }
 
-func getRevokableRSAChainCertTuple(cn string, previous *RSACertTuple, index int) RSACertTuple {
+func getRevokableRSAChainCertTuple(cn string, previous *RSACertTuple, index int, withCRL bool) RSACertTuple {
 	template := getCertTemplate(previous == nil, true, cn)
 	template.BasicConstraintsValid = true
 	template.IsCA = true
 	template.KeyUsage = x509.KeyUsageCertSign
 	template.OCSPServer = []string{fmt.Sprintf("http://example.com/chain_ocsp/%d", index)}
+	if withCRL {
+		template.KeyUsage |= x509.KeyUsageCRLSign
+		template.CRLDistributionPoints = []string{fmt.Sprintf("http://example.com/chain_crl/%d", index)}
+	}
 	return getRSACertTupleWithTemplate(template, previous.PrivateKey, previous)
 }
 
-func getRevokableRSARootChainCertTuple(cn string, pathLen int) RSACertTuple {
+func getRevokableRSARootChainCertTuple(cn string, pathLen int, withCRL bool) RSACertTuple {
 	pk, _ := rsa.GenerateKey(rand.Reader, 3072)
 	template := getCertTemplate(true, true, cn)
 	template.BasicConstraintsValid = true
 	template.IsCA = true
 	template.KeyUsage = x509.KeyUsageCertSign
+	if withCRL {
+		template.KeyUsage |= x509.KeyUsageCRLSign
+	}
 	template.MaxPathLen = pathLen
 	return getRSACertTupleWithTemplate(template, pk, nil)
 }
 
-func getRevokableRSALeafChainCertTuple(cn string, issuer *RSACertTuple, index int) RSACertTuple {
+func getRevokableRSALeafChainCertTuple(cn string, issuer *RSACertTuple, index int, withCRL bool) RSACertTuple {
 	template := getCertTemplate(false, true, cn)
 	template.BasicConstraintsValid = true
 	template.IsCA = false
 	template.KeyUsage = x509.KeyUsageDigitalSignature
 	template.OCSPServer = []string{fmt.Sprintf("http://example.com/chain_ocsp/%d", index)}
+	if withCRL {
+		template.CRLDistributionPoints = []string{fmt.Sprintf("http://example.com/chain_crl/%d", index)}
+	}
 	return getRSACertTupleWithTemplate(template, issuer.PrivateKey, issuer)
 }
 
+// GetRSAOCSPResponderCertTuple returns a delegated OCSP responder certificate issued by issuer signed using RSA algorithm.
+// The certificate has the OCSPSigning EKU if ocspSigningEKU is true and the id-pkix-ocsp-nocheck extension if noCheck is true.
+// Its revocation status is published at the local CRL distribution point /responder_crl.
+func GetRSAOCSPResponderCertTuple(issuer RSACertTuple, ocspSigningEKU bool, noCheck bool) RSACertTuple {
+	pk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	template := getCertTemplate(false, false, "Notation Test OCSP Responder")
+	if ocspSigningEKU {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
+	}
+	if noCheck {
+		asn1Null, _ := asn1.Marshal(asn1.NullRawValue)
+		template.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}, Critical: false, Value: asn1Null}}
+	}
+	template.CRLDistributionPoints = []string{"http://example.com/responder_crl"}
+	return getRSACertTupleWithTemplate(template, pk, &issuer)
+}
+
+// GetRSATimeStampingCertTuple returns a TSA certificate with the TimeStamping EKU issued by issuer signed using RSA algorithm.
+func GetRSATimeStampingCertTuple(issuer RSACertTuple) RSACertTuple {
+	pk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	template := getCertTemplate(false, false, "Notation Test TSA")
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping}
+	return getRSACertTupleWithTemplate(template, pk, &issuer)
+}
+
+// GetExpiredRSATimeStampingCertTuple returns an expired TSA certificate with the TimeStamping EKU issued by issuer signed using RSA algorithm.
+func GetExpiredRSATimeStampingCertTuple(issuer RSACertTuple) RSACertTuple {
+	pk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	template := getCertTemplate(false, false, "Notation Test Expired TSA")
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping}
+	template.NotBefore = time.Now().AddDate(0, 0, -2)
+	template.NotAfter = time.Now().AddDate(0, 0, -1)
+	return getRSACertTupleWithTemplate(template, pk, &issuer)
+}
+
 func getRSACertWithoutEKUTuple(cn string, issuer *RSACertTuple) RSACertTuple {
 	pk, _ := rsa.GenerateKey(rand.Reader, 3072)
 	template := getCertTemplate(issuer == nil, false, cn)
@@ -197,6 +267,11 @@ func getECCertTuple(cn string, issuer *ECCertTuple) ECCertTuple {
 	return GetECDSACertTupleWithPK(k, cn, issuer)
 }
 
+func getEd25519CertTuple(cn string, issuer *RSACertTuple) Ed25519CertTuple {
+	_, k, _ := ed25519.GenerateKey(rand.Reader)
+	return GetEd25519CertTupleWithPK(k, cn, issuer)
+}
+
 func GetRSASelfSignedSigningCertTuple(cn string) RSACertTuple {
 	// Even though we are creating self-signed root, we are using false for 'isRoot' to not
 	// add root CA's basic constraint, KU and EKU.
@@ -247,6 +322,18 @@ func GetECDSACertTupleWithPK(privKey *ecdsa.PrivateKey, cn string, issuer *ECCer
 	}
 }
 
+// GetEd25519CertTupleWithPK returns an Ed25519 certificate tuple for the given
+// key issued by the given RSA issuer
+func GetEd25519CertTupleWithPK(privKey ed25519.PrivateKey, cn string, issuer *RSACertTuple) Ed25519CertTuple {
+	template := getCertTemplate(false, true, cn)
+	certBytes, _ := x509.CreateCertificate(rand.Reader, template, issuer.Cert, privKey.Public(), issuer.PrivateKey)
+	cert, _ := x509.ParseCertificate(certBytes)
+	return Ed25519CertTuple{
+		Cert:       cert,
+		PrivateKey: privKey,
+	}
+}
+
 func getCertTemplate(isRoot bool, setCodeSignEKU bool, cn string) *x509.Certificate {
 	template := &x509.Certificate{
 		Subject: pkix.Name{
@@ -303,3 +390,8 @@ func GetECCertTuple(curve elliptic.Curve) ECCertTuple {
 	)
 	return certTuple
 }
+
+func GetEd25519CertTuple() Ed25519CertTuple {
+	rsaRoot := GetRSARootCertificate()
+	return getEd25519CertTuple("Test Ed25519", &rsaRoot)
+}
@@ -17,12 +17,17 @@ package testhelper
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/asn1"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -37,9 +42,12 @@ type spyRoundTripper struct {
 	desiredOCSPStatuses []ocsp.ResponseStatus
 	revokedTime         *time.Time
 	validPKIXNoCheck    bool
+	revokedCRLIndices   []int
+	responder           *RSACertTuple
+	responderRevoked    bool
 }
 
-func (s spyRoundTripper) roundTripResponse(index int, expired bool) (*http.Response, error) {
+func (s spyRoundTripper) roundTripResponse(req *http.Request, index int, expired bool) (*http.Response, error) {
 	// Verify index of cert in chain
 	if index == (len(s.certChain) - 1) {
 		return nil, errors.New("OCSP cannot be performed on root")
@@ -83,9 +91,21 @@ func (s spyRoundTripper) roundTripResponse(index int, expired bool) (*http.Respo
 	if s.validPKIXNoCheck {
 		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}, Critical: false, Value: nil})
 	}
+	if nonce, ok := requestNonce(req); ok {
+		// Echo the nonce of the request
+		template.ExtraExtensions = append(template.ExtraExtensions, nonce)
+	}
 
 	// Create ocsp response
-	response, err := ocsp.CreateResponse(s.certChain[index].Cert, s.certChain[index+1].Cert, template, s.certChain[index].PrivateKey)
+	var response []byte
+	var err error
+	if s.responder != nil {
+		// Sign by the delegated responder and embed its certificate
+		template.Certificate = s.responder.Cert
+		response, err = ocsp.CreateResponse(s.certChain[index+1].Cert, s.responder.Cert, template, s.responder.PrivateKey)
+	} else {
+		response, err = ocsp.CreateResponse(s.certChain[index].Cert, s.certChain[index+1].Cert, template, s.certChain[index].PrivateKey)
+	}
 	if err == nil {
 		return &http.Response{
 			Body:       io.NopCloser(bytes.NewBuffer(response)),
@@ -96,12 +116,124 @@ func (s spyRoundTripper) roundTripResponse(index int, expired bool) (*http.Respo
 	}
 }
 
+// requestNonce returns the id-pkix-ocsp-nonce extension of the OCSP request
+// sent either via POST or via GET, if present.
+func requestNonce(req *http.Request) (pkix.Extension, bool) {
+	var rawRequest []byte
+	var err error
+	if req.Method == http.MethodPost {
+		rawRequest, err = io.ReadAll(req.Body)
+	} else {
+		escapedPath := req.URL.EscapedPath()
+		var encodedRequest string
+		encodedRequest, err = url.PathUnescape(escapedPath[strings.LastIndex(escapedPath, "/")+1:])
+		if err == nil {
+			rawRequest, err = base64.StdEncoding.DecodeString(encodedRequest)
+		}
+	}
+	if err != nil {
+		return pkix.Extension{}, false
+	}
+
+	var ocspRequest struct {
+		TBSRequest struct {
+			Version           int           `asn1:"explicit,tag:0,default:0,optional"`
+			RequestorName     asn1.RawValue `asn1:"explicit,tag:1,optional"`
+			RequestList       asn1.RawValue
+			RequestExtensions []pkix.Extension `asn1:"explicit,tag:2,optional"`
+		}
+	}
+	if _, err := asn1.Unmarshal(rawRequest, &ocspRequest); err != nil {
+		return pkix.Extension{}, false
+	}
+	for _, extension := range ocspRequest.TBSRequest.RequestExtensions {
+		if extension.Id.Equal(asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 2}) {
+			return extension, true
+		}
+	}
+	return pkix.Extension{}, false
+}
+
+func (s spyRoundTripper) crlRoundTripResponse(index int, expired bool) (*http.Response, error) {
+	// Verify index of cert in chain
+	if index == (len(s.certChain) - 1) {
+		return nil, errors.New("CRL cannot be retrieved for root")
+	} else if index > (len(s.certChain) - 1) {
+		return nil, errors.New("index exceeded chain size")
+	}
+
+	// Create template for CRL
+	issuer := s.certChain[index+1]
+	var thisUpdate, nextUpdate time.Time
+	if expired {
+		thisUpdate = time.Now().Add(-2 * time.Hour)
+		nextUpdate = time.Now().Add(-1 * time.Hour)
+	} else {
+		thisUpdate = time.Now().Add(-1 * time.Hour)
+		nextUpdate = time.Now().Add(time.Hour)
+	}
+	template := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}
+	for _, revokedIndex := range s.revokedCRLIndices {
+		if revokedIndex != index {
+			continue
+		}
+		revokedCert := pkix.RevokedCertificate{
+			SerialNumber:   s.certChain[index].Cert.SerialNumber,
+			RevocationTime: time.Now().Add(-1 * time.Hour),
+		}
+		if s.revokedTime != nil {
+			revokedCert.RevocationTime = *s.revokedTime
+			generalizedTime, _ := asn1.MarshalWithParams(*s.revokedTime, "generalized")
+			revokedCert.Extensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{2, 5, 29, 24}, Critical: false, Value: generalizedTime}}
+		}
+		template.RevokedCertificates = append(template.RevokedCertificates, revokedCert)
+	}
+
+	// Create CRL signed by the issuer
+	return createCRLResponse(template, issuer)
+}
+
+func (s spyRoundTripper) responderCRLRoundTripResponse() (*http.Response, error) {
+	if s.responder == nil {
+		return nil, errors.New("no delegated OCSP responder specified")
+	}
+
+	// The responder is issued by the issuer of the leaf certificate
+	template := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-1 * time.Hour),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+	if s.responderRevoked {
+		template.RevokedCertificates = []pkix.RevokedCertificate{{
+			SerialNumber:   s.responder.Cert.SerialNumber,
+			RevocationTime: time.Now().Add(-1 * time.Hour),
+		}}
+	}
+	return createCRLResponse(template, s.certChain[1])
+}
+
+func createCRLResponse(template *x509.RevocationList, issuer RSACertTuple) (*http.Response, error) {
+	crl, err := x509.CreateRevocationList(rand.Reader, template, issuer.Cert, issuer.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Body:       io.NopCloser(bytes.NewBuffer(crl)),
+		StatusCode: http.StatusOK,
+	}, nil
+}
+
 func (s spyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	if match, _ := regexp.MatchString("^\\/ocsp.*", req.URL.Path); match {
-		return s.roundTripResponse(0, false)
+		return s.roundTripResponse(req, 0, false)
 
 	} else if match, _ := regexp.MatchString("^\\/expired_ocsp.*", req.URL.Path); match {
-		return s.roundTripResponse(0, true)
+		return s.roundTripResponse(req, 0, true)
 
 	} else if match, _ := regexp.MatchString("^\\/chain_ocsp.*", req.URL.Path); match {
 		// this url works with the revokable chain, which has url structure /chain_ocsp/<index> or /chain_ocsp/<index>/<base64_encoded_request>
@@ -109,7 +241,21 @@ func (s spyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		if err != nil {
 			return nil, err
 		}
-		return s.roundTripResponse(index, false)
+		return s.roundTripResponse(req, index, false)
+
+	} else if match, _ := regexp.MatchString("^\\/chain_crl.*", req.URL.Path); match {
+		// this url works with the revokable chain with CRL, which has url structure /chain_crl/<index>
+		index, err := strconv.Atoi(strings.Split(req.URL.Path, "/")[2])
+		if err != nil {
+			return nil, err
+		}
+		return s.crlRoundTripResponse(index, false)
+
+	} else if match, _ := regexp.MatchString("^\\/expired_crl.*", req.URL.Path); match {
+		return s.crlRoundTripResponse(0, true)
+
+	} else if match, _ := regexp.MatchString("^\\/responder_crl.*", req.URL.Path); match {
+		return s.responderCRLRoundTripResponse()
 
 	} else {
 		fmt.Printf("%s did not match a specified path, using default transport", req.URL.Path)
@@ -130,3 +276,37 @@ func MockClient(certChain []RSACertTuple, desiredOCSPStatuses []ocsp.ResponseSta
 		},
 	}
 }
+
+// MockClientWithCRL creates a mock HTTP Client that intercepts requests in the
+// same way as MockClient, and additionally serves CRLs from the /chain_crl and
+// /expired_crl endpoints. The certificates of the chain at revokedCRLIndices
+// are listed as revoked in the served CRLs.
+func MockClientWithCRL(certChain []RSACertTuple, desiredOCSPStatuses []ocsp.ResponseStatus, revokedTime *time.Time, validPKIXNoCheck bool, revokedCRLIndices []int) *http.Client {
+	return &http.Client{
+		Transport: spyRoundTripper{
+			backupTransport:     http.DefaultTransport,
+			certChain:           certChain,
+			desiredOCSPStatuses: desiredOCSPStatuses,
+			revokedTime:         revokedTime,
+			validPKIXNoCheck:    validPKIXNoCheck,
+			revokedCRLIndices:   revokedCRLIndices,
+		},
+	}
+}
+
+// MockClientWithResponder creates a mock HTTP Client that intercepts requests in
+// the same way as MockClient, but the OCSP responses are signed by the delegated
+// responder and embed its certificate. The CRL of the responder is served from
+// the /responder_crl endpoint, listing the responder as revoked if
+// responderRevoked is true.
+func MockClientWithResponder(certChain []RSACertTuple, desiredOCSPStatuses []ocsp.ResponseStatus, responder RSACertTuple, responderRevoked bool) *http.Client {
+	return &http.Client{
+		Transport: spyRoundTripper{
+			backupTransport:     http.DefaultTransport,
+			certChain:           certChain,
+			desiredOCSPStatuses: desiredOCSPStatuses,
+			responder:           &responder,
+			responderRevoked:    responderRevoked,
+		},
+	}
+}
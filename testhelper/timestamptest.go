@@ -0,0 +1,259 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testhelper
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"time"
+)
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningCert   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 12}
+	oidSigningCertV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidRSA           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// TimestampTokenOptions specifies the content of a test timestamp token.
+type TimestampTokenOptions struct {
+	// TSA signs the token.
+	TSA RSACertTuple
+
+	// Certificates are embedded in the token.
+	Certificates []*x509.Certificate
+
+	// Hash computes the message imprint and the digest of the signed
+	// attributes.
+	Hash crypto.Hash
+
+	// HashedMessage is the message imprint of the timestamped content.
+	HashedMessage []byte
+
+	// GenTime is the time at which the token is created.
+	GenTime time.Time
+
+	// Accuracy is the accuracy of GenTime.
+	Accuracy time.Duration
+
+	// Nonce is echoed in the token if not nil.
+	Nonce *big.Int
+
+	// SigningCertificate is identified by the signingCertificateV2
+	// attribute. If nil, TSA.Cert is identified.
+	SigningCertificate *x509.Certificate
+
+	// LegacySigningCertificate identifies the signing certificate with the
+	// signingCertificate attribute using SHA-1 instead.
+	LegacySigningCertificate bool
+
+	// OmitSigningCertificate omits the signingCertificateV2 attribute.
+	OmitSigningCertificate bool
+}
+
+type tsMessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type tsAccuracy struct {
+	Seconds      int `asn1:"optional"`
+	Milliseconds int `asn1:"optional,tag:0"`
+	Microseconds int `asn1:"optional,tag:1"`
+}
+
+type tsTSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint tsMessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time  `asn1:"generalized"`
+	Accuracy       tsAccuracy `asn1:"optional"`
+	Nonce          *big.Int   `asn1:"optional"`
+}
+
+type tsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type tsEncapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,tag:0"`
+}
+
+type tsSignedData struct {
+	Version                    int
+	DigestAlgorithmIdentifiers []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapsulatedContentInfo    tsEncapsulatedContentInfo
+	Certificates               asn1.RawValue  `asn1:"optional,tag:0"`
+	SignerInfos                []tsSignerInfo `asn1:"set"`
+}
+
+type tsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type tsSignerInfo struct {
+	Version            int
+	SignerIdentifier   tsIssuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttributes   asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type tsESSCertID struct {
+	CertHash []byte
+}
+
+type tsSigningCertificate struct {
+	Certs []tsESSCertID
+}
+
+type tsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// CreateTimestampToken creates a RFC 3161 timestamp token signed by the TSA
+// using RSA PKCS #1 v1.5.
+func CreateTimestampToken(opts TimestampTokenOptions) ([]byte, error) {
+	var hashOID asn1.ObjectIdentifier
+	switch opts.Hash {
+	case crypto.SHA256:
+		hashOID = oidSHA256
+	case crypto.SHA384:
+		hashOID = oidSHA384
+	case crypto.SHA512:
+		hashOID = oidSHA512
+	default:
+		return nil, errors.New("unsupported hash algorithm")
+	}
+	hashAlg := pkix.AlgorithmIdentifier{Algorithm: hashOID}
+
+	// TSTInfo
+	accuracy := opts.Accuracy
+	info, err := asn1.Marshal(tsTSTInfo{
+		Version: 1,
+		Policy:  asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: tsMessageImprint{
+			HashAlgorithm: hashAlg,
+			HashedMessage: opts.HashedMessage,
+		},
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		GenTime:      opts.GenTime.UTC(),
+		Accuracy: tsAccuracy{
+			Seconds:      int(accuracy / time.Second),
+			Milliseconds: int(accuracy % time.Second / time.Millisecond),
+			Microseconds: int(accuracy % time.Millisecond / time.Microsecond),
+		},
+		Nonce: opts.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// signed attributes
+	contentType, err := asn1.Marshal(oidTSTInfo)
+	if err != nil {
+		return nil, err
+	}
+	h := opts.Hash.New()
+	h.Write(info)
+	messageDigest, err := asn1.Marshal(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	attrs := []tsAttribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: contentType}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: messageDigest}}},
+	}
+	if !opts.OmitSigningCertificate {
+		signingCert := opts.SigningCertificate
+		if signingCert == nil {
+			signingCert = opts.TSA.Cert
+		}
+		// ESSCertIDv2 defaults to SHA-256 while ESSCertID uses SHA-1
+		attrType, certHash := oidSigningCertV2, crypto.SHA256.New()
+		if opts.LegacySigningCertificate {
+			attrType, certHash = oidSigningCert, crypto.SHA1.New()
+		}
+		certHash.Write(signingCert.Raw)
+		signingCertAttr, err := asn1.Marshal(tsSigningCertificate{
+			Certs: []tsESSCertID{{CertHash: certHash.Sum(nil)}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, tsAttribute{Type: attrType, Values: []asn1.RawValue{{FullBytes: signingCertAttr}}})
+	}
+	signedAttrs, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return nil, err
+	}
+	h = opts.Hash.New()
+	h.Write(signedAttrs)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, opts.TSA.PrivateKey, opts.Hash, h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	// signed attributes are IMPLICIT [0] tagged in SignerInfo
+	signedAttrs[0] = 0xa0
+
+	var certs []byte
+	for _, cert := range opts.Certificates {
+		certs = append(certs, cert.Raw...)
+	}
+	sd, err := asn1.Marshal(tsSignedData{
+		Version:                    3,
+		DigestAlgorithmIdentifiers: []pkix.AlgorithmIdentifier{hashAlg},
+		EncapsulatedContentInfo: tsEncapsulatedContentInfo{
+			ContentType: oidTSTInfo,
+			Content:     info,
+		},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []tsSignerInfo{{
+			Version: 1,
+			SignerIdentifier: tsIssuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: opts.TSA.Cert.RawIssuer},
+				SerialNumber: opts.TSA.Cert.SerialNumber,
+			},
+			DigestAlgorithm:    hashAlg,
+			SignedAttributes:   asn1.RawValue{FullBytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidRSA, Parameters: asn1.NullRawValue},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(tsContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
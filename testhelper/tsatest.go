@@ -0,0 +1,243 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testhelper
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PKIStatus values of a time-stamping response.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161#section-2.4.2
+const (
+	TSAStatusGranted   = 0
+	TSAStatusRejection = 2
+	TSAStatusWaiting   = 3
+)
+
+type tsRequest struct {
+	Version        int
+	MessageImprint tsMessageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+type tsPKIStatusInfo struct {
+	Status       int
+	StatusString []asn1.RawValue `asn1:"optional"`
+	FailInfo     asn1.BitString  `asn1:"optional"`
+}
+
+type tsResponse struct {
+	Status         tsPKIStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// TSA is an in-process RFC 3161 Time Stamping Authority issuing real signed
+// timestamp tokens. It can be used as an http.RoundTripper, or as an
+// http.Handler served by httptest.Server.
+//
+// The zero value is not usable. Use NewTSA to create a TSA, and set the
+// fields to inject failures before serving any request.
+type TSA struct {
+	// Root is the root certificate of the TSA certificate chain.
+	Root RSACertTuple
+
+	// Certificate is the TSA certificate signing the tokens.
+	Certificate RSACertTuple
+
+	// Accuracy is the accuracy of genTime in the issued tokens.
+	Accuracy time.Duration
+
+	// HTTPStatusCode makes the TSA respond with the HTTP status code without
+	// a body if it is not 0 or http.StatusOK.
+	HTTPStatusCode int
+
+	// Status is the PKIStatus of the responses. Responses with status other
+	// than TSAStatusGranted do not carry a token.
+	Status int
+
+	// WrongImprint makes the TSA issue tokens whose message imprint does not
+	// match the request.
+	WrongImprint bool
+
+	// ExpiredCertificate makes the TSA sign the tokens with an expired TSA
+	// certificate.
+	ExpiredCertificate bool
+
+	// OmitNonce makes the TSA omit the nonce of the request in the tokens.
+	OmitNonce bool
+
+	expiredOnce        sync.Once
+	expiredCertificate RSACertTuple
+}
+
+// NewTSA creates a TSA whose certificate is issued by the RSA root
+// certificate of testhelper.
+func NewTSA() *TSA {
+	root := GetRSARootCertificate()
+	return &TSA{
+		Root:        root,
+		Certificate: GetRSATimeStampingCertTuple(root),
+		Accuracy:    time.Second,
+	}
+}
+
+// CertChain returns the certificate chain of the TSA, from the TSA
+// certificate to the root certificate.
+func (tsa *TSA) CertChain() []*x509.Certificate {
+	return []*x509.Certificate{tsa.signingCertificate().Cert, tsa.Root.Cert}
+}
+
+// Client returns an HTTP client sending all requests to the TSA.
+func (tsa *TSA) Client() *http.Client {
+	return &http.Client{Transport: tsa}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (tsa *TSA) RoundTrip(req *http.Request) (*http.Response, error) {
+	statusCode, body := tsa.handle(req)
+	resp := &http.Response{
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Header:     http.Header{},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}
+	if statusCode == http.StatusOK {
+		resp.Header.Set("Content-Type", "application/timestamp-reply")
+	}
+	return resp, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (tsa *TSA) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	statusCode, body := tsa.handle(req)
+	if statusCode == http.StatusOK {
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+	}
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
+// handle returns the HTTP status code and the body of the response to the
+// time-stamping request.
+func (tsa *TSA) handle(req *http.Request) (int, []byte) {
+	if tsa.HTTPStatusCode != 0 && tsa.HTTPStatusCode != http.StatusOK {
+		return tsa.HTTPStatusCode, nil
+	}
+	if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/timestamp-query" {
+		return http.StatusBadRequest, nil
+	}
+	reqBytes, err := io.ReadAll(req.Body)
+	if err != nil {
+		return http.StatusBadRequest, nil
+	}
+	respBytes, err := tsa.Timestamp(reqBytes)
+	if err != nil {
+		return http.StatusInternalServerError, nil
+	}
+	return http.StatusOK, respBytes
+}
+
+// Timestamp returns the DER-encoded TimeStampResp for the DER-encoded
+// TimeStampReq.
+func (tsa *TSA) Timestamp(reqBytes []byte) ([]byte, error) {
+	var req tsRequest
+	if rest, err := asn1.Unmarshal(reqBytes, &req); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data in timestamp request")
+	}
+
+	if tsa.Status != TSAStatusGranted {
+		return asn1.Marshal(tsResponse{
+			Status: tsPKIStatusInfo{
+				Status:       tsa.Status,
+				StatusString: []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte("rejected by test TSA")}},
+			},
+		})
+	}
+
+	hash, err := hashFromOID(req.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	hashedMessage := append([]byte{}, req.MessageImprint.HashedMessage...)
+	if tsa.WrongImprint && len(hashedMessage) > 0 {
+		hashedMessage[0] ^= 0xff
+	}
+	nonce := req.Nonce
+	if tsa.OmitNonce {
+		nonce = nil
+	}
+	signingCert := tsa.signingCertificate()
+	var certs []*x509.Certificate
+	if req.CertReq {
+		certs = tsa.CertChain()
+	}
+	token, err := CreateTimestampToken(TimestampTokenOptions{
+		TSA:           signingCert,
+		Certificates:  certs,
+		Hash:          hash,
+		HashedMessage: hashedMessage,
+		GenTime:       time.Now(),
+		Accuracy:      tsa.Accuracy,
+		Nonce:         nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(tsResponse{
+		Status:         tsPKIStatusInfo{Status: TSAStatusGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
+
+// signingCertificate returns the certificate signing the tokens.
+func (tsa *TSA) signingCertificate() RSACertTuple {
+	if !tsa.ExpiredCertificate {
+		return tsa.Certificate
+	}
+	tsa.expiredOnce.Do(func() {
+		tsa.expiredCertificate = GetExpiredRSATimeStampingCertTuple(tsa.Root)
+	})
+	return tsa.expiredCertificate
+}
+
+// hashFromOID returns the hash algorithm of the OID.
+func hashFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported hash algorithm %v", oid)
+}
@@ -0,0 +1,47 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PKIStatusError is used when the time-stamping request is not granted.
+type PKIStatusError struct {
+	// Status is the status of the response.
+	Status PKIStatus
+
+	// StatusString is the optional human-readable text of the status.
+	StatusString []string
+
+	// FailureInfo contains the reasons for the failure.
+	FailureInfo []PKIFailureInfo
+}
+
+// Error returns the formatted error message.
+func (e *PKIStatusError) Error() string {
+	msg := fmt.Sprintf("timestamp request is not granted with status %s", e.Status)
+	if len(e.FailureInfo) > 0 {
+		failureInfo := make([]string, len(e.FailureInfo))
+		for i, fi := range e.FailureInfo {
+			failureInfo[i] = fi.String()
+		}
+		msg += fmt.Sprintf(", failure info: %s", strings.Join(failureInfo, ", "))
+	}
+	if len(e.StatusString) > 0 {
+		msg += fmt.Sprintf(", status string: %s", strings.Join(e.StatusString, "; "))
+	}
+	return msg
+}
@@ -0,0 +1,108 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// maxResponseSize is the maximum size of a time-stamping response.
+// Typical size is ~4 KB
+const maxResponseSize = 1024 * 1024 // bytes
+
+// Media types of the Time-Stamp Protocol via HTTP.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161#section-3.4
+const (
+	MediaTypeTimestampQuery = "application/timestamp-query"
+	MediaTypeTimestampReply = "application/timestamp-reply"
+)
+
+// httpTimestamper is a HTTP-based timestamper.
+type httpTimestamper struct {
+	httpClient *http.Client
+	endpoint   string
+}
+
+// NewHTTPTimestamper creates a HTTP-based timestamper with the endpoint
+// provided by the TSA. If httpClient is nil, http.DefaultClient is used.
+func NewHTTPTimestamper(httpClient *http.Client, endpoint string) (Timestamper, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	tsaURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if tsaURL.Scheme != "http" && tsaURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported TSA endpoint scheme %q", tsaURL.Scheme)
+	}
+	return &httpTimestamper{
+		httpClient: httpClient,
+		endpoint:   endpoint,
+	}, nil
+}
+
+// Timestamp sends the request to the TSA and returns the validated response.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161#section-3.4
+func (ts *httpTimestamper) Timestamp(ctx context.Context, req *Request) (*Response, error) {
+	reqBytes, err := req.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	hReq, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.endpoint, bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	hReq.Header.Set("Content-Type", MediaTypeTimestampQuery)
+	hResp, err := ts.httpClient.Do(hReq)
+	if err != nil {
+		return nil, err
+	}
+	defer hResp.Body.Close()
+
+	if hResp.StatusCode < 200 || hResp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %q: response bad status: %s", http.MethodPost, ts.endpoint, hResp.Status)
+	}
+	if contentType := hResp.Header.Get("Content-Type"); contentType != MediaTypeTimestampReply {
+		return nil, fmt.Errorf("%s %q: unexpected response content type %q", http.MethodPost, ts.endpoint, contentType)
+	}
+
+	lr := &io.LimitedReader{
+		R: hResp.Body,
+		N: maxResponseSize + 1,
+	}
+	respBytes, err := io.ReadAll(lr)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(respBytes)) > maxResponseSize {
+		return nil, fmt.Errorf("%s %q: response exceeds the max size of %d bytes", http.MethodPost, ts.endpoint, maxResponseSize)
+	}
+
+	var resp Response
+	if err := resp.UnmarshalBinary(respBytes); err != nil {
+		return nil, fmt.Errorf("failed to parse timestamp response: %w", err)
+	}
+	if err := resp.Validate(req); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,276 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/testhelper"
+)
+
+func TestHTTPTimestamper(t *testing.T) {
+	tsa := testhelper.NewTSA()
+	server := httptest.NewServer(tsa)
+	defer server.Close()
+
+	timestamper, err := NewHTTPTimestamper(nil, server.URL)
+	if err != nil {
+		t.Fatalf("NewHTTPTimestamper() error = %v", err)
+	}
+	content := []byte("notation")
+	req, err := NewRequestFromContent(content, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("NewRequestFromContent() error = %v", err)
+	}
+	resp, err := timestamper.Timestamp(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Timestamp() error = %v", err)
+	}
+
+	token, err := ParseSignedToken(resp.TokenBytes())
+	if err != nil {
+		t.Fatalf("ParseSignedToken() error = %v", err)
+	}
+	info, certChain, err := token.Verify(x509.VerifyOptions{Roots: rootPool(tsa.Root.Cert)})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if err := info.VerifyContent(content); err != nil {
+		t.Fatalf("VerifyContent() error = %v", err)
+	}
+	if info.Nonce.Cmp(req.Nonce) != 0 {
+		t.Errorf("Expected nonce %v but got %v", req.Nonce, info.Nonce)
+	}
+	if len(certChain) != 2 || !certChain[0].Equal(tsa.Certificate.Cert) {
+		t.Errorf("Expected cert chain of the TSA but got %v", certChain)
+	}
+	if ts := info.Timestamp(); ts.Accuracy != tsa.Accuracy {
+		t.Errorf("Expected accuracy %v but got %v", tsa.Accuracy, ts.Accuracy)
+	}
+}
+
+func TestSignatureTimestamper(t *testing.T) {
+	tsa := testhelper.NewTSA()
+	timestamper, err := NewHTTPTimestamper(tsa.Client(), "http://tsa.example.com")
+	if err != nil {
+		t.Fatalf("NewHTTPTimestamper() error = %v", err)
+	}
+	st := &SignatureTimestamper{Timestamper: timestamper}
+	sig := []byte("signature")
+	token, err := st.Timestamp(sig, crypto.SHA384)
+	if err != nil {
+		t.Fatalf("SignatureTimestamper.Timestamp() error = %v", err)
+	}
+	signedToken, err := ParseSignedToken(token)
+	if err != nil {
+		t.Fatalf("ParseSignedToken() error = %v", err)
+	}
+	info, err := signedToken.Info()
+	if err != nil {
+		t.Fatalf("Info() error = %v", err)
+	}
+	if err := info.VerifyContent(sig); err != nil {
+		t.Fatalf("VerifyContent() error = %v", err)
+	}
+}
+
+func TestSignatureTimestamperCancelled(t *testing.T) {
+	server := httptest.NewServer(testhelper.NewTSA())
+	defer server.Close()
+	timestamper, err := NewHTTPTimestamper(server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("NewHTTPTimestamper() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	st := &SignatureTimestamper{Timestamper: timestamper, Context: ctx}
+	if _, err := st.Timestamp([]byte("signature"), crypto.SHA384); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled but got %v", err)
+	}
+}
+
+func TestHTTPTimestamperTSAFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(tsa *testhelper.TSA)
+		wantErr string
+	}{
+		{
+			name:    "bad http status",
+			setup:   func(tsa *testhelper.TSA) { tsa.HTTPStatusCode = http.StatusServiceUnavailable },
+			wantErr: "response bad status: 503 Service Unavailable",
+		},
+		{
+			name:    "rejected",
+			setup:   func(tsa *testhelper.TSA) { tsa.Status = testhelper.TSAStatusRejection },
+			wantErr: "timestamp request is not granted with status rejection",
+		},
+		{
+			name:    "wrong imprint",
+			setup:   func(tsa *testhelper.TSA) { tsa.WrongImprint = true },
+			wantErr: "message imprint of the timestamp token does not match the request",
+		},
+		{
+			name:    "missing nonce",
+			setup:   func(tsa *testhelper.TSA) { tsa.OmitNonce = true },
+			wantErr: "nonce of the timestamp token does not match the request",
+		},
+	}
+	req, err := NewRequestFromContent([]byte("notation"), crypto.SHA256)
+	if err != nil {
+		t.Fatalf("NewRequestFromContent() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsa := testhelper.NewTSA()
+			tt.setup(tsa)
+			timestamper, err := NewHTTPTimestamper(tsa.Client(), "http://tsa.example.com")
+			if err != nil {
+				t.Fatalf("NewHTTPTimestamper() error = %v", err)
+			}
+			_, err = timestamper.Timestamp(context.Background(), req)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestHTTPTimestamperExpiredTSA(t *testing.T) {
+	tsa := testhelper.NewTSA()
+	tsa.ExpiredCertificate = true
+	timestamper, err := NewHTTPTimestamper(tsa.Client(), "http://tsa.example.com")
+	if err != nil {
+		t.Fatalf("NewHTTPTimestamper() error = %v", err)
+	}
+	req, err := NewRequestFromContent([]byte("notation"), crypto.SHA256)
+	if err != nil {
+		t.Fatalf("NewRequestFromContent() error = %v", err)
+	}
+	resp, err := timestamper.Timestamp(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Timestamp() error = %v", err)
+	}
+	token, err := ParseSignedToken(resp.TokenBytes())
+	if err != nil {
+		t.Fatalf("ParseSignedToken() error = %v", err)
+	}
+	if _, _, err := token.Verify(x509.VerifyOptions{Roots: rootPool(tsa.Root.Cert)}); err == nil || !strings.Contains(err.Error(), "invalid TSA certificate chain") {
+		t.Fatalf("Expected invalid TSA certificate chain but got %v", err)
+	}
+}
+
+func TestHTTPTimestamperError(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name: "bad http status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: "response bad status: 500 Internal Server Error",
+		},
+		{
+			name: "bad content type",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.Write([]byte("hello"))
+			},
+			wantErr: `unexpected response content type "text/plain"`,
+		},
+		{
+			name: "response too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", MediaTypeTimestampReply)
+				w.Write(make([]byte, maxResponseSize+1))
+			},
+			wantErr: "response exceeds the max size",
+		},
+		{
+			name: "response of max size",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", MediaTypeTimestampReply)
+				w.Write(make([]byte, maxResponseSize))
+			},
+			wantErr: "failed to parse timestamp response",
+		},
+		{
+			name: "malformed response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", MediaTypeTimestampReply)
+				w.Write([]byte("malformed"))
+			},
+			wantErr: "failed to parse timestamp response",
+		},
+		{
+			name: "missing token",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeResponse(t, w, Response{
+					Status: PKIStatusInfo{Status: PKIStatusGranted},
+				})
+			},
+			wantErr: "missing timestamp token in a granted response",
+		},
+	}
+	req, err := NewRequestFromContent([]byte("notation"), crypto.SHA256)
+	if err != nil {
+		t.Fatalf("NewRequestFromContent() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			timestamper, err := NewHTTPTimestamper(server.Client(), server.URL)
+			if err != nil {
+				t.Fatalf("NewHTTPTimestamper() error = %v", err)
+			}
+			_, err = timestamper.Timestamp(context.Background(), req)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestNewHTTPTimestamperError(t *testing.T) {
+	for _, endpoint := range []string{"ftp://example.com", "://example.com"} {
+		if _, err := NewHTTPTimestamper(nil, endpoint); err == nil {
+			t.Errorf("Expected error for endpoint %q but got nil", endpoint)
+		}
+	}
+	if _, err := (&SignatureTimestamper{}).Timestamp([]byte("signature"), crypto.SHA256); err == nil {
+		t.Error("Expected error for nil timestamper but got nil")
+	}
+}
+
+func writeResponse(t *testing.T, w http.ResponseWriter, resp Response) {
+	t.Helper()
+	data, err := resp.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Header().Set("Content-Type", MediaTypeTimestampReply)
+	w.Write(data)
+}
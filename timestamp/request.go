@@ -0,0 +1,149 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Hash algorithm OIDs
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5754#section-2
+var (
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// nonceSize is the size of the random nonce in bits.
+const nonceSize = 64
+
+// MessageImprint contains the hash of the datum to be timestamped.
+//
+//	MessageImprint ::= SEQUENCE {
+//	 hashAlgorithm  AlgorithmIdentifier,
+//	 hashedMessage  OCTET STRING }
+type MessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// Request is a time-stamping request.
+//
+//	TimeStampReq ::= SEQUENCE {
+//	 version         INTEGER                 { v1(1) },
+//	 messageImprint  MessageImprint,
+//	 reqPolicy       TSAPolicyId              OPTIONAL,
+//	 nonce           INTEGER                  OPTIONAL,
+//	 certReq         BOOLEAN                  DEFAULT FALSE,
+//	 extensions      [0] IMPLICIT Extensions  OPTIONAL }
+type Request struct {
+	Version        int // currently v1
+	MessageImprint MessageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+// NewRequest creates a request for the digest computed using hash. The
+// request carries a random nonce and asks the TSA to include its certificate
+// in the response.
+func NewRequest(digest []byte, hash crypto.Hash) (*Request, error) {
+	hashAlg, err := hashAlgorithmOID(hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(digest) != hash.Size() {
+		return nil, fmt.Errorf("digest size %d does not match the size %d of hash algorithm %s", len(digest), hash.Size(), hash)
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), nonceSize))
+	if err != nil {
+		return nil, err
+	}
+	return &Request{
+		Version: 1,
+		MessageImprint: MessageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm: hashAlg,
+			},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	}, nil
+}
+
+// NewRequestFromContent creates a request for the content by computing its
+// digest using hash.
+func NewRequestFromContent(content []byte, hash crypto.Hash) (*Request, error) {
+	if !hash.Available() {
+		return nil, fmt.Errorf("hash algorithm %s is not available", hash)
+	}
+	h := hash.New()
+	h.Write(content)
+	return NewRequest(h.Sum(nil), hash)
+}
+
+// MarshalBinary encodes the request to DER.
+func (r *Request) MarshalBinary() ([]byte, error) {
+	if r == nil {
+		return nil, errors.New("nil request")
+	}
+	return asn1.Marshal(*r)
+}
+
+// UnmarshalBinary decodes the request from DER.
+func (r *Request) UnmarshalBinary(data []byte) error {
+	rest, err := asn1.Unmarshal(data, r)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("trailing data in timestamp request")
+	}
+	return nil
+}
+
+// hashAlgorithmOID returns the OID of the hash algorithm.
+func hashAlgorithmOID(hash crypto.Hash) (asn1.ObjectIdentifier, error) {
+	switch hash {
+	case crypto.SHA256:
+		return oidSHA256, nil
+	case crypto.SHA384:
+		return oidSHA384, nil
+	case crypto.SHA512:
+		return oidSHA512, nil
+	}
+	return nil, fmt.Errorf("unsupported hash algorithm %s", hash)
+}
+
+// hashAlgorithmFromOID returns the hash algorithm of the OID.
+func hashAlgorithmFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported hash algorithm %v", oid)
+}
@@ -0,0 +1,116 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestNewRequest(t *testing.T) {
+	digest := sha256.Sum256([]byte("notation"))
+	req, err := NewRequest(digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	if req.Version != 1 {
+		t.Errorf("Expected version 1 but got %d", req.Version)
+	}
+	if !req.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) {
+		t.Errorf("Expected hash algorithm %v but got %v", oidSHA256, req.MessageImprint.HashAlgorithm.Algorithm)
+	}
+	if !bytes.Equal(req.MessageImprint.HashedMessage, digest[:]) {
+		t.Errorf("Expected hashed message %x but got %x", digest, req.MessageImprint.HashedMessage)
+	}
+	if req.Nonce == nil {
+		t.Error("Expected nonce but got nil")
+	}
+	if !req.CertReq {
+		t.Error("Expected certReq to be true")
+	}
+}
+
+func TestNewRequestError(t *testing.T) {
+	digest := sha256.Sum256([]byte("notation"))
+	tests := []struct {
+		name   string
+		digest []byte
+		hash   crypto.Hash
+	}{
+		{
+			name:   "unsupported hash",
+			digest: digest[:],
+			hash:   crypto.MD5,
+		},
+		{
+			name:   "digest size mismatch",
+			digest: digest[:],
+			hash:   crypto.SHA384,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRequest(tt.digest, tt.hash); err == nil {
+				t.Errorf("Expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestNewRequestFromContent(t *testing.T) {
+	content := []byte("notation")
+	req, err := NewRequestFromContent(content, crypto.SHA384)
+	if err != nil {
+		t.Fatalf("NewRequestFromContent() error = %v", err)
+	}
+	h := crypto.SHA384.New()
+	h.Write(content)
+	if digest := h.Sum(nil); !bytes.Equal(req.MessageImprint.HashedMessage, digest) {
+		t.Errorf("Expected hashed message %x but got %x", digest, req.MessageImprint.HashedMessage)
+	}
+}
+
+func TestRequestMarshalBinary(t *testing.T) {
+	req, err := NewRequestFromContent([]byte("notation"), crypto.SHA512)
+	if err != nil {
+		t.Fatalf("NewRequestFromContent() error = %v", err)
+	}
+	data, err := req.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	var got Request
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if got.Nonce.Cmp(req.Nonce) != 0 {
+		t.Errorf("Expected nonce %v but got %v", req.Nonce, got.Nonce)
+	}
+	if !bytes.Equal(got.MessageImprint.HashedMessage, req.MessageImprint.HashedMessage) {
+		t.Errorf("Expected hashed message %x but got %x", req.MessageImprint.HashedMessage, got.MessageImprint.HashedMessage)
+	}
+	if !got.CertReq {
+		t.Error("Expected certReq to be true")
+	}
+
+	if err := got.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error("Expected error for trailing data but got nil")
+	}
+	if _, err := (*Request)(nil).MarshalBinary(); err == nil {
+		t.Error("Expected error for nil request but got nil")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+)
+
+// PKIStatus is the status of a time-stamping response.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161#section-2.4.2
+type PKIStatus int
+
+const (
+	PKIStatusGranted                PKIStatus = 0 // you got exactly what you asked for
+	PKIStatusGrantedWithMods        PKIStatus = 1 // you got something like what you asked for
+	PKIStatusRejection              PKIStatus = 2 // you don't get it, more information elsewhere in the message
+	PKIStatusWaiting                PKIStatus = 3 // the request body part has not yet been processed, expect to hear more later
+	PKIStatusRevocationWarning      PKIStatus = 4 // this message contains a warning that a revocation is imminent
+	PKIStatusRevocationNotification PKIStatus = 5 // notification that a revocation has occurred
+)
+
+// String converts the PKIStatus to a string.
+func (s PKIStatus) String() string {
+	switch s {
+	case PKIStatusGranted:
+		return "granted"
+	case PKIStatusGrantedWithMods:
+		return "grantedWithMods"
+	case PKIStatusRejection:
+		return "rejection"
+	case PKIStatusWaiting:
+		return "waiting"
+	case PKIStatusRevocationWarning:
+		return "revocationWarning"
+	case PKIStatusRevocationNotification:
+		return "revocationNotification"
+	default:
+		return "unknown PKIStatus"
+	}
+}
+
+// PKIFailureInfo is the bit index of the reason for the rejection of a
+// time-stamping request.
+type PKIFailureInfo int
+
+const (
+	FailureInfoBadAlg              PKIFailureInfo = 0  // unrecognized or unsupported Algorithm Identifier
+	FailureInfoBadRequest          PKIFailureInfo = 2  // transaction not permitted or supported
+	FailureInfoBadDataFormat       PKIFailureInfo = 5  // the data submitted has the wrong format
+	FailureInfoTimeNotAvailable    PKIFailureInfo = 14 // the TSA's time source is not available
+	FailureInfoUnacceptedPolicy    PKIFailureInfo = 15 // the requested TSA policy is not supported by the TSA
+	FailureInfoUnacceptedExtension PKIFailureInfo = 16 // the requested extension is not supported by the TSA
+	FailureInfoAddInfoNotAvailable PKIFailureInfo = 17 // the additional information requested could not be understood or is not available
+	FailureInfoSystemFailure       PKIFailureInfo = 25 // the request cannot be handled due to system failure
+)
+
+// String converts the PKIFailureInfo to a string.
+func (fi PKIFailureInfo) String() string {
+	switch fi {
+	case FailureInfoBadAlg:
+		return "badAlg"
+	case FailureInfoBadRequest:
+		return "badRequest"
+	case FailureInfoBadDataFormat:
+		return "badDataFormat"
+	case FailureInfoTimeNotAvailable:
+		return "timeNotAvailable"
+	case FailureInfoUnacceptedPolicy:
+		return "unacceptedPolicy"
+	case FailureInfoUnacceptedExtension:
+		return "unacceptedExtension"
+	case FailureInfoAddInfoNotAvailable:
+		return "addInfoNotAvailable"
+	case FailureInfoSystemFailure:
+		return "systemFailure"
+	default:
+		return "unknown PKIFailureInfo"
+	}
+}
+
+// PKIStatusInfo contains the status of a time-stamping response.
+//
+//	PKIStatusInfo ::= SEQUENCE {
+//	 status        PKIStatus,
+//	 statusString  PKIFreeText     OPTIONAL,
+//	 failInfo      PKIFailureInfo  OPTIONAL }
+type PKIStatusInfo struct {
+	Status       PKIStatus
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// Err returns nil if the request is granted. Otherwise, a PKIStatusError
+// describing the status is returned.
+func (si PKIStatusInfo) Err() error {
+	if si.Status == PKIStatusGranted || si.Status == PKIStatusGrantedWithMods {
+		return nil
+	}
+
+	var failureInfo []PKIFailureInfo
+	for _, fi := range []PKIFailureInfo{
+		FailureInfoBadAlg,
+		FailureInfoBadRequest,
+		FailureInfoBadDataFormat,
+		FailureInfoTimeNotAvailable,
+		FailureInfoUnacceptedPolicy,
+		FailureInfoUnacceptedExtension,
+		FailureInfoAddInfoNotAvailable,
+		FailureInfoSystemFailure,
+	} {
+		if si.FailInfo.At(int(fi)) != 0 {
+			failureInfo = append(failureInfo, fi)
+		}
+	}
+	return &PKIStatusError{
+		Status:       si.Status,
+		StatusString: si.StatusString,
+		FailureInfo:  failureInfo,
+	}
+}
+
+// Response is a time-stamping response.
+//
+//	TimeStampResp ::= SEQUENCE {
+//	 status          PKIStatusInfo,
+//	 timeStampToken  TimeStampToken  OPTIONAL }
+type Response struct {
+	Status         PKIStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// MarshalBinary encodes the response to DER.
+func (r *Response) MarshalBinary() ([]byte, error) {
+	if r == nil {
+		return nil, errors.New("nil response")
+	}
+	return asn1.Marshal(*r)
+}
+
+// UnmarshalBinary decodes the response from DER.
+func (r *Response) UnmarshalBinary(data []byte) error {
+	rest, err := asn1.Unmarshal(data, r)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("trailing data in timestamp response")
+	}
+	return nil
+}
+
+// TokenBytes returns the bytes of the timestamp token.
+func (r *Response) TokenBytes() []byte {
+	return r.TimeStampToken.FullBytes
+}
+
+// Validate checks that the request is granted and that the response contains
+// a timestamp token. If req is not nil, the token is checked against the
+// request as well.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161#section-2.4.2
+func (r *Response) Validate(req *Request) error {
+	if err := r.Status.Err(); err != nil {
+		return err
+	}
+	if len(r.TokenBytes()) == 0 {
+		return errors.New("missing timestamp token in a granted response")
+	}
+	if req == nil {
+		return nil
+	}
+
+	token, err := ParseSignedToken(r.TokenBytes())
+	if err != nil {
+		return err
+	}
+	info, err := token.Info()
+	if err != nil {
+		return err
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(req.MessageImprint.HashAlgorithm.Algorithm) ||
+		!bytes.Equal(info.MessageImprint.HashedMessage, req.MessageImprint.HashedMessage) {
+		return errors.New("message imprint of the timestamp token does not match the request")
+	}
+	if req.Nonce != nil && (info.Nonce == nil || info.Nonce.Cmp(req.Nonce) != 0) {
+		return errors.New("nonce of the timestamp token does not match the request")
+	}
+	if req.CertReq && len(token.Certificates) == 0 {
+		return errors.New("missing TSA certificate in the timestamp token")
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+func TestResponseValidate(t *testing.T) {
+	token := asn1.RawValue{FullBytes: []byte{0x30, 0x00}}
+	tests := []struct {
+		name    string
+		resp    Response
+		wantErr string
+	}{
+		{
+			name: "granted",
+			resp: Response{
+				Status:         PKIStatusInfo{Status: PKIStatusGranted},
+				TimeStampToken: token,
+			},
+		},
+		{
+			name: "granted with mods",
+			resp: Response{
+				Status:         PKIStatusInfo{Status: PKIStatusGrantedWithMods},
+				TimeStampToken: token,
+			},
+		},
+		{
+			name: "missing token",
+			resp: Response{
+				Status: PKIStatusInfo{Status: PKIStatusGranted},
+			},
+			wantErr: "missing timestamp token in a granted response",
+		},
+		{
+			name: "rejection",
+			resp: Response{
+				Status: PKIStatusInfo{
+					Status:       PKIStatusRejection,
+					StatusString: []string{"bad digest"},
+					FailInfo:     newFailInfo(FailureInfoBadAlg, FailureInfoBadDataFormat),
+				},
+			},
+			wantErr: "timestamp request is not granted with status rejection, failure info: badAlg, badDataFormat, status string: bad digest",
+		},
+		{
+			name: "waiting",
+			resp: Response{
+				Status: PKIStatusInfo{Status: PKIStatusWaiting},
+			},
+			wantErr: "timestamp request is not granted with status waiting",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.resp.Validate(nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error but got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Expected error %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestResponseMarshalBinary(t *testing.T) {
+	resp := Response{
+		Status: PKIStatusInfo{
+			Status:   PKIStatusRejection,
+			FailInfo: newFailInfo(FailureInfoSystemFailure),
+		},
+	}
+	data, err := resp.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	var got Response
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	var statusErr *PKIStatusError
+	if err := got.Validate(nil); !errors.As(err, &statusErr) {
+		t.Fatalf("Expected PKIStatusError but got %v", err)
+	}
+	if len(statusErr.FailureInfo) != 1 || statusErr.FailureInfo[0] != FailureInfoSystemFailure {
+		t.Errorf("Expected failure info [%v] but got %v", FailureInfoSystemFailure, statusErr.FailureInfo)
+	}
+
+	if err := got.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Error("Expected error for trailing data but got nil")
+	}
+	if _, err := (*Response)(nil).MarshalBinary(); err == nil {
+		t.Error("Expected error for nil response but got nil")
+	}
+}
+
+func TestPKIStatusString(t *testing.T) {
+	if got := PKIStatus(42).String(); got != "unknown PKIStatus" {
+		t.Errorf("Expected unknown PKIStatus but got %s", got)
+	}
+	if got := PKIFailureInfo(42).String(); got != "unknown PKIFailureInfo" {
+		t.Errorf("Expected unknown PKIFailureInfo but got %s", got)
+	}
+}
+
+// newFailInfo creates a PKIFailureInfo bit string with the given bits set.
+func newFailInfo(fis ...PKIFailureInfo) asn1.BitString {
+	bitLength := int(FailureInfoSystemFailure) + 1
+	b := make([]byte, (bitLength+7)/8)
+	for _, fi := range fis {
+		b[int(fi)/8] |= 0x80 >> (uint(fi) % 8)
+	}
+	return asn1.BitString{Bytes: b, BitLength: bitLength}
+}
@@ -0,0 +1,67 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timestamp provides a client of the Time-Stamp Protocol (TSP)
+// defined in RFC 3161, which obtains timestamp tokens from a Time Stamping
+// Authority (TSA).
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161
+package timestamp
+
+import (
+	"context"
+	"crypto"
+	"errors"
+)
+
+// Timestamper stamps the time.
+type Timestamper interface {
+	// Timestamp stamps the time with the given request.
+	Timestamp(ctx context.Context, req *Request) (*Response, error)
+}
+
+// SignatureTimestamper obtains timestamp tokens for signatures from a
+// Timestamper. It implements signature.Timestamper.
+type SignatureTimestamper struct {
+	// Timestamper is used to request the timestamp tokens.
+	Timestamper Timestamper
+
+	// Context is passed to Timestamper for every request, so that the
+	// requests can be cancelled. If it is nil, context.Background() is used.
+	Context context.Context
+}
+
+func (s *SignatureTimestamper) ctx() context.Context {
+	if s.Context == nil {
+		return context.Background()
+	}
+	return s.Context
+}
+
+// Timestamp requests a timestamp token for the signature, whose message
+// imprint is the digest of the signature computed using hash, and returns the
+// token.
+func (s *SignatureTimestamper) Timestamp(signature []byte, hash crypto.Hash) ([]byte, error) {
+	if s.Timestamper == nil {
+		return nil, errors.New("timestamper is nil")
+	}
+	req, err := NewRequestFromContent(signature, hash)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Timestamper.Timestamp(s.ctx(), req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.TokenBytes(), nil
+}
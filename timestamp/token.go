@@ -0,0 +1,355 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/cms"
+	nx509 "github.com/notaryproject/notation-core-go/x509"
+)
+
+// oidTSTInfo is the content type of TSTInfo.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161#section-2.4.2
+var oidTSTInfo = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+
+// Accuracy represents the time deviation around the UTC time contained in
+// GeneralizedTime.
+//
+//	Accuracy ::= SEQUENCE {
+//	 seconds  INTEGER           OPTIONAL,
+//	 millis   [0] INTEGER (1..999) OPTIONAL,
+//	 micros   [1] INTEGER (1..999) OPTIONAL }
+type Accuracy struct {
+	Seconds      int `asn1:"optional"`
+	Milliseconds int `asn1:"optional,tag:0"`
+	Microseconds int `asn1:"optional,tag:1"`
+}
+
+// Duration returns the accuracy as a time.Duration.
+func (a Accuracy) Duration() time.Duration {
+	return time.Duration(a.Seconds)*time.Second +
+		time.Duration(a.Milliseconds)*time.Millisecond +
+		time.Duration(a.Microseconds)*time.Microsecond
+}
+
+// TSTInfo is the timestamp information signed by the TSA.
+//
+//	TSTInfo ::= SEQUENCE {
+//	 version         INTEGER                 { v1(1) },
+//	 policy          TSAPolicyId,
+//	 messageImprint  MessageImprint,
+//	 serialNumber    INTEGER,
+//	 genTime         GeneralizedTime,
+//	 accuracy        Accuracy                OPTIONAL,
+//	 ordering        BOOLEAN                 DEFAULT FALSE,
+//	 nonce           INTEGER                 OPTIONAL,
+//	 tsa             [0] GeneralName         OPTIONAL,
+//	 extensions      [1] IMPLICIT Extensions OPTIONAL }
+type TSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint MessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time        `asn1:"generalized"`
+	Accuracy       Accuracy         `asn1:"optional"`
+	Ordering       bool             `asn1:"optional,default:false"`
+	Nonce          *big.Int         `asn1:"optional"`
+	TSA            asn1.RawValue    `asn1:"optional,tag:0"`
+	Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+// Timestamp returns the timestamp denoted by the TSTInfo.
+func (tst *TSTInfo) Timestamp() *Timestamp {
+	return &Timestamp{
+		Value:    tst.GenTime,
+		Accuracy: tst.Accuracy.Duration(),
+	}
+}
+
+// VerifyContent checks that the message imprint of the TSTInfo matches the
+// content.
+func (tst *TSTInfo) VerifyContent(content []byte) error {
+	hash, err := hashAlgorithmFromOID(tst.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	h := hash.New()
+	h.Write(content)
+	if !bytes.Equal(h.Sum(nil), tst.MessageImprint.HashedMessage) {
+		return errors.New("message imprint of the timestamp token does not match the content")
+	}
+	return nil
+}
+
+// Timestamp is the time asserted by a TSA, which lies in the range of
+// [Value-Accuracy, Value+Accuracy].
+type Timestamp struct {
+	// Value is the genTime of the timestamp.
+	Value time.Time
+
+	// Accuracy is the time deviation around Value.
+	Accuracy time.Duration
+}
+
+// Earliest returns the earliest time of the timestamp range.
+func (t *Timestamp) Earliest() time.Time {
+	return t.Value.Add(-t.Accuracy)
+}
+
+// Latest returns the latest time of the timestamp range.
+func (t *Timestamp) Latest() time.Time {
+	return t.Value.Add(t.Accuracy)
+}
+
+// SignedToken is a parsed timestamp token, which is a CMS SignedData with a
+// TSTInfo as the encapsulated content.
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc3161#section-2.4.2
+type SignedToken struct {
+	// Certificates are the certificates carried in the token.
+	Certificates []*x509.Certificate
+
+	// TSTInfoBytes is the DER-encoded TSTInfo.
+	TSTInfoBytes []byte
+
+	signedData *cms.ParsedSignedData
+}
+
+// ParseSignedToken parses a BER or DER encoded timestamp token.
+func ParseSignedToken(data []byte) (*SignedToken, error) {
+	signedData, err := cms.ParseSignedData(data)
+	if err != nil {
+		return nil, fmt.Errorf("malformed timestamp token: %w", err)
+	}
+	if !signedData.ContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("malformed timestamp token: unexpected encapsulated content type %v", signedData.ContentType)
+	}
+	if len(signedData.Content) == 0 {
+		return nil, errors.New("malformed timestamp token: missing TSTInfo")
+	}
+	// a timestamp token must be signed by exactly one TSA
+	if len(signedData.Signers) != 1 {
+		return nil, fmt.Errorf("malformed timestamp token: expect exactly one signer but got %d", len(signedData.Signers))
+	}
+	// signed attributes are mandatory as the signingCertificate attribute
+	// must be present
+	signedAttrs := signedData.Signers[0].SignedAttributes
+	if len(signedAttrs) == 0 {
+		return nil, errors.New("malformed timestamp token: missing signed attributes")
+	}
+	if !signedAttrs.Has(cms.OIDAttributeSigningCertificate) && !signedAttrs.Has(cms.OIDAttributeSigningCertificateV2) {
+		return nil, errors.New("malformed timestamp token: missing signingCertificate or signingCertificateV2 attribute")
+	}
+	return &SignedToken{
+		Certificates: signedData.Certificates,
+		TSTInfoBytes: signedData.Content,
+		signedData:   signedData,
+	}, nil
+}
+
+// Info returns the parsed TSTInfo of the token.
+func (t *SignedToken) Info() (*TSTInfo, error) {
+	var info TSTInfo
+	if rest, err := asn1.Unmarshal(t.TSTInfoBytes, &info); err != nil {
+		return nil, fmt.Errorf("malformed TSTInfo: %w", err)
+	} else if len(rest) != 0 {
+		return nil, errors.New("malformed TSTInfo: trailing data")
+	}
+	return &info, nil
+}
+
+// Verify verifies the signature of the token with the signing certificate
+// found in the token, and verifies the certificate chain of the TSA against
+// opts.Roots at the genTime of the token. The chain must also conform to
+// x509.ValidateTimeStampingCertChain.
+// On success, the verified TSTInfo and the certificate chain of the TSA,
+// ordered from the TSA certificate to the trusted root certificate, are
+// returned.
+//
+// opts.Roots is the pool of trusted TSA root certificates. If it is nil, the
+// system roots are used. The certificates carried in the token are added to
+// opts.Intermediates, and opts.CurrentTime is overridden by the genTime of
+// the token. opts.KeyUsages is ignored, as the extended key usages of the
+// chain are checked by x509.ValidateTimeStampingCertChain instead.
+func (t *SignedToken) Verify(opts x509.VerifyOptions) (*TSTInfo, []*x509.Certificate, error) {
+	signingCerts, err := t.signedData.Verify()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	if err := verifySigningCertificate(t.signedData.Signers[0].SignedAttributes, signingCerts[0]); err != nil {
+		return nil, nil, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	info, err := t.Info()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.Intermediates == nil {
+		opts.Intermediates = x509.NewCertPool()
+	} else {
+		opts.Intermediates = opts.Intermediates.Clone()
+	}
+	for _, cert := range t.Certificates {
+		opts.Intermediates.AddCert(cert)
+	}
+	opts.CurrentTime = info.GenTime
+	opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	chains, err := signingCerts[0].Verify(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid TSA certificate chain: %w", err)
+	}
+	certChain := chains[0]
+	if err := nx509.ValidateTimeStampingCertChain(certChain, &info.GenTime); err != nil {
+		return nil, nil, fmt.Errorf("invalid TSA certificate chain: %w", err)
+	}
+	return info, certChain, nil
+}
+
+// essCertID identifies a certificate by its SHA-1 hash.
+//
+//	SigningCertificate ::= SEQUENCE {
+//	 certs     SEQUENCE OF ESSCertID,
+//	 policies  SEQUENCE OF PolicyInformation OPTIONAL }
+//
+//	ESSCertID ::= SEQUENCE {
+//	 certHash      Hash,
+//	 issuerSerial  IssuerSerial OPTIONAL }
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc2634#section-5.4
+type essCertID struct {
+	CertHash     []byte
+	IssuerSerial issuerSerial `asn1:"optional"`
+}
+
+type signingCertificate struct {
+	Certs    []essCertID
+	Policies asn1.RawValue `asn1:"optional"`
+}
+
+// essCertIDv2 identifies a certificate by its hash computed with
+// HashAlgorithm, which defaults to SHA-256.
+//
+//	SigningCertificateV2 ::= SEQUENCE {
+//	 certs     SEQUENCE OF ESSCertIDv2,
+//	 policies  SEQUENCE OF PolicyInformation OPTIONAL }
+//
+//	ESSCertIDv2 ::= SEQUENCE {
+//	 hashAlgorithm  AlgorithmIdentifier DEFAULT {algorithm id-sha256},
+//	 certHash       Hash,
+//	 issuerSerial   IssuerSerial OPTIONAL }
+//
+// Reference: https://www.rfc-editor.org/rfc/rfc5035#section-4
+type essCertIDv2 struct {
+	HashAlgorithm pkix.AlgorithmIdentifier `asn1:"optional"`
+	CertHash      []byte
+	IssuerSerial  issuerSerial `asn1:"optional"`
+}
+
+type signingCertificateV2 struct {
+	Certs    []essCertIDv2
+	Policies asn1.RawValue `asn1:"optional"`
+}
+
+// issuerSerial identifies a certificate by its issuer and serial number.
+//
+//	IssuerSerial ::= SEQUENCE {
+//	 issuer        GeneralNames,
+//	 serialNumber  CertificateSerialNumber }
+type issuerSerial struct {
+	Issuer       []asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// verifySigningCertificate checks that the first certificate identified by
+// the signingCertificate or signingCertificateV2 attribute is the signing
+// certificate of the token.
+//
+// References:
+//   - https://www.rfc-editor.org/rfc/rfc3161#section-2.4.2
+//   - https://www.rfc-editor.org/rfc/rfc5816#section-2.2.1
+func verifySigningCertificate(signedAttrs cms.Attributes, cert *x509.Certificate) error {
+	found := false
+	if signedAttrs.Has(cms.OIDAttributeSigningCertificateV2) {
+		var attr signingCertificateV2
+		if err := signedAttrs.Get(cms.OIDAttributeSigningCertificateV2, &attr); err != nil {
+			return fmt.Errorf("invalid signingCertificateV2 attribute: %w", err)
+		}
+		if len(attr.Certs) == 0 {
+			return errors.New("invalid signingCertificateV2 attribute: missing certificate identifier")
+		}
+		certID := attr.Certs[0]
+		hash := crypto.SHA256
+		if len(certID.HashAlgorithm.Algorithm) > 0 {
+			var err error
+			if hash, err = hashAlgorithmFromOID(certID.HashAlgorithm.Algorithm); err != nil {
+				return fmt.Errorf("invalid signingCertificateV2 attribute: %w", err)
+			}
+		}
+		if err := verifyESSCertID(hash, certID.CertHash, certID.IssuerSerial, cert); err != nil {
+			return fmt.Errorf("signingCertificateV2 attribute does not match the signing certificate: %w", err)
+		}
+		found = true
+	}
+	if signedAttrs.Has(cms.OIDAttributeSigningCertificate) {
+		var attr signingCertificate
+		if err := signedAttrs.Get(cms.OIDAttributeSigningCertificate, &attr); err != nil {
+			return fmt.Errorf("invalid signingCertificate attribute: %w", err)
+		}
+		if len(attr.Certs) == 0 {
+			return errors.New("invalid signingCertificate attribute: missing certificate identifier")
+		}
+		certID := attr.Certs[0]
+		if err := verifyESSCertID(crypto.SHA1, certID.CertHash, certID.IssuerSerial, cert); err != nil {
+			return fmt.Errorf("signingCertificate attribute does not match the signing certificate: %w", err)
+		}
+		found = true
+	}
+	if !found {
+		return errors.New("missing signingCertificate or signingCertificateV2 attribute")
+	}
+	return nil
+}
+
+// verifyESSCertID checks the certificate hash and the optional issuer and
+// serial number of an ESS certificate identifier against cert.
+func verifyESSCertID(hash crypto.Hash, certHash []byte, is issuerSerial, cert *x509.Certificate) error {
+	h := hash.New()
+	h.Write(cert.Raw)
+	if !bytes.Equal(h.Sum(nil), certHash) {
+		return errors.New("certificate hash mismatch")
+	}
+	if is.SerialNumber == nil {
+		return nil
+	}
+	if is.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		return errors.New("serial number mismatch")
+	}
+	for _, name := range is.Issuer {
+		// directoryName [4] Name
+		if name.Class == asn1.ClassContextSpecific && name.Tag == 4 && bytes.Equal(name.Bytes, cert.RawIssuer) {
+			return nil
+		}
+	}
+	return errors.New("issuer mismatch")
+}
@@ -0,0 +1,331 @@
+// Copyright The Notary Project Authors.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timestamp
+
+import (
+	"crypto"
+	"crypto/x509"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/testhelper"
+)
+
+func TestSignedTokenVerify(t *testing.T) {
+	root := testhelper.GetRSARootCertificate()
+	tsa := testhelper.GetRSATimeStampingCertTuple(root)
+	content := []byte("signature")
+	genTime := time.Now().Truncate(time.Second)
+	accuracy := 1500 * time.Millisecond
+	token := createToken(t, testhelper.TimestampTokenOptions{
+		TSA:           tsa,
+		Certificates:  []*x509.Certificate{root.Cert, tsa.Cert},
+		Hash:          crypto.SHA256,
+		HashedMessage: digest(crypto.SHA256, content),
+		GenTime:       genTime,
+		Accuracy:      accuracy,
+	})
+
+	signedToken, err := ParseSignedToken(token)
+	if err != nil {
+		t.Fatalf("ParseSignedToken() error = %v", err)
+	}
+	info, certChain, err := signedToken.Verify(x509.VerifyOptions{Roots: rootPool(root.Cert)})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(certChain) != 2 || !certChain[0].Equal(tsa.Cert) || !certChain[1].Equal(root.Cert) {
+		t.Fatalf("Expected cert chain from TSA to root but got %v", certChain)
+	}
+	if err := info.VerifyContent(content); err != nil {
+		t.Fatalf("VerifyContent() error = %v", err)
+	}
+	if err := info.VerifyContent([]byte("other")); err == nil {
+		t.Fatal("Expected VerifyContent() to fail for other content")
+	}
+
+	ts := info.Timestamp()
+	if !ts.Value.Equal(genTime) {
+		t.Errorf("Expected genTime %v but got %v", genTime, ts.Value)
+	}
+	if ts.Accuracy != accuracy {
+		t.Errorf("Expected accuracy %v but got %v", accuracy, ts.Accuracy)
+	}
+	if !ts.Earliest().Equal(genTime.Add(-accuracy)) || !ts.Latest().Equal(genTime.Add(accuracy)) {
+		t.Errorf("Expected range [%v, %v] but got [%v, %v]", genTime.Add(-accuracy), genTime.Add(accuracy), ts.Earliest(), ts.Latest())
+	}
+}
+
+// TestSignedTokenVerifyOpenSSL verifies timestamp tokens issued by
+//
+//	openssl ts -reply -queryfile testdata/request.tsq
+//
+// with the ess_cert_id_alg of sha256 and sha1 respectively, which identify
+// the TSA certificate with the signingCertificateV2 and the
+// signingCertificate attribute.
+func TestSignedTokenVerifyOpenSSL(t *testing.T) {
+	content, err := os.ReadFile("testdata/content.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootPEM, err := os.ReadFile("testdata/root.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(rootPEM) {
+		t.Fatal("failed to load the root certificate")
+	}
+	reqBytes, err := os.ReadFile("testdata/request.tsq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req Request
+	if err := req.UnmarshalBinary(reqBytes); err != nil {
+		t.Fatalf("Request.UnmarshalBinary() error = %v", err)
+	}
+
+	for _, file := range []string{"testdata/response.tsr", "testdata/response-sha1.tsr"} {
+		t.Run(file, func(t *testing.T) {
+			respBytes, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var resp Response
+			if err := resp.UnmarshalBinary(respBytes); err != nil {
+				t.Fatalf("Response.UnmarshalBinary() error = %v", err)
+			}
+			if err := resp.Validate(&req); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			signedToken, err := ParseSignedToken(resp.TokenBytes())
+			if err != nil {
+				t.Fatalf("ParseSignedToken() error = %v", err)
+			}
+			info, certChain, err := signedToken.Verify(x509.VerifyOptions{Roots: roots})
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if len(certChain) != 2 || certChain[0].Subject.CommonName != "Notation Test Fixture tsa" {
+				t.Fatalf("Expected cert chain of the fixture TSA but got %v", certChain)
+			}
+			if err := info.VerifyContent(content); err != nil {
+				t.Fatalf("VerifyContent() error = %v", err)
+			}
+			if accuracy := info.Timestamp().Accuracy; accuracy != 1500*time.Millisecond {
+				t.Fatalf("Expected accuracy 1.5s but got %v", accuracy)
+			}
+
+			if _, _, err := signedToken.Verify(x509.VerifyOptions{Roots: x509.NewCertPool()}); err == nil {
+				t.Fatal("Expected Verify() to fail with an untrusted root")
+			}
+		})
+	}
+}
+
+func TestSignedTokenVerifyLegacySigningCertificate(t *testing.T) {
+	root := testhelper.GetRSARootCertificate()
+	tsa := testhelper.GetRSATimeStampingCertTuple(root)
+	token := createToken(t, testhelper.TimestampTokenOptions{
+		TSA:                      tsa,
+		Certificates:             []*x509.Certificate{tsa.Cert},
+		Hash:                     crypto.SHA256,
+		HashedMessage:            digest(crypto.SHA256, nil),
+		GenTime:                  time.Now(),
+		LegacySigningCertificate: true,
+	})
+	signedToken, err := ParseSignedToken(token)
+	if err != nil {
+		t.Fatalf("ParseSignedToken() error = %v", err)
+	}
+	if _, _, err := signedToken.Verify(x509.VerifyOptions{Roots: rootPool(root.Cert)}); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestSignedTokenVerifyError(t *testing.T) {
+	root := testhelper.GetRSARootCertificate()
+	tsa := testhelper.GetRSATimeStampingCertTuple(root)
+	leaf := testhelper.GetRSALeafCertificate()
+	otherTSA := testhelper.GetRSATimeStampingCertTuple(root)
+	forgedRoot := testhelper.GetRSACertTupleWithPK(testhelper.GetRSACertTuple(3072).PrivateKey, "Notation Test RSA Root", nil)
+	forgedTSA := testhelper.GetRSATimeStampingCertTuple(forgedRoot)
+	content := []byte("signature")
+	tests := []struct {
+		name    string
+		opts    testhelper.TimestampTokenOptions
+		roots   *x509.CertPool
+		wantErr string
+	}{
+		{
+			name: "signing certificate not found",
+			opts: testhelper.TimestampTokenOptions{
+				TSA:          tsa,
+				Certificates: []*x509.Certificate{root.Cert},
+			},
+			wantErr: "signing certificate not found",
+		},
+		{
+			name: "invalid signature",
+			opts: testhelper.TimestampTokenOptions{
+				TSA: testhelper.RSACertTuple{
+					Cert:       tsa.Cert,
+					PrivateKey: otherTSA.PrivateKey,
+				},
+				Certificates: []*x509.Certificate{tsa.Cert, root.Cert},
+			},
+			wantErr: "invalid signature",
+		},
+		{
+			name: "missing root certificate",
+			opts: testhelper.TimestampTokenOptions{
+				TSA:          otherTSA,
+				Certificates: []*x509.Certificate{otherTSA.Cert},
+			},
+			roots:   x509.NewCertPool(),
+			wantErr: "invalid TSA certificate chain",
+		},
+		{
+			name: "forged root certificate",
+			opts: testhelper.TimestampTokenOptions{
+				TSA:          forgedTSA,
+				Certificates: []*x509.Certificate{forgedTSA.Cert, forgedRoot.Cert},
+			},
+			wantErr: "invalid TSA certificate chain",
+		},
+		{
+			name: "code signing certificate",
+			opts: testhelper.TimestampTokenOptions{
+				TSA:          leaf,
+				Certificates: []*x509.Certificate{leaf.Cert, root.Cert},
+			},
+			wantErr: "extended key usage must not contain CodeSigning eku",
+		},
+		{
+			name: "signingCertificateV2 mismatch",
+			opts: testhelper.TimestampTokenOptions{
+				TSA:                tsa,
+				Certificates:       []*x509.Certificate{tsa.Cert, root.Cert},
+				SigningCertificate: otherTSA.Cert,
+			},
+			wantErr: "signingCertificateV2 attribute does not match the signing certificate",
+		},
+		{
+			name: "signingCertificate mismatch",
+			opts: testhelper.TimestampTokenOptions{
+				TSA:                      tsa,
+				Certificates:             []*x509.Certificate{tsa.Cert, root.Cert},
+				SigningCertificate:       otherTSA.Cert,
+				LegacySigningCertificate: true,
+			},
+			wantErr: "signingCertificate attribute does not match the signing certificate",
+		},
+		{
+			name: "genTime outside of certificate validity",
+			opts: testhelper.TimestampTokenOptions{
+				TSA:          tsa,
+				Certificates: []*x509.Certificate{tsa.Cert, root.Cert},
+				GenTime:      time.Now().AddDate(1, 0, 0),
+			},
+			wantErr: "invalid TSA certificate chain",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Hash = crypto.SHA256
+			tt.opts.HashedMessage = digest(crypto.SHA256, content)
+			if tt.opts.GenTime.IsZero() {
+				tt.opts.GenTime = time.Now()
+			}
+			signedToken, err := ParseSignedToken(createToken(t, tt.opts))
+			if err != nil {
+				t.Fatalf("ParseSignedToken() error = %v", err)
+			}
+			if tt.roots == nil {
+				tt.roots = rootPool(root.Cert)
+			}
+			_, _, err = signedToken.Verify(x509.VerifyOptions{Roots: tt.roots})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseSignedTokenError(t *testing.T) {
+	tests := []struct {
+		name  string
+		token []byte
+	}{
+		{
+			name:  "empty token",
+			token: nil,
+		},
+		{
+			name:  "not a content info",
+			token: []byte{0x02, 0x01, 0x01},
+		},
+		{
+			name: "missing signingCertificate attribute",
+			token: createToken(t, testhelper.TimestampTokenOptions{
+				TSA:                    testhelper.GetRSATimeStampingCertTuple(testhelper.GetRSARootCertificate()),
+				Hash:                   crypto.SHA256,
+				HashedMessage:          digest(crypto.SHA256, nil),
+				GenTime:                time.Now(),
+				OmitSigningCertificate: true,
+			}),
+		},
+		{
+			name: "trailing data",
+			token: append(createToken(t, testhelper.TimestampTokenOptions{
+				TSA:           testhelper.GetRSATimeStampingCertTuple(testhelper.GetRSARootCertificate()),
+				Hash:          crypto.SHA256,
+				HashedMessage: digest(crypto.SHA256, nil),
+				GenTime:       time.Now(),
+			}), 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseSignedToken(tt.token); err == nil {
+				t.Fatal("Expected error but got nil")
+			}
+		})
+	}
+}
+
+func createToken(t *testing.T, opts testhelper.TimestampTokenOptions) []byte {
+	t.Helper()
+	token, err := testhelper.CreateTimestampToken(opts)
+	if err != nil {
+		t.Fatalf("CreateTimestampToken() error = %v", err)
+	}
+	return token
+}
+
+func rootPool(certs ...*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return pool
+}
+
+func digest(hash crypto.Hash, content []byte) []byte {
+	h := hash.New()
+	h.Write(content)
+	return h.Sum(nil)
+}
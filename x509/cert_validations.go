@@ -16,6 +16,7 @@ package x509
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"errors"
@@ -259,6 +260,10 @@ func validateKeyLength(cert *x509.Certificate) error {
 		if key.Params().N.BitLen() < 256 {
 			return fmt.Errorf("certificate with subject %q: ecdsa public key length must be 256 bits or higher", cert.Subject)
 		}
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("certificate with subject %q: ed25519 public key length must be %d bytes", cert.Subject, ed25519.PublicKeySize)
+		}
 	}
 	return nil
 }
@@ -197,6 +197,8 @@ func TestValidCodeSigningChain(t *testing.T) {
 		{"RSA Leaf certificate without EKU", []*x509.Certificate{testhelper.GetRSALeafCertificateWithoutEKU().Cert,
 			testhelper.GetRSARootCertificate().Cert}},
 		{"Open SSL minimum certificate", []*x509.Certificate{openSSLMinimumCert}},
+		{"Ed25519 leaf certificate", []*x509.Certificate{testhelper.GetEd25519LeafCertificate().Cert,
+			testhelper.GetRSARootCertificate().Cert}},
 	}
 
 	signingTime := time.Now()